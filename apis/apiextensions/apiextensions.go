@@ -21,6 +21,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
 	v1beta1 "github.com/crossplane/crossplane/apis/apiextensions/v1beta1"
 )
 
@@ -28,6 +29,7 @@ func init() {
 	// Register the types with the Scheme so the components can map objects to GroupVersionKinds and back
 	AddToSchemes = append(AddToSchemes,
 		v1.AddToScheme,
+		v1alpha1.AddToScheme,
 		v1beta1.AddToScheme,
 	)
 }
@@ -17,12 +17,21 @@ limitations under the License.
 package v1
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math"
 	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -32,12 +41,17 @@ import (
 )
 
 const (
-	errMathNoMultiplier   = "no input is given"
-	errMathInputNonNumber = "input is required to be a number for math transformer"
-	errPatchSetType       = "a patch in a PatchSet cannot be of type PatchSet"
-	errRequiredField      = "%s is required by type %s"
-	errUndefinedPatchSet  = "cannot find PatchSet by name %s"
-	errInvalidPatchType   = "patch type %s is unsupported"
+	errMathNoMultiplier         = "no input is given"
+	errMathInputNonNumber       = "input is required to be a number for math transformer"
+	errPatchSetType             = "a patch in a PatchSet cannot be of type PatchSet"
+	errRequiredField            = "%s is required by type %s"
+	errUndefinedPatchSet        = "cannot find PatchSet by name %s"
+	errInvalidPatchType         = "patch type %s is unsupported"
+	errCombineRequiresVariables = "combine patch types require at least one variable"
+	errStringRegexpCompile      = "cannot compile regexp"
+	errStringDecodeBase64       = "cannot decode base64"
+	errMatchRegexpCompile       = "cannot compile regexp"
+	errMatchInvalidJSON         = "result is not valid JSON"
 
 	errFmtConvertInputTypeNotSupported = "input type %s is not supported"
 	errFmtConversionPairNotSupported   = "conversion from %s to %s is not supported"
@@ -45,8 +59,33 @@ const (
 	errFmtTypeNotSupported             = "transform type %s is not supported"
 	errFmtConfigMissing                = "given type %s requires configuration"
 	errFmtTransformTypeFailed          = "%s transform could not resolve"
+	errFmtTransformTypeInvalid         = "%s transform is invalid"
 	errFmtMapTypeNotSupported          = "type %s is not supported for map transform"
-	errFmtMapNotFound                  = "key %s is not found in map"
+	errFmtMapNotFound                  = "key %s is not found in map and no fallback value is supplied"
+	errFmtMapFallbackToNotSupported    = "fallbackTo %s is not supported"
+	errFmtMapKeyReserved               = "map key %s is reserved for configuring the fallback and cannot be used as a pair"
+	errFmtMapInvalidJSON               = "value for key %s is not valid JSON"
+	errFmtMatchPattern                 = "invalid pattern at index %d"
+	errFmtMatchPatternTypeNotSupported = "pattern type %s is not supported"
+	errFmtMatchFallbackToNotSupported  = "fallbackTo %s is not supported"
+	errFmtMatchTypeNotSupported        = "type %s is not supported for match transform"
+	errFmtMatchNotFound                = "no pattern matches %s and no fallback value is supplied"
+	errFmtCombineStrategyNotSupported  = "combine strategy %s is not supported"
+	errFmtCombineConfigMissing         = "given combine strategy %s requires configuration"
+	errFmtCombineStrategyFailed        = "%s strategy could not combine"
+	errFmtCombineVariable              = "cannot get value of variable at index %d from field path %q of %s"
+	errFmtCombineResourceMissing       = "cannot get value of variable at index %d: composed resource %q is not available"
+	errFmtCombineResourceName          = "variable at index %d: fromResourceName is only supported by type %s"
+	errFmtMathTypeNotSupported         = "math transform type %s is not supported"
+	errFmtMathOperandMissing           = "math transform type %s requires a value"
+	errFmtMathZeroOperand              = "math transform type %s requires a non-zero value"
+	errFmtMathRoundingNotSupported     = "rounding mode %s is not supported"
+	errFmtStringTypeNotSupported       = "string transform type %s is not supported"
+	errFmtStringConfigMissing          = "string transform type %s requires %s"
+	errFmtStringConversionNotSupported = "string conversion %s is not supported"
+	errFmtStringInputNonString         = "input is required to be a string for string transform type %s"
+	errFmtStringRegexpGroupInvalid     = "regexp group %d is invalid; expression has %d groups"
+	errFmtStringRegexpNoMatch          = "regexp %q does not match input"
 )
 
 // CompositionSpec specifies the desired state of the definition.
@@ -71,6 +110,69 @@ type CompositionSpec struct {
 	// this composition will be created.
 	// +optional
 	WriteConnectionSecretsToNamespace *string `json:"writeConnectionSecretsToNamespace,omitempty"`
+
+	// Environment configures the environment from which composed resources
+	// may be patched using FromEnvironmentFieldPath patches.
+	// +optional
+	Environment *EnvironmentConfiguration `json:"environment,omitempty"`
+
+	// DeletionOrder is a list of resource template names. When a composite
+	// resource is deleted the composed resources of these templates are
+	// deleted first, in the listed order. The remaining composed resources are
+	// then deleted in the reverse of the order in which they are applied; i.e.
+	// after any resources that depend on them, and otherwise in reverse
+	// declaration order. Each composed resource is deleted only once the
+	// resource deleted before it no longer exists.
+	// +optional
+	DeletionOrder []string `json:"deletionOrder,omitempty"`
+}
+
+// EnvironmentConfiguration selects the EnvironmentConfigs that form the
+// environment of composite resources that use a Composition.
+type EnvironmentConfiguration struct {
+	// EnvironmentConfigs selects EnvironmentConfigs by reference or by label
+	// selector. The data of all selected EnvironmentConfigs is deep merged in
+	// order to form the environment, with later EnvironmentConfigs taking
+	// precedence. EnvironmentConfigs matched by a selector are merged in
+	// order of their names.
+	// +optional
+	EnvironmentConfigs []EnvironmentSource `json:"environmentConfigs,omitempty"`
+}
+
+// EnvironmentSourceType specifies how an EnvironmentSource selects
+// EnvironmentConfigs.
+type EnvironmentSourceType string
+
+// Accepted EnvironmentSourceTypes.
+const (
+	EnvironmentSourceTypeReference EnvironmentSourceType = "Reference"
+	EnvironmentSourceTypeSelector  EnvironmentSourceType = "Selector"
+)
+
+// An EnvironmentSource selects one or more EnvironmentConfigs.
+type EnvironmentSource struct {
+	// Type specifies whether EnvironmentConfigs are selected by reference or
+	// by label selector.
+	// +optional
+	// +kubebuilder:validation:Enum=Reference;Selector
+	// +kubebuilder:default=Reference
+	Type EnvironmentSourceType `json:"type,omitempty"`
+
+	// Ref is a reference to an EnvironmentConfig by name. Required when type
+	// is Reference.
+	// +optional
+	Ref *EnvironmentSourceReference `json:"ref,omitempty"`
+
+	// Selector selects EnvironmentConfigs by label. Required when type is
+	// Selector. All matching EnvironmentConfigs are selected.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// An EnvironmentSourceReference references an EnvironmentConfig by name.
+type EnvironmentSourceReference struct {
+	// Name of the referenced EnvironmentConfig.
+	Name string `json:"name"`
 }
 
 // InlinePatchSets dereferences PatchSets and includes their patches inline. The
@@ -164,6 +266,110 @@ type ComposedTemplate struct {
 	// default readiness check is to have the "Ready" condition to be "True".
 	// +optional
 	ReadinessChecks []ReadinessCheck `json:"readinessChecks,omitempty"`
+
+	// Condition determines whether this template is included when composing
+	// resources. The condition is evaluated against the composite resource.
+	// Templates without a condition are always included. A composed resource
+	// that was created from this template is deleted if the condition is no
+	// longer met. Only named templates may specify a condition.
+	// +optional
+	Condition *ComposedTemplateCondition `json:"condition,omitempty"`
+
+	// ForEach expands this template into one template per element of an
+	// array field of the composite resource. FromElementFieldPath patches may
+	// be used to patch from each element. Composed resources are identified by
+	// the key of the element they were composed from, and are deleted when
+	// their element is removed from the array. Only named templates may
+	// specify forEach.
+	// +optional
+	ForEach *ForEach `json:"forEach,omitempty"`
+
+	// DependsOn lists the names of other resource templates whose composed
+	// resources must be ready before the composed resource of this template is
+	// applied. Dependencies must not be cyclic, and may not name a template
+	// that specifies forEach. A dependency on a template that is excluded by
+	// its condition is ignored. Only named templates may specify dependencies.
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// DeletionPolicy specifies what will happen to the composed resource of
+	// this template when its composite resource is deleted. The composed
+	// resource is deleted by default. It is instead orphaned, and thus
+	// retained, when the policy is Orphan. The deletion policy of the
+	// composite resource applies when this field is not set.
+	// +optional
+	// +kubebuilder:validation:Enum=Orphan;Delete
+	DeletionPolicy *xpv1.DeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// ForEachKeyType determines how the elements of a ForEach array are
+// identified.
+type ForEachKeyType string
+
+// ForEach key types.
+const (
+	ForEachKeyTypeIndex     ForEachKeyType = "Index"
+	ForEachKeyTypeValue     ForEachKeyType = "Value"
+	ForEachKeyTypeFieldPath ForEachKeyType = "FieldPath"
+)
+
+// ForEach configures how a template is expanded into one template per element
+// of an array field of the composite resource.
+type ForEach struct {
+	// FieldPath of the composite resource array to iterate over. No resources
+	// are composed if the field path does not exist.
+	FieldPath string `json:"fieldPath"`
+
+	// Key determines how each element is identified. Elements are identified
+	// by their index in the array when the key is Index, by their value when
+	// the key is Value, and by the value of the field at the keyFieldPath of
+	// each element when the key is FieldPath. Keys must be unique scalar
+	// values. Using a key other than Index allows elements to be reordered, or
+	// removed from the middle of the array, without affecting the composed
+	// resources of other elements.
+	// +optional
+	// +kubebuilder:validation:Enum=Index;Value;FieldPath
+	// +kubebuilder:default=Index
+	Key ForEachKeyType `json:"key,omitempty"`
+
+	// KeyFieldPath is the path of the field within each element whose value
+	// identifies the element. Required when key is FieldPath.
+	// +optional
+	KeyFieldPath *string `json:"keyFieldPath,omitempty"`
+}
+
+// ComposedTemplateConditionType is used for composed template condition types.
+type ComposedTemplateConditionType string
+
+// The possible values for composed template condition type.
+const (
+	ComposedTemplateConditionTypeFieldPathExists ComposedTemplateConditionType = "FieldPathExists"
+	ComposedTemplateConditionTypeMatchString     ComposedTemplateConditionType = "MatchString"
+	ComposedTemplateConditionTypeMatchInteger    ComposedTemplateConditionType = "MatchInteger"
+	ComposedTemplateConditionTypeMatchTrue       ComposedTemplateConditionType = "MatchTrue"
+	ComposedTemplateConditionTypeMatchFalse      ComposedTemplateConditionType = "MatchFalse"
+)
+
+// A ComposedTemplateCondition is a predicate that is evaluated against a field
+// of the composite resource. A condition is never met if its field path does
+// not exist.
+type ComposedTemplateCondition struct {
+	// Type indicates the type of predicate you'd like to use.
+	// +kubebuilder:validation:Enum=FieldPathExists;MatchString;MatchInteger;MatchTrue;MatchFalse
+	Type ComposedTemplateConditionType `json:"type"`
+
+	// FieldPath of the composite resource whose value will be used.
+	FieldPath string `json:"fieldPath"`
+
+	// MatchString is the value you'd like to match if you're using
+	// "MatchString" type.
+	// +optional
+	MatchString string `json:"matchString,omitempty"`
+
+	// MatchInteger is the value you'd like to match if you're using
+	// "MatchInteger" type.
+	// +optional
+	MatchInteger int64 `json:"matchInteger,omitempty"`
 }
 
 // ReadinessCheckType is used for readiness check types.
@@ -175,13 +381,19 @@ const (
 	ReadinessCheckTypeMatchString  ReadinessCheckType = "MatchString"
 	ReadinessCheckTypeMatchInteger ReadinessCheckType = "MatchInteger"
 	ReadinessCheckTypeNone         ReadinessCheckType = "None"
+
+	ReadinessCheckTypeMatchCondition                 ReadinessCheckType = "MatchCondition"
+	ReadinessCheckTypeMatchTrue                      ReadinessCheckType = "MatchTrue"
+	ReadinessCheckTypeMatchFalse                     ReadinessCheckType = "MatchFalse"
+	ReadinessCheckTypeMatchIntegerGreaterThanOrEqual ReadinessCheckType = "MatchIntegerGreaterThanOrEqual"
+	ReadinessCheckTypeMatchIntegerLessThanOrEqual    ReadinessCheckType = "MatchIntegerLessThanOrEqual"
 )
 
 // ReadinessCheck is used to indicate how to tell whether a resource is ready
 // for consumption
 type ReadinessCheck struct {
 	// Type indicates the type of probe you'd like to use.
-	// +kubebuilder:validation:Enum="MatchString";"MatchInteger";"NonEmpty";"None"
+	// +kubebuilder:validation:Enum="MatchString";"MatchInteger";"NonEmpty";"None";"MatchCondition";"MatchTrue";"MatchFalse";"MatchIntegerGreaterThanOrEqual";"MatchIntegerLessThanOrEqual"
 	Type ReadinessCheckType `json:"type"`
 
 	// FieldPath shows the path of the field whose value will be used.
@@ -193,8 +405,27 @@ type ReadinessCheck struct {
 	MatchString string `json:"matchString,omitempty"`
 
 	// MatchInt is the value you'd like to match if you're using "MatchInt" type.
+	// It is also the bound used by the "MatchIntegerGreaterThanOrEqual" and
+	// "MatchIntegerLessThanOrEqual" types.
 	// +optional
 	MatchInteger int64 `json:"matchInteger,omitempty"`
+
+	// MatchCondition is the condition you'd like to match if you're using
+	// "MatchCondition" type. The fieldPath is not used by this type.
+	// +optional
+	MatchCondition *MatchConditionReadinessCheck `json:"matchCondition,omitempty"`
+}
+
+// MatchConditionReadinessCheck is used to indicate how to tell whether a
+// resource is ready for consumption by matching one of its conditions.
+type MatchConditionReadinessCheck struct {
+	// Type indicates the type of condition you'd like to use.
+	// +kubebuilder:default="Ready"
+	Type xpv1.ConditionType `json:"type"`
+
+	// Status is the status of the condition you'd like to match.
+	// +kubebuilder:default="True"
+	Status corev1.ConditionStatus `json:"status"`
 }
 
 // A PatchType is a type of patch.
@@ -205,6 +436,12 @@ const (
 	PatchTypeFromCompositeFieldPath PatchType = "FromCompositeFieldPath" // Default
 	PatchTypePatchSet               PatchType = "PatchSet"
 	PatchTypeToCompositeFieldPath   PatchType = "ToCompositeFieldPath"
+	PatchTypeCombineFromComposite   PatchType = "CombineFromComposite"
+	PatchTypeCombineToComposite     PatchType = "CombineToComposite"
+
+	PatchTypeFromEnvironmentFieldPath PatchType = "FromEnvironmentFieldPath"
+	PatchTypeFromComposedFieldPath    PatchType = "FromComposedFieldPath"
+	PatchTypeFromElementFieldPath     PatchType = "FromElementFieldPath"
 )
 
 // Patch objects are applied between composite and composed resources. Their
@@ -215,19 +452,35 @@ type Patch struct {
 	// Type sets the patching behaviour to be used. Each patch type may require
 	// its' own fields to be set on the Patch object.
 	// +optional
-	// +kubebuilder:validation:Enum=FromCompositeFieldPath;PatchSet;ToCompositeFieldPath
+	// +kubebuilder:validation:Enum=FromCompositeFieldPath;PatchSet;ToCompositeFieldPath;CombineFromComposite;CombineToComposite;FromEnvironmentFieldPath;FromComposedFieldPath;FromElementFieldPath
 	// +kubebuilder:default=FromCompositeFieldPath
 	Type PatchType `json:"type,omitempty"`
 
 	// FromFieldPath is the path of the field on the resource whose value is
-	// to be used as input. Required when type is FromCompositeFieldPath or
-	// ToCompositeFieldPath.
+	// to be used as input. Required when type is FromCompositeFieldPath,
+	// ToCompositeFieldPath, FromEnvironmentFieldPath, FromComposedFieldPath,
+	// or FromElementFieldPath. The input of a FromElementFieldPath patch is an
+	// object with the fields 'element', 'index', and 'key'.
 	// +optional
 	FromFieldPath *string `json:"fromFieldPath,omitempty"`
 
+	// FromResourceName is the name of the resource template whose composed
+	// resource is to be used as input. The named template must be declared
+	// before the template of this patch. Required when type is
+	// FromComposedFieldPath.
+	// +optional
+	FromResourceName *string `json:"fromResourceName,omitempty"`
+
+	// Combine is the patch configuration for a CombineFromComposite or
+	// CombineToComposite patch. Required when type is CombineFromComposite or
+	// CombineToComposite.
+	// +optional
+	Combine *Combine `json:"combine,omitempty"`
+
 	// ToFieldPath is the path of the field on the resource whose value will
 	// be changed with the result of transforms. Leave empty if you'd like to
-	// propagate to the same path as fromFieldPath.
+	// propagate to the same path as fromFieldPath. Required when type is
+	// CombineFromComposite or CombineToComposite.
 	// +optional
 	ToFieldPath *string `json:"toFieldPath,omitempty"`
 
@@ -263,6 +516,203 @@ type PatchPolicy struct {
 	// +kubebuilder:validation:Enum=Optional;Required
 	// +optional
 	FromFieldPath *FromFieldPathPolicy `json:"fromFieldPath,omitempty"`
+
+	// MergeOptions specifies how to merge the patched value into any value
+	// that already exists at the toFieldPath. The existing value is replaced
+	// if no merge options are specified.
+	// +optional
+	MergeOptions *MergeOptions `json:"mergeOptions,omitempty"`
+}
+
+// GetMergeOptions returns the merge options of this PatchPolicy, if any.
+func (pp *PatchPolicy) GetMergeOptions() *MergeOptions {
+	if pp == nil {
+		return nil
+	}
+	return pp.MergeOptions
+}
+
+// MergeOptions specify how a patched value is merged into any existing value.
+// Objects are always deep merged when merge options are specified; keys that
+// exist only in the existing object are kept.
+type MergeOptions struct {
+	// KeepMapValues specifies that values that already exist in an object
+	// take precedence over patched values with the same key.
+	// +optional
+	KeepMapValues *bool `json:"keepMapValues,omitempty"`
+
+	// AppendSlice specifies that patched array elements are appended to any
+	// existing array, rather than replacing it.
+	// +optional
+	AppendSlice *bool `json:"appendSlice,omitempty"`
+
+	// Deduplicate specifies that patched array elements that are equal to an
+	// element of the existing array are not appended. Only applies when
+	// appendSlice is true.
+	// +optional
+	Deduplicate *bool `json:"deduplicate,omitempty"`
+}
+
+// Merge the supplied patched value into the supplied existing value.
+func (mo *MergeOptions) Merge(existing, patched interface{}) interface{} {
+	switch p := patched.(type) {
+	case map[string]interface{}:
+		e, ok := existing.(map[string]interface{})
+		if !ok {
+			return patched
+		}
+		out := make(map[string]interface{}, len(e)+len(p))
+		for k, v := range e {
+			out[k] = v
+		}
+		for k, v := range p {
+			ev, exists := out[k]
+			if !exists {
+				out[k] = v
+				continue
+			}
+			_, em := ev.(map[string]interface{})
+			_, pm := v.(map[string]interface{})
+			_, es := ev.([]interface{})
+			_, ps := v.([]interface{})
+			switch {
+			case (em && pm) || (es && ps && mo.appendSlice()):
+				out[k] = mo.Merge(ev, v)
+			case mo.keepMapValues():
+				// Keep the existing value.
+			default:
+				out[k] = v
+			}
+		}
+		return out
+	case []interface{}:
+		e, ok := existing.([]interface{})
+		if !ok || !mo.appendSlice() {
+			return patched
+		}
+		out := make([]interface{}, 0, len(e)+len(p))
+		out = append(out, e...)
+		for _, v := range p {
+			if mo.deduplicate() && containsValue(out, v) {
+				continue
+			}
+			out = append(out, v)
+		}
+		return out
+	default:
+		return patched
+	}
+}
+
+func (mo *MergeOptions) keepMapValues() bool {
+	return mo.KeepMapValues != nil && *mo.KeepMapValues
+}
+
+func (mo *MergeOptions) appendSlice() bool {
+	return mo.AppendSlice != nil && *mo.AppendSlice
+}
+
+func (mo *MergeOptions) deduplicate() bool {
+	return mo.Deduplicate != nil && *mo.Deduplicate
+}
+
+func containsValue(s []interface{}, v interface{}) bool {
+	for _, e := range s {
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// A CombineVariable defines the source of a value that is combined with
+// others to form and patch an output value. Currently, this only supports
+// retrieving values from a field path.
+type CombineVariable struct {
+	// FromResourceName is the name of the resource template whose composed
+	// resource this variable is read from. Only supported by
+	// CombineToComposite patches, which otherwise read each variable from the
+	// composed resource of the patch's own template.
+	// +optional
+	FromResourceName *string `json:"fromResourceName,omitempty"`
+
+	// FromFieldPath is the path of the field on the source whose value is
+	// to be used as input.
+	FromFieldPath string `json:"fromFieldPath"`
+
+	// Policy specifies how to handle this variable's fromFieldPath not
+	// existing. 'Optional' means the patch will be a no-op if the specified
+	// fromFieldPath does not exist, while 'Required' means the patch will fail.
+	// Defaults to the fromFieldPath policy of the patch. If the patch does not
+	// specify one, variables of a CombineToComposite patch are 'Required' while
+	// those of a CombineFromComposite patch are 'Optional'.
+	// +kubebuilder:validation:Enum=Optional;Required
+	// +optional
+	Policy *FromFieldPathPolicy `json:"policy,omitempty"`
+}
+
+// A CombineStrategy determines what strategy will be applied to combine
+// variables.
+type CombineStrategy string
+
+// CombineStrategy strategy definitions.
+const (
+	CombineStrategyString CombineStrategy = "string"
+)
+
+// A Combine configures a patch that combines more than one input field into a
+// single output field.
+type Combine struct {
+	// Variables are the list of variables whose values will be retrieved and
+	// combined. Variables are passed to the combine strategy in order.
+	// +kubebuilder:validation:MinItems=1
+	Variables []CombineVariable `json:"variables"`
+
+	// Strategy defines the strategy to use to combine the input variable
+	// values. Currently only string is supported.
+	// +kubebuilder:validation:Enum=string
+	Strategy CombineStrategy `json:"strategy"`
+
+	// String declares that input variables should be combined into a single
+	// string, using the relevant settings for formatting purposes.
+	// +optional
+	String *StringCombine `json:"string,omitempty"`
+}
+
+// Combine calls the appropriate combiner.
+func (c *Combine) Combine(vars []interface{}) (interface{}, error) {
+	var combiner interface {
+		Combine(vars []interface{}) (interface{}, error)
+	}
+
+	switch c.Strategy {
+	case CombineStrategyString:
+		combiner = c.String
+	default:
+		return nil, errors.Errorf(errFmtCombineStrategyNotSupported, string(c.Strategy))
+	}
+
+	// Check for nil interface requires reflection.
+	if reflect.ValueOf(combiner).IsNil() {
+		return nil, errors.Errorf(errFmtCombineConfigMissing, string(c.Strategy))
+	}
+	out, err := combiner.Combine(vars)
+	return out, errors.Wrapf(err, errFmtCombineStrategyFailed, string(c.Strategy))
+}
+
+// A StringCombine combines multiple input values into a single string.
+type StringCombine struct {
+	// Format the input using a Go format string. Variables are supplied as
+	// positional arguments in the order they are declared, so explicit
+	// argument indexes such as %[2]s may be used. See
+	// https://golang.org/pkg/fmt/ for details.
+	Format string `json:"fmt"`
+}
+
+// Combine returns a single output by running a string format with all of its
+// input variables.
+func (s *StringCombine) Combine(vars []interface{}) (interface{}, error) {
+	return fmt.Sprintf(s.Format, vars...), nil
 }
 
 // Apply executes a patching operation between the from and to resources.
@@ -277,6 +727,21 @@ func (c *Patch) Apply(from, to runtime.Object, only ...PatchType) error {
 		return c.applyFromFieldPathPatch(from, to)
 	case PatchTypeToCompositeFieldPath:
 		return c.applyFromFieldPathPatch(to, from)
+	case PatchTypeCombineFromComposite:
+		return c.applyCombineFromCompositePatch(from, to)
+	case PatchTypeCombineToComposite:
+		return c.ApplyCombineToComposite(from, to, nil)
+	case PatchTypeFromEnvironmentFieldPath:
+		// The from resource is the environment, not the composite resource.
+		return c.applyFromFieldPathPatch(from, to)
+	case PatchTypeFromComposedFieldPath:
+		// The from resource is another composed resource of the same
+		// composite resource, not the composite resource.
+		return c.applyFromFieldPathPatch(from, to)
+	case PatchTypeFromElementFieldPath:
+		// The from resource is an element of a ForEach array, not the
+		// composite resource.
+		return c.applyFromFieldPathPatch(from, to)
 	case PatchTypePatchSet:
 		// Already resolved - nothing to do.
 	}
@@ -328,27 +793,164 @@ func (c *Patch) applyFromFieldPathPatch(from, to runtime.Object) error { // noli
 	if err != nil {
 		return err
 	}
+	out, err := c.transform(in)
+	if err != nil {
+		return err
+	}
+
+	return patchFieldValueToObject(*c.ToFieldPath, out, to, c.Policy.GetMergeOptions())
+}
+
+// applyCombineFromCompositePatch patches the supplied composed resource,
+// reading every variable from the supplied composite resource.
+func (c *Patch) applyCombineFromCompositePatch(cp, cd runtime.Object) error {
+	return c.applyCombineFromVariablesPatch(func(i int, v CombineVariable) (runtime.Object, string, error) {
+		if v.FromResourceName != nil {
+			return nil, "", errors.Errorf(errFmtCombineResourceName, i, PatchTypeCombineToComposite)
+		}
+		return cp, "the composite resource", nil
+	}, cd)
+}
+
+// ApplyCombineToComposite applies a CombineToComposite patch to the supplied
+// composite resource. A variable that names a resource template is read from
+// the supplied composed resources, keyed by the name of their template. Any
+// other variable is read from the supplied composed resource.
+func (c *Patch) ApplyCombineToComposite(cp, cd runtime.Object, composed map[string]runtime.Object) error {
+	return c.applyCombineFromVariablesPatch(func(i int, v CombineVariable) (runtime.Object, string, error) {
+		if v.FromResourceName == nil {
+			return cd, "the composed resource", nil
+		}
+		from, ok := composed[*v.FromResourceName]
+		if !ok {
+			return nil, "", errors.Errorf(errFmtCombineResourceMissing, i, *v.FromResourceName)
+		}
+		return from, fmt.Sprintf("composed resource %q", *v.FromResourceName), nil
+	}, cp)
+}
+
+// A variableSource returns the resource from which the supplied variable is
+// read, and a description of that resource for use in errors.
+type variableSource func(i int, v CombineVariable) (runtime.Object, string, error)
+
+// applyCombineFromVariablesPatch patches the "to" resource, taking a list of
+// input variables and combining them into a single output value. The single
+// output value may then be further transformed if they are defined on the
+// patch.
+func (c *Patch) applyCombineFromVariablesPatch(source variableSource, to runtime.Object) error {
+	// Combine patch requires configuration
+	if c.Combine == nil {
+		return errors.Errorf(errRequiredField, "Combine", c.Type)
+	}
+	// Destination field path is required since we can't default to multiple
+	// fields.
+	if c.ToFieldPath == nil {
+		return errors.Errorf(errRequiredField, "ToFieldPath", c.Type)
+	}
+
+	vl := len(c.Combine.Variables)
+	if vl < 1 {
+		return errors.New(errCombineRequiresVariables)
+	}
+
+	in := make([]interface{}, vl)
+	for i, v := range c.Combine.Variables {
+		from, desc, err := source(i, v)
+		if err != nil {
+			return err
+		}
+		fromMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(from)
+		if err != nil {
+			return err
+		}
+		val, err := fieldpath.Pave(fromMap).GetValue(v.FromFieldPath)
+		if IsOptionalFieldPathNotFound(err, c.variablePolicy(v)) {
+			// A single optional variable that does not exist renders the
+			// combined value meaningless, so the patch becomes a no-op.
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, errFmtCombineVariable, i, v.FromFieldPath, desc)
+		}
+		in[i] = val
+	}
+
+	cb, err := c.Combine.Combine(in)
+	if err != nil {
+		return err
+	}
+
+	out, err := c.transform(cb)
+	if err != nil {
+		return err
+	}
+
+	return patchFieldValueToObject(*c.ToFieldPath, out, to, c.Policy.GetMergeOptions())
+}
+
+// variablePolicy returns the policy that applies to the supplied combine
+// variable. A variable's own policy takes precedence over that of its patch.
+// Like other patches from the composite resource, CombineFromComposite
+// variables are optional unless either policy says otherwise. CombineToComposite
+// variables are required by default, so that a composite resource is not
+// reported ready while a composed resource is missing an input.
+func (c *Patch) variablePolicy(v CombineVariable) *PatchPolicy {
+	switch {
+	case v.Policy != nil:
+		return &PatchPolicy{FromFieldPath: v.Policy}
+	case c.Policy != nil && c.Policy.FromFieldPath != nil:
+		return c.Policy
+	case c.Type != PatchTypeCombineToComposite:
+		return c.Policy
+	}
+	required := FromFieldPathPolicyRequired
+	return &PatchPolicy{FromFieldPath: &required}
+}
+
+// transform runs the supplied input through the patch's transforms, in order.
+func (c *Patch) transform(in interface{}) (interface{}, error) {
+	var err error
 	out := in
 	for i, f := range c.Transforms {
 		if out, err = f.Transform(out); err != nil {
-			return errors.Wrapf(err, errFmtTransformAtIndex, i)
+			return nil, errors.Wrapf(err, errFmtTransformAtIndex, i)
 		}
 	}
+	return out, nil
+}
 
+// patchFieldValueToObject sets the supplied value at the supplied field path
+// of the supplied object, merging it into any existing value if merge options
+// are supplied.
+func patchFieldValueToObject(fieldPath string, value interface{}, to runtime.Object, mo *MergeOptions) error {
 	if u, ok := to.(interface{ UnstructuredContent() map[string]interface{} }); ok {
-		return fieldpath.Pave(u.UnstructuredContent()).SetValue(*c.ToFieldPath, out)
+		return setFieldValue(fieldpath.Pave(u.UnstructuredContent()), fieldPath, value, mo)
 	}
 
 	toMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(to)
 	if err != nil {
 		return err
 	}
-	if err := fieldpath.Pave(toMap).SetValue(*c.ToFieldPath, out); err != nil {
+	if err := setFieldValue(fieldpath.Pave(toMap), fieldPath, value, mo); err != nil {
 		return err
 	}
 	return runtime.DefaultUnstructuredConverter.FromUnstructured(toMap, to)
 }
 
+func setFieldValue(p *fieldpath.Paved, fieldPath string, value interface{}, mo *MergeOptions) error {
+	if mo == nil {
+		return p.SetValue(fieldPath, value)
+	}
+	existing, err := p.GetValue(fieldPath)
+	if fieldpath.IsNotFound(err) {
+		return p.SetValue(fieldPath, value)
+	}
+	if err != nil {
+		return err
+	}
+	return p.SetValue(fieldPath, mo.Merge(existing, value))
+}
+
 // IsOptionalFieldPathNotFound returns true if the supplied error indicates a
 // field path was not found, and the supplied policy indicates a patch from that
 // field path was optional.
@@ -374,6 +976,7 @@ const (
 	TransformTypeMath    TransformType = "math"
 	TransformTypeString  TransformType = "string"
 	TransformTypeConvert TransformType = "convert"
+	TransformTypeMatch   TransformType = "match"
 )
 
 // Transform is a unit of process whose input is transformed into an output with
@@ -381,7 +984,7 @@ const (
 type Transform struct {
 
 	// Type of the transform to be run.
-	// +kubebuilder:validation:Enum=map;math;string;convert
+	// +kubebuilder:validation:Enum=map;math;string;convert;match
 	Type TransformType `json:"type"`
 
 	// Math is used to transform the input via mathematical operations such as
@@ -389,7 +992,10 @@ type Transform struct {
 	// +optional
 	Math *MathTransform `json:"math,omitempty"`
 
-	// Map uses the input as a key in the given map and returns the value.
+	// Map uses the input as a key in the given map and returns the value. The
+	// keys fallbackValue and fallbackTo are reserved; they configure what is
+	// returned if the input is not a key of the map, like those of a match
+	// transform. A map whose fallbackTo is neither Value nor Input is invalid.
 	// +optional
 	Map *MapTransform `json:"map,omitempty"`
 
@@ -401,108 +1007,731 @@ type Transform struct {
 	// Convert is used to cast the input into the given output type.
 	// +optional
 	Convert *ConvertTransform `json:"convert,omitempty"`
+
+	// Match returns the result of the first of the given patterns to match the
+	// input, optionally falling back to a default value.
+	// +optional
+	Match *MatchTransform `json:"match,omitempty"`
 }
 
-// Transform calls the appropriate Transformer.
-func (t *Transform) Transform(input interface{}) (interface{}, error) {
-	var transformer interface {
-		Resolve(input interface{}) (interface{}, error)
-	}
+// A transformer resolves the output of a Transform given its input.
+type transformer interface {
+	Resolve(input interface{}) (interface{}, error)
+}
+
+// A validator may be implemented by a transformer that is able to validate its
+// configuration before it is asked to resolve any input.
+type validator interface {
+	Validate() error
+}
+
+// transformer returns the appropriate transformer for this Transform's type.
+func (t *Transform) transformer() (transformer, error) {
+	var tr transformer
 	switch t.Type {
 	case TransformTypeMath:
-		transformer = t.Math
+		tr = t.Math
 	case TransformTypeMap:
-		transformer = t.Map
+		tr = t.Map
 	case TransformTypeString:
-		transformer = t.String
+		tr = t.String
 	case TransformTypeConvert:
-		transformer = t.Convert
+		tr = t.Convert
+	case TransformTypeMatch:
+		tr = t.Match
 	default:
 		return nil, errors.Errorf(errFmtTypeNotSupported, string(t.Type))
 	}
 	// An interface equals nil only if both the type and value are nil. Above,
-	// even if t.<Type> is nil, its type is assigned to "transformer" but we're
+	// even if t.<Type> is nil, its type is assigned to "tr" but we're
 	// interested in whether only the value is nil or not.
-	if reflect.ValueOf(transformer).IsNil() {
+	if reflect.ValueOf(tr).IsNil() {
 		return nil, errors.Errorf(errFmtConfigMissing, string(t.Type))
 	}
-	out, err := transformer.Resolve(input)
+	return tr, nil
+}
+
+// Validate this Transform. Validation is intended to catch configuration
+// errors before any input is transformed, e.g. at composition validation time.
+func (t *Transform) Validate() error {
+	tr, err := t.transformer()
+	if err != nil {
+		return err
+	}
+	v, ok := tr.(validator)
+	if !ok {
+		return nil
+	}
+	return errors.Wrapf(v.Validate(), errFmtTransformTypeInvalid, string(t.Type))
+}
+
+// Transform calls the appropriate Transformer.
+func (t *Transform) Transform(input interface{}) (interface{}, error) {
+	tr, err := t.transformer()
+	if err != nil {
+		return nil, err
+	}
+	out, err := tr.Resolve(input)
 	return out, errors.Wrapf(err, errFmtTransformTypeFailed, string(t.Type))
 }
 
+// MathTransformType is the type of operation a MathTransform performs.
+type MathTransformType string
+
+// Accepted MathTransformTypes.
+const (
+	MathTransformTypeMultiply MathTransformType = "Multiply"
+	MathTransformTypeAdd      MathTransformType = "Add"
+	MathTransformTypeSubtract MathTransformType = "Subtract"
+	MathTransformTypeDivide   MathTransformType = "Divide"
+	MathTransformTypeModulo   MathTransformType = "Modulo"
+	MathTransformTypeClampMin MathTransformType = "ClampMin"
+	MathTransformTypeClampMax MathTransformType = "ClampMax"
+)
+
+// MathRoundingMode specifies how a MathTransform rounds a fractional result to
+// an integer.
+type MathRoundingMode string
+
+// Accepted MathRoundingModes.
+const (
+	MathRoundingModeTruncate MathRoundingMode = "Truncate"
+	MathRoundingModeFloor    MathRoundingMode = "Floor"
+	MathRoundingModeCeil     MathRoundingMode = "Ceil"
+	MathRoundingModeRound    MathRoundingMode = "Round"
+)
+
 // MathTransform conducts mathematical operations on the input with the given
 // configuration in its properties.
 type MathTransform struct {
+	// Type of the mathematical operation to perform. Each type requires the
+	// field of the same name to be set; e.g. the Add type requires add.
+	// Defaults to Multiply.
+	// +optional
+	// +kubebuilder:validation:Enum=Multiply;Add;Subtract;Divide;Modulo;ClampMin;ClampMax
+	Type MathTransformType `json:"type,omitempty"`
+
 	// Multiply the value.
 	// +optional
 	Multiply *int64 `json:"multiply,omitempty"`
+
+	// Add to the value.
+	// +optional
+	Add *int64 `json:"add,omitempty"`
+
+	// Subtract from the value.
+	// +optional
+	Subtract *int64 `json:"subtract,omitempty"`
+
+	// Divide the value. Must not be zero.
+	// +optional
+	Divide *int64 `json:"divide,omitempty"`
+
+	// Modulo returns the remainder of dividing the value. Must not be zero.
+	// +optional
+	Modulo *int64 `json:"modulo,omitempty"`
+
+	// ClampMin returns this value if the input is less than it.
+	// +optional
+	ClampMin *int64 `json:"clampMin,omitempty"`
+
+	// ClampMax returns this value if the input is greater than it.
+	// +optional
+	ClampMax *int64 `json:"clampMax,omitempty"`
+
+	// Round a fractional result to an integer using the supplied mode. Integer
+	// input always produces integer output; an integer division that has a
+	// remainder is truncated unless a different mode is supplied. Floating
+	// point input produces floating point output unless a mode is supplied.
+	// +optional
+	// +kubebuilder:validation:Enum=Truncate;Floor;Ceil;Round
+	Round *MathRoundingMode `json:"round,omitempty"`
+}
+
+// GetType returns the type of this MathTransform, defaulting to Multiply.
+func (m *MathTransform) GetType() MathTransformType {
+	if m.Type == "" {
+		return MathTransformTypeMultiply
+	}
+	return m.Type
+}
+
+// operand returns the operand of this MathTransform's type. It returns nil if
+// the operand is not set, and an error if the type is not supported.
+func (m *MathTransform) operand() (*int64, error) {
+	switch m.GetType() {
+	case MathTransformTypeMultiply:
+		return m.Multiply, nil
+	case MathTransformTypeAdd:
+		return m.Add, nil
+	case MathTransformTypeSubtract:
+		return m.Subtract, nil
+	case MathTransformTypeDivide:
+		return m.Divide, nil
+	case MathTransformTypeModulo:
+		return m.Modulo, nil
+	case MathTransformTypeClampMin:
+		return m.ClampMin, nil
+	case MathTransformTypeClampMax:
+		return m.ClampMax, nil
+	default:
+		return nil, errors.Errorf(errFmtMathTypeNotSupported, string(m.Type))
+	}
+}
+
+// Validate this MathTransform.
+func (m *MathTransform) Validate() error {
+	op, err := m.operand()
+	if err != nil {
+		return err
+	}
+	if op == nil {
+		if m.GetType() == MathTransformTypeMultiply {
+			return errors.New(errMathNoMultiplier)
+		}
+		return errors.Errorf(errFmtMathOperandMissing, string(m.GetType()))
+	}
+	if *op == 0 && (m.GetType() == MathTransformTypeDivide || m.GetType() == MathTransformTypeModulo) {
+		return errors.Errorf(errFmtMathZeroOperand, string(m.GetType()))
+	}
+	if m.Round != nil {
+		switch *m.Round {
+		case MathRoundingModeTruncate, MathRoundingModeFloor, MathRoundingModeCeil, MathRoundingModeRound:
+		default:
+			return errors.Errorf(errFmtMathRoundingNotSupported, string(*m.Round))
+		}
+	}
+	return nil
 }
 
 // Resolve runs the Math transform.
 func (m *MathTransform) Resolve(input interface{}) (interface{}, error) {
-	if m.Multiply == nil {
-		return nil, errors.New(errMathNoMultiplier)
+	if err := m.Validate(); err != nil {
+		return nil, err
 	}
+	op, _ := m.operand()
 	switch i := input.(type) {
 	case int64:
-		return *m.Multiply * i, nil
+		return m.resolveInt(i, *op), nil
 	case int:
-		return *m.Multiply * int64(i), nil
+		return m.resolveInt(int64(i), *op), nil
+	case float64:
+		return m.resolveFloat(i, float64(*op)), nil
 	default:
 		return nil, errors.New(errMathInputNonNumber)
 	}
 }
 
+func (m *MathTransform) resolveInt(i, op int64) int64 {
+	switch m.GetType() {
+	case MathTransformTypeAdd:
+		return i + op
+	case MathTransformTypeSubtract:
+		return i - op
+	case MathTransformTypeDivide:
+		if i%op == 0 || m.Round == nil || *m.Round == MathRoundingModeTruncate {
+			return i / op
+		}
+		return round(float64(i)/float64(op), *m.Round)
+	case MathTransformTypeModulo:
+		return i % op
+	case MathTransformTypeClampMin:
+		if i < op {
+			return op
+		}
+		return i
+	case MathTransformTypeClampMax:
+		if i > op {
+			return op
+		}
+		return i
+	default:
+		return i * op
+	}
+}
+
+func (m *MathTransform) resolveFloat(i, op float64) interface{} {
+	var out float64
+	switch m.GetType() {
+	case MathTransformTypeAdd:
+		out = i + op
+	case MathTransformTypeSubtract:
+		out = i - op
+	case MathTransformTypeDivide:
+		out = i / op
+	case MathTransformTypeModulo:
+		out = math.Mod(i, op)
+	case MathTransformTypeClampMin:
+		out = math.Max(i, op)
+	case MathTransformTypeClampMax:
+		out = math.Min(i, op)
+	default:
+		out = i * op
+	}
+	if m.Round == nil {
+		return out
+	}
+	return round(out, *m.Round)
+}
+
+// round the supplied float to an int64 using the supplied mode.
+func round(f float64, mode MathRoundingMode) int64 {
+	switch mode {
+	case MathRoundingModeFloor:
+		return int64(math.Floor(f))
+	case MathRoundingModeCeil:
+		return int64(math.Ceil(f))
+	case MathRoundingModeRound:
+		return int64(math.Round(f))
+	default:
+		return int64(math.Trunc(f))
+	}
+}
+
 // MapTransform returns a value for the input from the given map.
 type MapTransform struct {
 	// TODO(negz): Are Pairs really optional if a MapTransform was specified?
 
-	// Pairs is the map that will be used for transform.
+	// Pairs is the map that will be used for transform. Values may be any
+	// valid JSON, e.g. strings, numbers, arrays, or objects.
 	// +optional
-	Pairs map[string]string `json:",inline"`
+	Pairs map[string]extv1.JSON `json:",inline"`
+
+	// FallbackValue is returned if the input is not a key of the map and
+	// fallbackTo is Value. It may be any valid JSON. The transform returns an
+	// error if the input is not a key of the map and no fallback value is
+	// supplied.
+	FallbackValue *extv1.JSON `json:"-"`
+
+	// FallbackTo determines what is returned if the input is not a key of the
+	// map; either the fallbackValue or the input itself. Defaults to Value.
+	FallbackTo MatchFallbackTo `json:"-"`
 }
 
+// Keys of a MapTransform that configure its fallback, rather than being pairs.
+const (
+	mapKeyFallbackValue = "fallbackValue"
+	mapKeyFallbackTo    = "fallbackTo"
+)
+
 // NOTE(negz): The Kubernetes JSON decoder doesn't seem to like inlining a map
 // into a struct - doing so results in a seemingly successful unmarshal of the
 // data, but an empty map. We must keep the ,inline tag nevertheless in order to
 // trick the CRD generator into thinking MapTransform is an arbitrary map (i.e.
-// generating a validation schema with string additionalProperties), but the
-// actual marshalling is handled by the marshal methods below.
+// generating a validation schema with arbitrary additionalProperties), but the
+// actual marshalling is handled by the marshal methods below. A structural
+// schema can't specify both properties and additionalProperties, so the
+// fallback fields are marshalled as reserved keys of the same map.
 
 // UnmarshalJSON into this MapTransform.
 func (m *MapTransform) UnmarshalJSON(b []byte) error {
-	return json.Unmarshal(b, &m.Pairs)
+	if err := json.Unmarshal(b, &m.Pairs); err != nil {
+		return err
+	}
+	if v, ok := m.Pairs[mapKeyFallbackValue]; ok {
+		m.FallbackValue = &v
+		delete(m.Pairs, mapKeyFallbackValue)
+	}
+	// A fallbackTo that is not a supported value is left as a pair, so that
+	// Validate can report that the key is reserved.
+	if v, ok := m.Pairs[mapKeyFallbackTo]; ok {
+		var to MatchFallbackTo
+		if err := json.Unmarshal(v.Raw, &to); err == nil && (to == MatchFallbackToValue || to == MatchFallbackToInput) {
+			m.FallbackTo = to
+			delete(m.Pairs, mapKeyFallbackTo)
+		}
+	}
+	return nil
 }
 
 // MarshalJSON from this MapTransform.
 func (m MapTransform) MarshalJSON() ([]byte, error) {
-	return json.Marshal(m.Pairs)
+	if m.FallbackValue == nil && m.FallbackTo == "" {
+		return json.Marshal(m.Pairs)
+	}
+	out := make(map[string]extv1.JSON, len(m.Pairs)+2)
+	for k, v := range m.Pairs {
+		out[k] = v
+	}
+	if m.FallbackValue != nil {
+		out[mapKeyFallbackValue] = *m.FallbackValue
+	}
+	if m.FallbackTo != "" {
+		out[mapKeyFallbackTo] = extv1.JSON{Raw: []byte(fmt.Sprintf("%q", m.FallbackTo))}
+	}
+	return json.Marshal(out)
+}
+
+// Validate this MapTransform.
+func (m *MapTransform) Validate() error {
+	for _, k := range []string{mapKeyFallbackValue, mapKeyFallbackTo} {
+		if _, ok := m.Pairs[k]; ok {
+			return errors.Errorf(errFmtMapKeyReserved, k)
+		}
+	}
+	switch m.FallbackTo {
+	case "", MatchFallbackToValue, MatchFallbackToInput:
+		return nil
+	default:
+		return errors.Errorf(errFmtMapFallbackToNotSupported, string(m.FallbackTo))
+	}
 }
 
 // Resolve runs the Map transform.
 func (m *MapTransform) Resolve(input interface{}) (interface{}, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
 	switch i := input.(type) {
 	case string:
-		val, ok := m.Pairs[i]
-		if !ok {
+		p, ok := m.Pairs[i]
+		if !ok && m.FallbackTo == MatchFallbackToInput {
+			return input, nil
+		}
+		if !ok && m.FallbackValue == nil {
 			return nil, errors.Errorf(errFmtMapNotFound, i)
 		}
-		return val, nil
+		if !ok {
+			p = *m.FallbackValue
+		}
+		var val interface{}
+		err := json.Unmarshal(p.Raw, &val)
+		return val, errors.Wrapf(err, errFmtMapInvalidJSON, i)
 	default:
 		return nil, errors.Errorf(errFmtMapTypeNotSupported, reflect.TypeOf(input).String())
 	}
 }
 
+// MatchTransformPatternType is the type of a MatchTransformPattern.
+type MatchTransformPatternType string
+
+// Accepted MatchTransformPatternTypes.
+const (
+	MatchTransformPatternTypeLiteral MatchTransformPatternType = "Literal"
+	MatchTransformPatternTypeRegexp  MatchTransformPatternType = "Regexp"
+)
+
+// MatchFallbackTo specifies what a MatchTransform or MapTransform returns when
+// it has no result for its input.
+type MatchFallbackTo string
+
+// Accepted MatchFallbackTos.
+const (
+	MatchFallbackToValue MatchFallbackTo = "Value"
+	MatchFallbackToInput MatchFallbackTo = "Input"
+)
+
+// A MatchTransform returns the result of the first of its patterns to match
+// the input.
+type MatchTransform struct {
+	// Patterns are evaluated in order. The result of the first pattern that
+	// matches the input is returned.
+	// +optional
+	Patterns []MatchTransformPattern `json:"patterns,omitempty"`
+
+	// FallbackValue is returned if no pattern matches the input and fallbackTo
+	// is Value. It may be any valid JSON. The transform returns an error if no
+	// pattern matches and no fallback value is supplied.
+	// +optional
+	FallbackValue *extv1.JSON `json:"fallbackValue,omitempty"`
+
+	// FallbackTo determines what is returned if no pattern matches the input;
+	// either the fallbackValue or the input itself. Defaults to Value.
+	// +optional
+	// +kubebuilder:validation:Enum=Value;Input
+	FallbackTo MatchFallbackTo `json:"fallbackTo,omitempty"`
+}
+
+// A MatchTransformPattern is a pattern that a MatchTransform may match.
+type MatchTransformPattern struct {
+	// Type of the pattern. Literal patterns match input that is exactly equal
+	// to the literal. Regexp patterns match input that matches the regular
+	// expression. Defaults to Literal.
+	// +optional
+	// +kubebuilder:validation:Enum=Literal;Regexp
+	Type MatchTransformPatternType `json:"type,omitempty"`
+
+	// Literal exactly matches the input string. Required by type Literal.
+	// +optional
+	Literal *string `json:"literal,omitempty"`
+
+	// Regexp matches the input string. Required by type Regexp. See
+	// https://pkg.go.dev/regexp/ for details.
+	// +optional
+	Regexp *string `json:"regexp,omitempty"`
+
+	// Result is returned if this pattern matches. It may be any valid JSON.
+	Result extv1.JSON `json:"result"`
+}
+
+// GetType returns the type of this MatchTransformPattern, defaulting to
+// Literal.
+func (p *MatchTransformPattern) GetType() MatchTransformPatternType {
+	if p.Type == "" {
+		return MatchTransformPatternTypeLiteral
+	}
+	return p.Type
+}
+
+// Validate this MatchTransformPattern.
+func (p *MatchTransformPattern) Validate() error {
+	switch p.GetType() {
+	case MatchTransformPatternTypeLiteral:
+		if p.Literal == nil {
+			return errors.Errorf(errRequiredField, "literal", string(p.GetType()))
+		}
+	case MatchTransformPatternTypeRegexp:
+		if p.Regexp == nil {
+			return errors.Errorf(errRequiredField, "regexp", string(p.GetType()))
+		}
+		if _, err := compileRegexp(*p.Regexp); err != nil {
+			return errors.Wrap(err, errMatchRegexpCompile)
+		}
+	default:
+		return errors.Errorf(errFmtMatchPatternTypeNotSupported, string(p.Type))
+	}
+	return nil
+}
+
+// matches returns true if this MatchTransformPattern matches the supplied
+// input. The pattern must be valid.
+func (p *MatchTransformPattern) matches(input string) bool {
+	if p.GetType() == MatchTransformPatternTypeRegexp {
+		re, err := compileRegexp(*p.Regexp)
+		return err == nil && re.MatchString(input)
+	}
+	return *p.Literal == input
+}
+
+// regexps caches compiled regular expressions by their source. Transforms are
+// resolved every time a composite resource is reconciled, so we compile each
+// expression only once rather than every time it is used.
+var regexps sync.Map
+
+// compileRegexp returns the supplied regular expression, compiled.
+func compileRegexp(expr string) (*regexp.Regexp, error) {
+	if re, ok := regexps.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	regexps.Store(expr, re)
+	return re, nil
+}
+
+// Validate this MatchTransform.
+func (m *MatchTransform) Validate() error {
+	for i := range m.Patterns {
+		if err := m.Patterns[i].Validate(); err != nil {
+			return errors.Wrapf(err, errFmtMatchPattern, i)
+		}
+	}
+	switch m.FallbackTo {
+	case "", MatchFallbackToValue, MatchFallbackToInput:
+	default:
+		return errors.Errorf(errFmtMatchFallbackToNotSupported, string(m.FallbackTo))
+	}
+	return nil
+}
+
+// Resolve runs the Match transform.
+func (m *MatchTransform) Resolve(input interface{}) (interface{}, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	in, ok := input.(string)
+	if !ok {
+		return nil, errors.Errorf(errFmtMatchTypeNotSupported, reflect.TypeOf(input).String())
+	}
+
+	var result *extv1.JSON
+	for i := range m.Patterns {
+		if m.Patterns[i].matches(in) {
+			result = &m.Patterns[i].Result
+			break
+		}
+	}
+
+	if result == nil {
+		if m.FallbackTo == MatchFallbackToInput {
+			return input, nil
+		}
+		if m.FallbackValue == nil {
+			return nil, errors.Errorf(errFmtMatchNotFound, in)
+		}
+		result = m.FallbackValue
+	}
+
+	var val interface{}
+	err := json.Unmarshal(result.Raw, &val)
+	return val, errors.Wrap(err, errMatchInvalidJSON)
+}
+
+// StringTransformType is the type of operation a StringTransform performs.
+type StringTransformType string
+
+// Accepted StringTransformTypes.
+const (
+	StringTransformTypeFormat     StringTransformType = "Format"
+	StringTransformTypeConvert    StringTransformType = "Convert"
+	StringTransformTypeTrimPrefix StringTransformType = "TrimPrefix"
+	StringTransformTypeTrimSuffix StringTransformType = "TrimSuffix"
+	StringTransformTypeRegexp     StringTransformType = "Regexp"
+)
+
+// StringConversionType is the type of conversion a StringTransform of type
+// Convert performs.
+type StringConversionType string
+
+// Accepted StringConversionTypes.
+const (
+	StringConversionTypeToUpper    StringConversionType = "ToUpper"
+	StringConversionTypeToLower    StringConversionType = "ToLower"
+	StringConversionTypeToBase64   StringConversionType = "ToBase64"
+	StringConversionTypeFromBase64 StringConversionType = "FromBase64"
+	StringConversionTypeToSha256   StringConversionType = "ToSha256"
+)
+
 // A StringTransform returns a string given the supplied input.
 type StringTransform struct {
+	// Type of the string transform to perform. Defaults to Format.
+	// +optional
+	// +kubebuilder:validation:Enum=Format;Convert;TrimPrefix;TrimSuffix;Regexp
+	Type StringTransformType `json:"type,omitempty"`
+
 	// Format the input using a Go format string. See
-	// https://golang.org/pkg/fmt/ for details.
-	Format string `json:"fmt"`
+	// https://golang.org/pkg/fmt/ for details. Required by type Format.
+	// +optional
+	Format string `json:"fmt,omitempty"`
+
+	// Convert the input string. Required by type Convert.
+	// +optional
+	// +kubebuilder:validation:Enum=ToUpper;ToLower;ToBase64;FromBase64;ToSha256
+	Convert *StringConversionType `json:"convert,omitempty"`
+
+	// Trim the supplied prefix or suffix from the input string. Required by
+	// types TrimPrefix and TrimSuffix.
+	// +optional
+	Trim *string `json:"trim,omitempty"`
+
+	// Regexp extracts a match from the input string. Required by type Regexp.
+	// +optional
+	Regexp *StringTransformRegexp `json:"regexp,omitempty"`
+}
+
+// A StringTransformRegexp extracts a match from the input using a regular
+// expression.
+type StringTransformRegexp struct {
+	// Match string. May optionally include submatches, aka capture groups.
+	// See https://pkg.go.dev/regexp/ for details.
+	Match string `json:"match"`
+
+	// Group number to match. 0 (the default) matches the entire expression.
+	// +optional
+	Group *int `json:"group,omitempty"`
+}
+
+// GetType returns the type of this StringTransform, defaulting to Format.
+func (s *StringTransform) GetType() StringTransformType {
+	if s.Type == "" {
+		return StringTransformTypeFormat
+	}
+	return s.Type
+}
+
+// Validate this StringTransform.
+func (s *StringTransform) Validate() error {
+	switch s.GetType() {
+	case StringTransformTypeFormat:
+		if s.Format == "" {
+			return errors.Errorf(errFmtStringConfigMissing, string(s.GetType()), "fmt")
+		}
+	case StringTransformTypeConvert:
+		if s.Convert == nil {
+			return errors.Errorf(errFmtStringConfigMissing, string(s.GetType()), "convert")
+		}
+		switch *s.Convert {
+		case StringConversionTypeToUpper, StringConversionTypeToLower, StringConversionTypeToBase64, StringConversionTypeFromBase64, StringConversionTypeToSha256:
+		default:
+			return errors.Errorf(errFmtStringConversionNotSupported, string(*s.Convert))
+		}
+	case StringTransformTypeTrimPrefix, StringTransformTypeTrimSuffix:
+		if s.Trim == nil {
+			return errors.Errorf(errFmtStringConfigMissing, string(s.GetType()), "trim")
+		}
+	case StringTransformTypeRegexp:
+		if s.Regexp == nil {
+			return errors.Errorf(errFmtStringConfigMissing, string(s.GetType()), "regexp")
+		}
+		re, err := compileRegexp(s.Regexp.Match)
+		if err != nil {
+			return errors.Wrap(err, errStringRegexpCompile)
+		}
+		if g := s.Regexp.group(); g < 0 || g > re.NumSubexp() {
+			return errors.Errorf(errFmtStringRegexpGroupInvalid, g, re.NumSubexp())
+		}
+	default:
+		return errors.Errorf(errFmtStringTypeNotSupported, string(s.Type))
+	}
+	return nil
 }
 
 // Resolve runs the String transform.
 func (s *StringTransform) Resolve(input interface{}) (interface{}, error) {
-	return fmt.Sprintf(s.Format, input), nil
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	if s.GetType() == StringTransformTypeFormat {
+		return fmt.Sprintf(s.Format, input), nil
+	}
+
+	in, ok := input.(string)
+	if !ok {
+		return nil, errors.Errorf(errFmtStringInputNonString, string(s.GetType()))
+	}
+	switch s.GetType() {
+	case StringTransformTypeConvert:
+		return stringConvert(in, *s.Convert)
+	case StringTransformTypeTrimPrefix:
+		return strings.TrimPrefix(in, *s.Trim), nil
+	case StringTransformTypeTrimSuffix:
+		return strings.TrimSuffix(in, *s.Trim), nil
+	default:
+		// Validate has already ensured this is a Regexp transform whose
+		// expression compiles.
+		re, _ := compileRegexp(s.Regexp.Match)
+		groups := re.FindStringSubmatch(in)
+		if groups == nil {
+			return nil, errors.Errorf(errFmtStringRegexpNoMatch, s.Regexp.Match)
+		}
+		return groups[s.Regexp.group()], nil
+	}
+}
+
+func (r *StringTransformRegexp) group() int {
+	if r.Group == nil {
+		return 0
+	}
+	return *r.Group
+}
+
+func stringConvert(in string, c StringConversionType) (interface{}, error) {
+	switch c {
+	case StringConversionTypeToUpper:
+		return strings.ToUpper(in), nil
+	case StringConversionTypeToLower:
+		return strings.ToLower(in), nil
+	case StringConversionTypeToBase64:
+		return base64.StdEncoding.EncodeToString([]byte(in)), nil
+	case StringConversionTypeFromBase64:
+		out, err := base64.StdEncoding.DecodeString(in)
+		return string(out), errors.Wrap(err, errStringDecodeBase64)
+	default:
+		sum := sha256.Sum256([]byte(in))
+		return hex.EncodeToString(sum[:]), nil
+	}
 }
 
 // The list of supported ConvertTransform input and output types.
@@ -608,6 +1837,7 @@ const (
 	ConnectionDetailTypeFromConnectionSecretKey ConnectionDetailType = "FromConnectionSecretKey"
 	ConnectionDetailTypeFromFieldPath           ConnectionDetailType = "FromFieldPath"
 	ConnectionDetailTypeFromValue               ConnectionDetailType = "FromValue"
+	ConnectionDetailTypeCombine                 ConnectionDetailType = "Combine"
 )
 
 // ConnectionDetail includes the information about the propagation of the connection
@@ -624,7 +1854,7 @@ type ConnectionDetail struct {
 	// ConnectionDetail object. If the type is omitted Crossplane will attempt
 	// to infer it based on which other fields were specified.
 	// +optional
-	// +kubebuilder:validation:Enum=FromConnectionSecretKey;FromFieldPath;FromValue
+	// +kubebuilder:validation:Enum=FromConnectionSecretKey;FromFieldPath;FromValue;Combine
 	Type *ConnectionDetailType `json:"type,omitempty"`
 
 	// FromConnectionSecretKey is the key that will be used to fetch the value
@@ -645,6 +1875,60 @@ type ConnectionDetail struct {
 	// FromConnectionSecretKey when set.
 	// +optional
 	Value *string `json:"value,omitempty"`
+
+	// Combine configures a connection detail that combines several values
+	// into one, for example to build a connection URL. Name must be
+	// specified. Required when type is Combine.
+	// +optional
+	Combine *ConnectionDetailCombine `json:"combine,omitempty"`
+
+	// Transforms are the list of functions that are used to transform the
+	// value of the connection detail before it is propagated. Transforms are
+	// applied in order.
+	// +optional
+	Transforms []Transform `json:"transforms,omitempty"`
+}
+
+// A ConnectionDetailCombine combines several values into a single connection
+// detail.
+type ConnectionDetailCombine struct {
+	// Variables are the list of variables whose values will be retrieved and
+	// combined. Variables are passed to the combine strategy in order.
+	// +kubebuilder:validation:MinItems=1
+	Variables []ConnectionDetailVariable `json:"variables"`
+
+	// Strategy defines the strategy to use to combine the input variable
+	// values. Currently only string is supported.
+	// +kubebuilder:validation:Enum=string
+	Strategy CombineStrategy `json:"strategy"`
+
+	// String declares that input variables should be combined into a single
+	// string, using the relevant settings for formatting purposes.
+	// +optional
+	String *StringCombine `json:"string,omitempty"`
+}
+
+// Combine calls the appropriate combiner.
+func (c *ConnectionDetailCombine) Combine(vars []interface{}) (interface{}, error) {
+	return (&Combine{Strategy: c.Strategy, String: c.String}).Combine(vars)
+}
+
+// A ConnectionDetailVariable defines the source of a value that is combined
+// with others to form a connection detail. Exactly one source must be set.
+type ConnectionDetailVariable struct {
+	// FromConnectionSecretKey is the key of the composed resource's connection
+	// secret whose value is to be used as input.
+	// +optional
+	FromConnectionSecretKey *string `json:"fromConnectionSecretKey,omitempty"`
+
+	// FromFieldPath is the path of the field on the composed resource whose
+	// value is to be used as input.
+	// +optional
+	FromFieldPath *string `json:"fromFieldPath,omitempty"`
+
+	// Value is a fixed value to be used as input.
+	// +optional
+	Value *string `json:"value,omitempty"`
 }
 
 // CompositionStatus shows the observed state of the composition.
@@ -17,13 +17,18 @@ limitations under the License.
 package v1
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"reflect"
+	"regexp"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/pkg/errors"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/utils/pointer"
 
 	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
@@ -142,9 +147,9 @@ func TestPatchTypeReplacement(t *testing.T) {
 									Transforms: []Transform{{
 										Type: TransformTypeMap,
 										Map: &MapTransform{
-											Pairs: map[string]string{
-												"k-1": "v-1",
-												"k-2": "v-2",
+											Pairs: map[string]extv1.JSON{
+												"k-1": {Raw: []byte(`"v-1"`)},
+												"k-2": {Raw: []byte(`"v-2"`)},
 											},
 										},
 									}},
@@ -195,9 +200,9 @@ func TestPatchTypeReplacement(t *testing.T) {
 								Transforms: []Transform{{
 									Type: TransformTypeMap,
 									Map: &MapTransform{
-										Pairs: map[string]string{
-											"k-1": "v-1",
-											"k-2": "v-2",
+										Pairs: map[string]extv1.JSON{
+											"k-1": {Raw: []byte(`"v-1"`)},
+											"k-2": {Raw: []byte(`"v-2"`)},
 										},
 									},
 								}},
@@ -249,8 +254,10 @@ func TestPatchTypeReplacement(t *testing.T) {
 
 func TestMapResolve(t *testing.T) {
 	type args struct {
-		m map[string]string
-		i interface{}
+		m             map[string]extv1.JSON
+		fallbackValue *extv1.JSON
+		fallbackTo    MatchFallbackTo
+		i             interface{}
 	}
 	type want struct {
 		o   interface{}
@@ -277,85 +284,96 @@ func TestMapResolve(t *testing.T) {
 				err: errors.Errorf(errFmtMapNotFound, "ola"),
 			},
 		},
-		"Success": {
+		"KeyNotFoundFallbackValue": {
 			args: args{
-				m: map[string]string{"ola": "voila"},
-				i: "ola",
+				m:             map[string]extv1.JSON{"hello": {Raw: []byte(`"world"`)}},
+				fallbackValue: &extv1.JSON{Raw: []byte(`"default"`)},
+				i:             "ola",
 			},
 			want: want{
-				o: "voila",
+				o: "default",
 			},
 		},
-	}
-	for name, tc := range cases {
-		t.Run(name, func(t *testing.T) {
-			got, err := (&MapTransform{Pairs: tc.m}).Resolve(tc.i)
-
-			if diff := cmp.Diff(tc.want.o, got); diff != "" {
-				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
-			}
-			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
-				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
-			}
-		})
-	}
-}
-
-func TestMathResolve(t *testing.T) {
-	m := int64(2)
-
-	type args struct {
-		multiplier *int64
-		i          interface{}
-	}
-	type want struct {
-		o   interface{}
-		err error
-	}
-
-	cases := map[string]struct {
-		args
-		want
-	}{
-		"NoMultiplier": {
+		"KeyNotFoundFallbackToValueWithoutValue": {
 			args: args{
-				i: 25,
+				fallbackTo: MatchFallbackToValue,
+				i:          "ola",
 			},
 			want: want{
-				err: errors.New(errMathNoMultiplier),
+				err: errors.Errorf(errFmtMapNotFound, "ola"),
 			},
 		},
-		"NonNumberInput": {
+		"KeyNotFoundFallbackToInput": {
+			args: args{
+				m:             map[string]extv1.JSON{"hello": {Raw: []byte(`"world"`)}},
+				fallbackValue: &extv1.JSON{Raw: []byte(`"default"`)},
+				fallbackTo:    MatchFallbackToInput,
+				i:             "ola",
+			},
+			want: want{
+				o: "ola",
+			},
+		},
+		"KeyFoundIgnoresFallback": {
+			args: args{
+				m:             map[string]extv1.JSON{"ola": {Raw: []byte(`"voila"`)}},
+				fallbackValue: &extv1.JSON{Raw: []byte(`"default"`)},
+				fallbackTo:    MatchFallbackToInput,
+				i:             "ola",
+			},
+			want: want{
+				o: "voila",
+			},
+		},
+		"InvalidFallbackTo": {
 			args: args{
-				multiplier: &m,
+				fallbackTo: "Nope",
 				i:          "ola",
 			},
 			want: want{
-				err: errors.New(errMathInputNonNumber),
+				err: errors.Errorf(errFmtMapFallbackToNotSupported, "Nope"),
+			},
+		},
+		"ReservedKey": {
+			args: args{
+				m: map[string]extv1.JSON{"fallbackValue": {Raw: []byte(`"voila"`)}},
+				i: "fallbackValue",
+			},
+			want: want{
+				err: errors.Errorf(errFmtMapKeyReserved, "fallbackValue"),
 			},
 		},
 		"Success": {
 			args: args{
-				multiplier: &m,
-				i:          3,
+				m: map[string]extv1.JSON{"ola": {Raw: []byte(`"voila"`)}},
+				i: "ola",
 			},
 			want: want{
-				o: 3 * m,
+				o: "voila",
 			},
 		},
-		"SuccessInt64": {
+		"SuccessNumber": {
 			args: args{
-				multiplier: &m,
-				i:          int64(3),
+				m: map[string]extv1.JSON{"ola": {Raw: []byte(`42`)}},
+				i: "ola",
 			},
 			want: want{
-				o: 3 * m,
+				o: float64(42),
+			},
+		},
+		"SuccessObject": {
+			args: args{
+				m: map[string]extv1.JSON{"small": {Raw: []byte(`{"cpu":1,"zones":["a","b"]}`)}},
+				i: "small",
+			},
+			want: want{
+				o: map[string]interface{}{"cpu": float64(1), "zones": []interface{}{"a", "b"}},
 			},
 		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			got, err := (&MathTransform{Multiply: tc.multiplier}).Resolve(tc.i)
+			got, err := (&MapTransform{Pairs: tc.m, FallbackValue: tc.fallbackValue, FallbackTo: tc.fallbackTo}).Resolve(tc.i)
 
 			if diff := cmp.Diff(tc.want.o, got); diff != "" {
 				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
@@ -367,57 +385,93 @@ func TestMathResolve(t *testing.T) {
 	}
 }
 
-func TestStringResolve(t *testing.T) {
-
-	type args struct {
-		fmts string
-		i    interface{}
-	}
-	type want struct {
-		o   interface{}
-		err error
-	}
-
+func TestMapTransformJSON(t *testing.T) {
 	cases := map[string]struct {
-		args
-		want
+		reason string
+		json   string
+		want   MapTransform
 	}{
-		"FmtString": {
-			args: args{
-				fmts: "verycool%s",
-				i:    "thing",
-			},
-			want: want{
-				o: "verycoolthing",
-			},
+		"PairsOnly": {
+			reason: "A map without reserved keys should unmarshal into pairs only.",
+			json:   `{"us-west":"West US","size":{"cpu":1}}`,
+			want: MapTransform{Pairs: map[string]extv1.JSON{
+				"us-west": {Raw: []byte(`"West US"`)},
+				"size":    {Raw: []byte(`{"cpu":1}`)},
+			}},
 		},
-		"FmtInteger": {
-			args: args{
-				fmts: "the largest %d",
-				i:    8,
-			},
-			want: want{
-				o: "the largest 8",
+		"Fallback": {
+			reason: "The reserved fallback keys should unmarshal into the fallback fields rather than pairs.",
+			json:   `{"us-west":"West US","fallbackValue":"Central US","fallbackTo":"Input"}`,
+			want: MapTransform{
+				Pairs:         map[string]extv1.JSON{"us-west": {Raw: []byte(`"West US"`)}},
+				FallbackValue: &extv1.JSON{Raw: []byte(`"Central US"`)},
+				FallbackTo:    MatchFallbackToInput,
 			},
 		},
+		"UnsupportedFallbackTo": {
+			reason: "A fallbackTo key that is neither Value nor Input should be left as a pair, so that validation rejects it.",
+			json:   `{"us-west":"West US","fallbackTo":"us-east"}`,
+			want: MapTransform{Pairs: map[string]extv1.JSON{
+				"us-west":    {Raw: []byte(`"West US"`)},
+				"fallbackTo": {Raw: []byte(`"us-east"`)},
+			}},
+		},
 	}
+
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			got, err := (&StringTransform{Format: tc.fmts}).Resolve(tc.i)
+			got := MapTransform{}
+			if err := json.Unmarshal([]byte(tc.json), &got); err != nil {
+				t.Fatalf("\n%s\njson.Unmarshal(...): %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\njson.Unmarshal(...): -want, +got:\n%s", tc.reason, diff)
+			}
 
-			if diff := cmp.Diff(tc.want.o, got); diff != "" {
-				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			// Marshalling and unmarshalling again should round trip.
+			b, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("\n%s\njson.Marshal(...): %v", tc.reason, err)
 			}
-			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
-				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			rt := MapTransform{}
+			if err := json.Unmarshal(b, &rt); err != nil {
+				t.Fatalf("\n%s\njson.Unmarshal(...): %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, rt); diff != "" {
+				t.Errorf("\n%s\njson round trip: -want, +got:\n%s", tc.reason, diff)
 			}
 		})
 	}
 }
 
-func TestConvertResolve(t *testing.T) {
+func TestCompileRegexp(t *testing.T) {
+	invalid := "[a-"
+	_, errCompile := regexp.Compile(invalid)
+	_, err := compileRegexp(invalid)
+	if diff := cmp.Diff(errCompile, err, test.EquateErrors()); diff != "" {
+		t.Errorf("compileRegexp(...): -want error, +got error:\n%s", diff)
+	}
+
+	first, err := compileRegexp("^o")
+	if err != nil {
+		t.Fatalf("compileRegexp(...): %v", err)
+	}
+	second, err := compileRegexp("^o")
+	if err != nil {
+		t.Fatalf("compileRegexp(...): %v", err)
+	}
+	if first != second {
+		t.Errorf("compileRegexp(...): want a regular expression to be compiled only once")
+	}
+}
+
+func TestMatchResolve(t *testing.T) {
+	typeRegexp := MatchTransformPatternTypeRegexp
+	invalid := "[a-"
+	_, errCompile := regexp.Compile(invalid)
+
 	type args struct {
-		ot string
+		mt MatchTransform
 		i  interface{}
 	}
 	type want struct {
@@ -429,55 +483,101 @@ func TestConvertResolve(t *testing.T) {
 		args
 		want
 	}{
-		"StringToBool": {
+		"NonStringInput": {
 			args: args{
-				i:  "true",
-				ot: ConvertTransformTypeBool,
+				i: 5,
 			},
 			want: want{
-				o: true,
+				err: errors.Errorf(errFmtMatchTypeNotSupported, "int"),
 			},
 		},
-		"SameTypeNoOp": {
+		"MissingLiteral": {
 			args: args{
-				i:  true,
-				ot: ConvertTransformTypeBool,
+				mt: MatchTransform{Patterns: []MatchTransformPattern{{}}},
+				i:  "ola",
 			},
 			want: want{
-				o: true,
+				err: errors.Wrapf(errors.Errorf(errRequiredField, "literal", MatchTransformPatternTypeLiteral), errFmtMatchPattern, 0),
 			},
 		},
-		"IntAliasToInt64": {
+		"InvalidRegexp": {
 			args: args{
-				i:  int64(1),
-				ot: ConvertTransformTypeInt,
+				mt: MatchTransform{Patterns: []MatchTransformPattern{{Type: typeRegexp, Regexp: &invalid}}},
+				i:  "ola",
 			},
 			want: want{
-				o: int64(1),
+				err: errors.Wrapf(errors.Wrap(errCompile, errMatchRegexpCompile), errFmtMatchPattern, 0),
 			},
 		},
-		"InputTypeNotSupported": {
+		"UnsupportedFallbackTo": {
 			args: args{
-				i:  []int{64},
-				ot: ConvertTransformTypeString,
+				mt: MatchTransform{FallbackTo: "Nowhere"},
+				i:  "ola",
 			},
 			want: want{
-				err: errors.Errorf(errFmtConvertInputTypeNotSupported, reflect.TypeOf([]int{}).Kind().String()),
+				err: errors.Errorf(errFmtMatchFallbackToNotSupported, "Nowhere"),
 			},
 		},
-		"ConversionPairNotSupported": {
+		"NoMatchNoFallback": {
 			args: args{
-				i:  "[64]",
-				ot: "[]int",
+				mt: MatchTransform{Patterns: []MatchTransformPattern{
+					{Literal: pointer.StringPtr("voila"), Result: extv1.JSON{Raw: []byte(`"voila"`)}},
+				}},
+				i: "ola",
 			},
 			want: want{
-				err: errors.Errorf(errFmtConversionPairNotSupported, "string", "[]int"),
+				err: errors.Errorf(errFmtMatchNotFound, "ola"),
+			},
+		},
+		"FirstMatchWins": {
+			args: args{
+				mt: MatchTransform{Patterns: []MatchTransformPattern{
+					{Type: typeRegexp, Regexp: pointer.StringPtr("^o"), Result: extv1.JSON{Raw: []byte(`"first"`)}},
+					{Literal: pointer.StringPtr("ola"), Result: extv1.JSON{Raw: []byte(`"second"`)}},
+				}},
+				i: "ola",
+			},
+			want: want{
+				o: "first",
+			},
+		},
+		"LiteralObjectResult": {
+			args: args{
+				mt: MatchTransform{Patterns: []MatchTransformPattern{
+					{Type: typeRegexp, Regexp: pointer.StringPtr("^x"), Result: extv1.JSON{Raw: []byte(`"first"`)}},
+					{Literal: pointer.StringPtr("ola"), Result: extv1.JSON{Raw: []byte(`{"cool":true}`)}},
+				}},
+				i: "ola",
+			},
+			want: want{
+				o: map[string]interface{}{"cool": true},
+			},
+		},
+		"FallbackToValue": {
+			args: args{
+				mt: MatchTransform{FallbackValue: &extv1.JSON{Raw: []byte(`["default"]`)}},
+				i:  "ola",
+			},
+			want: want{
+				o: []interface{}{"default"},
+			},
+		},
+		"FallbackToInput": {
+			args: args{
+				mt: MatchTransform{
+					FallbackTo:    MatchFallbackToInput,
+					FallbackValue: &extv1.JSON{Raw: []byte(`"default"`)},
+				},
+				i: "ola",
+			},
+			want: want{
+				o: "ola",
 			},
 		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			got, err := (&ConvertTransform{ToType: tc.args.ot}).Resolve(tc.i)
+			got, err := tc.mt.Resolve(tc.i)
 
 			if diff := cmp.Diff(tc.want.o, got); diff != "" {
 				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
@@ -489,302 +589,1387 @@ func TestConvertResolve(t *testing.T) {
 	}
 }
 
-func TestPatchApply(t *testing.T) {
-	now := metav1.NewTime(time.Unix(0, 0))
-	lpt := fake.ConnectionDetailsLastPublishedTimer{
-		Time: &now,
-	}
-
-	errNotFound := func(path string) error {
-		p := &fieldpath.Paved{}
-		_, err := p.GetValue(path)
-		return err
-	}
+func TestMathResolve(t *testing.T) {
+	m := int64(2)
+	zero := int64(0)
+	floor := MathRoundingModeFloor
+	ceil := MathRoundingModeCeil
+	nearest := MathRoundingModeRound
+	bad := MathRoundingMode("Sideways")
 
 	type args struct {
-		patch Patch
-		cp    *fake.Composite
-		cd    *fake.Composed
-		only  []PatchType
+		mt MathTransform
+		i  interface{}
 	}
 	type want struct {
-		cp  *fake.Composite
-		cd  *fake.Composed
+		o   interface{}
 		err error
 	}
 
 	cases := map[string]struct {
-		reason string
 		args
 		want
 	}{
-		"InvalidCompositeFieldPathPatch": {
-			reason: "Should return error when required fields not passed to applyFromFieldPathPatch",
+		"NoMultiplier": {
 			args: args{
-				patch: Patch{
-					Type: PatchTypeFromCompositeFieldPath,
-				},
-				cp: &fake.Composite{
-					ConnectionDetailsLastPublishedTimer: lpt,
-				},
-				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				i: 25,
 			},
 			want: want{
-				err: errors.Errorf(errRequiredField, "FromFieldPath", PatchTypeFromCompositeFieldPath),
+				err: errors.New(errMathNoMultiplier),
 			},
 		},
-		"InvalidPatchType": {
-			reason: "Should return an error if an invalid patch type is specified",
+		"NonNumberInput": {
 			args: args{
-				patch: Patch{
-					Type: "invalid-patchtype",
-				},
-				cp: &fake.Composite{
-					ConnectionDetailsLastPublishedTimer: lpt,
-				},
-				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				mt: MathTransform{Multiply: &m},
+				i:  "ola",
 			},
 			want: want{
-				err: errors.Errorf(errInvalidPatchType, "invalid-patchtype"),
+				err: errors.New(errMathInputNonNumber),
 			},
 		},
-		"ValidCompositeFieldPathPatch": {
-			reason: "Should correctly apply a CompositeFieldPathPatch with valid settings",
+		"Success": {
 			args: args{
-				patch: Patch{
-					Type:          PatchTypeFromCompositeFieldPath,
-					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
-					ToFieldPath:   pointer.StringPtr("objectMeta.labels"),
-				},
-				cp: &fake.Composite{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "cp",
-						Labels: map[string]string{
-							"Test": "blah",
-						},
-					},
-					ConnectionDetailsLastPublishedTimer: lpt,
-				},
-				cd: &fake.Composed{
-					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
-				},
+				mt: MathTransform{Multiply: &m},
+				i:  3,
 			},
 			want: want{
-				cd: &fake.Composed{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "cd",
-						Labels: map[string]string{
-							"Test": "blah",
-						},
-					},
-				},
-				err: nil,
+				o: 3 * m,
 			},
 		},
-		"MissingOptionalFieldPath": {
-			reason: "A FromFieldPath patch should be a no-op when an optional fromFieldPath doesn't exist",
+		"SuccessInt64": {
 			args: args{
-				patch: Patch{
-					Type:          PatchTypeFromCompositeFieldPath,
-					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
-					ToFieldPath:   pointer.StringPtr("objectMeta.labels"),
-				},
-				cp: &fake.Composite{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "cp",
+				mt: MathTransform{Multiply: &m},
+				i:  int64(3),
+			},
+			want: want{
+				o: 3 * m,
+			},
+		},
+		"SuccessFloat64": {
+			args: args{
+				mt: MathTransform{Multiply: &m},
+				i:  float64(1.5),
+			},
+			want: want{
+				o: float64(3),
+			},
+		},
+		"UnsupportedType": {
+			args: args{
+				mt: MathTransform{Type: "Exponent", Multiply: &m},
+				i:  3,
+			},
+			want: want{
+				err: errors.Errorf(errFmtMathTypeNotSupported, "Exponent"),
+			},
+		},
+		"MissingOperand": {
+			args: args{
+				mt: MathTransform{Type: MathTransformTypeAdd, Multiply: &m},
+				i:  3,
+			},
+			want: want{
+				err: errors.Errorf(errFmtMathOperandMissing, MathTransformTypeAdd),
+			},
+		},
+		"UnsupportedRoundingMode": {
+			args: args{
+				mt: MathTransform{Multiply: &m, Round: &bad},
+				i:  3,
+			},
+			want: want{
+				err: errors.Errorf(errFmtMathRoundingNotSupported, bad),
+			},
+		},
+		"Add": {
+			args: args{
+				mt: MathTransform{Type: MathTransformTypeAdd, Add: &m},
+				i:  3,
+			},
+			want: want{
+				o: int64(5),
+			},
+		},
+		"Subtract": {
+			args: args{
+				mt: MathTransform{Type: MathTransformTypeSubtract, Subtract: &m},
+				i:  int64(3),
+			},
+			want: want{
+				o: int64(1),
+			},
+		},
+		"DivideByZero": {
+			args: args{
+				mt: MathTransform{Type: MathTransformTypeDivide, Divide: &zero},
+				i:  3,
+			},
+			want: want{
+				err: errors.Errorf(errFmtMathZeroOperand, MathTransformTypeDivide),
+			},
+		},
+		"DivideTruncates": {
+			args: args{
+				mt: MathTransform{Type: MathTransformTypeDivide, Divide: &m},
+				i:  int64(5),
+			},
+			want: want{
+				o: int64(2),
+			},
+		},
+		"DivideCeil": {
+			args: args{
+				mt: MathTransform{Type: MathTransformTypeDivide, Divide: &m, Round: &ceil},
+				i:  int64(5),
+			},
+			want: want{
+				o: int64(3),
+			},
+		},
+		"DivideFloorNegative": {
+			args: args{
+				mt: MathTransform{Type: MathTransformTypeDivide, Divide: &m, Round: &floor},
+				i:  int64(-5),
+			},
+			want: want{
+				o: int64(-3),
+			},
+		},
+		"DivideFloat64": {
+			args: args{
+				mt: MathTransform{Type: MathTransformTypeDivide, Divide: &m},
+				i:  float64(5),
+			},
+			want: want{
+				o: float64(2.5),
+			},
+		},
+		"DivideFloat64Round": {
+			args: args{
+				mt: MathTransform{Type: MathTransformTypeDivide, Divide: &m, Round: &nearest},
+				i:  float64(5),
+			},
+			want: want{
+				o: int64(3),
+			},
+		},
+		"ModuloByZero": {
+			args: args{
+				mt: MathTransform{Type: MathTransformTypeModulo, Modulo: &zero},
+				i:  3,
+			},
+			want: want{
+				err: errors.Errorf(errFmtMathZeroOperand, MathTransformTypeModulo),
+			},
+		},
+		"Modulo": {
+			args: args{
+				mt: MathTransform{Type: MathTransformTypeModulo, Modulo: &m},
+				i:  7,
+			},
+			want: want{
+				o: int64(1),
+			},
+		},
+		"ModuloFloat64": {
+			args: args{
+				mt: MathTransform{Type: MathTransformTypeModulo, Modulo: &m},
+				i:  float64(7.5),
+			},
+			want: want{
+				o: float64(1.5),
+			},
+		},
+		"ClampMin": {
+			args: args{
+				mt: MathTransform{Type: MathTransformTypeClampMin, ClampMin: &m},
+				i:  1,
+			},
+			want: want{
+				o: int64(2),
+			},
+		},
+		"ClampMinNotClamped": {
+			args: args{
+				mt: MathTransform{Type: MathTransformTypeClampMin, ClampMin: &m},
+				i:  float64(2.5),
+			},
+			want: want{
+				o: float64(2.5),
+			},
+		},
+		"ClampMax": {
+			args: args{
+				mt: MathTransform{Type: MathTransformTypeClampMax, ClampMax: &m},
+				i:  float64(2.5),
+			},
+			want: want{
+				o: float64(2),
+			},
+		},
+		"ClampMaxNotClamped": {
+			args: args{
+				mt: MathTransform{Type: MathTransformTypeClampMax, ClampMax: &m},
+				i:  int64(1),
+			},
+			want: want{
+				o: int64(1),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.mt.Resolve(tc.i)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStringResolve(t *testing.T) {
+	upper := StringConversionTypeToUpper
+	lower := StringConversionTypeToLower
+	toBase64 := StringConversionTypeToBase64
+	fromBase64 := StringConversionTypeFromBase64
+	toSha256 := StringConversionTypeToSha256
+	unknown := StringConversionType("ToKlingon")
+	two := 2
+
+	type args struct {
+		st StringTransform
+		i  interface{}
+	}
+	type want struct {
+		o   interface{}
+		err error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"FmtString": {
+			args: args{
+				st: StringTransform{Format: "verycool%s"},
+				i:  "thing",
+			},
+			want: want{
+				o: "verycoolthing",
+			},
+		},
+		"FmtInteger": {
+			args: args{
+				st: StringTransform{Format: "the largest %d"},
+				i:  8,
+			},
+			want: want{
+				o: "the largest 8",
+			},
+		},
+		"FmtMissing": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeFormat},
+				i:  8,
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringConfigMissing, StringTransformTypeFormat, "fmt"),
+			},
+		},
+		"UnsupportedType": {
+			args: args{
+				st: StringTransform{Type: "Reverse"},
+				i:  "thing",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringTypeNotSupported, "Reverse"),
+			},
+		},
+		"NonStringInput": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeConvert, Convert: &upper},
+				i:  8,
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringInputNonString, StringTransformTypeConvert),
+			},
+		},
+		"ConvertMissing": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeConvert},
+				i:  "thing",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringConfigMissing, StringTransformTypeConvert, "convert"),
+			},
+		},
+		"ConvertUnsupported": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeConvert, Convert: &unknown},
+				i:  "thing",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringConversionNotSupported, unknown),
+			},
+		},
+		"ConvertToUpper": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeConvert, Convert: &upper},
+				i:  "thing",
+			},
+			want: want{
+				o: "THING",
+			},
+		},
+		"ConvertToLower": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeConvert, Convert: &lower},
+				i:  "ThInG",
+			},
+			want: want{
+				o: "thing",
+			},
+		},
+		"ConvertToBase64": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeConvert, Convert: &toBase64},
+				i:  "thing",
+			},
+			want: want{
+				o: "dGhpbmc=",
+			},
+		},
+		"ConvertFromBase64": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeConvert, Convert: &fromBase64},
+				i:  "dGhpbmc=",
+			},
+			want: want{
+				o: "thing",
+			},
+		},
+		"ConvertFromInvalidBase64": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeConvert, Convert: &fromBase64},
+				i:  "!",
+			},
+			want: want{
+				o:   "",
+				err: errors.Wrap(base64.CorruptInputError(0), errStringDecodeBase64),
+			},
+		},
+		"ConvertToSha256": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeConvert, Convert: &toSha256},
+				i:  "thing",
+			},
+			want: want{
+				o: "5de94d691ae3039a3f5d362fea9a35964fe0cc4ae44a6a84266d3614755c69b2",
+			},
+		},
+		"TrimMissing": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeTrimPrefix},
+				i:  "thing",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringConfigMissing, StringTransformTypeTrimPrefix, "trim"),
+			},
+		},
+		"TrimPrefix": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeTrimPrefix, Trim: pointer.StringPtr("very")},
+				i:  "verycoolthing",
+			},
+			want: want{
+				o: "coolthing",
+			},
+		},
+		"TrimSuffix": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeTrimSuffix, Trim: pointer.StringPtr("thing")},
+				i:  "verycoolthing",
+			},
+			want: want{
+				o: "verycool",
+			},
+		},
+		"RegexpMissing": {
+			args: args{
+				st: StringTransform{Type: StringTransformTypeRegexp},
+				i:  "thing",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringConfigMissing, StringTransformTypeRegexp, "regexp"),
+			},
+		},
+		"RegexpInvalidGroup": {
+			args: args{
+				st: StringTransform{
+					Type:   StringTransformTypeRegexp,
+					Regexp: &StringTransformRegexp{Match: "^(.*)$", Group: &two},
+				},
+				i: "thing",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringRegexpGroupInvalid, 2, 1),
+			},
+		},
+		"RegexpNoMatch": {
+			args: args{
+				st: StringTransform{
+					Type:   StringTransformTypeRegexp,
+					Regexp: &StringTransformRegexp{Match: "^arn:"},
+				},
+				i: "thing",
+			},
+			want: want{
+				err: errors.Errorf(errFmtStringRegexpNoMatch, "^arn:"),
+			},
+		},
+		"RegexpEntireMatch": {
+			args: args{
+				st: StringTransform{
+					Type:   StringTransformTypeRegexp,
+					Regexp: &StringTransformRegexp{Match: "[0-9]+"},
+				},
+				i: "arn:aws:iam::123456789012:user/cool",
+			},
+			want: want{
+				o: "123456789012",
+			},
+		},
+		"RegexpCaptureGroup": {
+			args: args{
+				st: StringTransform{
+					Type:   StringTransformTypeRegexp,
+					Regexp: &StringTransformRegexp{Match: "^arn:aws:iam::([0-9]+):(.*)$", Group: &two},
+				},
+				i: "arn:aws:iam::123456789012:user/cool",
+			},
+			want: want{
+				o: "user/cool",
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.st.Resolve(tc.i)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConvertResolve(t *testing.T) {
+	type args struct {
+		ot string
+		i  interface{}
+	}
+	type want struct {
+		o   interface{}
+		err error
+	}
+
+	cases := map[string]struct {
+		args
+		want
+	}{
+		"StringToBool": {
+			args: args{
+				i:  "true",
+				ot: ConvertTransformTypeBool,
+			},
+			want: want{
+				o: true,
+			},
+		},
+		"SameTypeNoOp": {
+			args: args{
+				i:  true,
+				ot: ConvertTransformTypeBool,
+			},
+			want: want{
+				o: true,
+			},
+		},
+		"IntAliasToInt64": {
+			args: args{
+				i:  int64(1),
+				ot: ConvertTransformTypeInt,
+			},
+			want: want{
+				o: int64(1),
+			},
+		},
+		"InputTypeNotSupported": {
+			args: args{
+				i:  []int{64},
+				ot: ConvertTransformTypeString,
+			},
+			want: want{
+				err: errors.Errorf(errFmtConvertInputTypeNotSupported, reflect.TypeOf([]int{}).Kind().String()),
+			},
+		},
+		"ConversionPairNotSupported": {
+			args: args{
+				i:  "[64]",
+				ot: "[]int",
+			},
+			want: want{
+				err: errors.Errorf(errFmtConversionPairNotSupported, "string", "[]int"),
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := (&ConvertTransform{ToType: tc.args.ot}).Resolve(tc.i)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("Resolve(b): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPatchApply(t *testing.T) {
+	now := metav1.NewTime(time.Unix(0, 0))
+	lpt := fake.ConnectionDetailsLastPublishedTimer{
+		Time: &now,
+	}
+
+	errNotFound := func(path string) error {
+		p := &fieldpath.Paved{}
+		_, err := p.GetValue(path)
+		return err
+	}
+
+	type args struct {
+		patch Patch
+		cp    *fake.Composite
+		cd    *fake.Composed
+		only  []PatchType
+	}
+	type want struct {
+		cp  *fake.Composite
+		cd  *fake.Composed
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"InvalidCompositeFieldPathPatch": {
+			reason: "Should return error when required fields not passed to applyFromFieldPathPatch",
+			args: args{
+				patch: Patch{
+					Type: PatchTypeFromCompositeFieldPath,
+				},
+				cp: &fake.Composite{
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				err: errors.Errorf(errRequiredField, "FromFieldPath", PatchTypeFromCompositeFieldPath),
+			},
+		},
+		"InvalidPatchType": {
+			reason: "Should return an error if an invalid patch type is specified",
+			args: args{
+				patch: Patch{
+					Type: "invalid-patchtype",
+				},
+				cp: &fake.Composite{
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				err: errors.Errorf(errInvalidPatchType, "invalid-patchtype"),
+			},
+		},
+		"ValidCompositeFieldPathPatch": {
+			reason: "Should correctly apply a CompositeFieldPathPatch with valid settings",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
+					ToFieldPath:   pointer.StringPtr("objectMeta.labels"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+						Labels: map[string]string{
+							"Test": "blah",
+						},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+			want: want{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cd",
+						Labels: map[string]string{
+							"Test": "blah",
+						},
+					},
+				},
+				err: nil,
+			},
+		},
+		"MissingOptionalFieldPath": {
+			reason: "A FromFieldPath patch should be a no-op when an optional fromFieldPath doesn't exist",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
+					ToFieldPath:   pointer.StringPtr("objectMeta.labels"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+			want: want{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cd",
+					},
+				},
+				err: nil,
+			},
+		},
+		"MissingRequiredFieldPath": {
+			reason: "A FromFieldPath patch should return an error when a required fromFieldPath doesn't exist",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("wat"),
+					Policy: &PatchPolicy{
+						FromFieldPath: func() *FromFieldPathPolicy {
+							s := FromFieldPathPolicyRequired
+							return &s
+						}(),
+					},
+					ToFieldPath: pointer.StringPtr("wat"),
+				},
+				cp: &fake.Composite{
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+			want: want{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cd",
+					},
+				},
+				err: errNotFound("wat"),
+			},
+		},
+		"FilterExcludeCompositeFieldPathPatch": {
+			reason: "Should not apply the patch as the PatchType is not present in filter.",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
+					ToFieldPath:   pointer.StringPtr("objectMeta.labels"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+						Labels: map[string]string{
+							"Test": "blah",
+						},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+				only: []PatchType{PatchTypePatchSet},
+			},
+			want: want{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cd",
+					},
+				},
+				err: nil,
+			},
+		},
+		"FilterIncludeCompositeFieldPathPatch": {
+			reason: "Should apply the patch as the PatchType is present in filter.",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
+					ToFieldPath:   pointer.StringPtr("objectMeta.labels"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+						Labels: map[string]string{
+							"Test": "blah",
+						},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+				only: []PatchType{PatchTypeFromCompositeFieldPath},
+			},
+			want: want{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cd",
+						Labels: map[string]string{
+							"Test": "blah",
+						}},
+				},
+				err: nil,
+			},
+		},
+		"DefaultToFieldCompositeFieldPathPatch": {
+			reason: "Should correctly default the ToFieldPath value if not specified.",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+						Labels: map[string]string{
+							"Test": "blah",
+						},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cd",
+					},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+						Labels: map[string]string{
+							"Test": "blah",
+						},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cd",
+						Labels: map[string]string{
+							"Test": "blah",
+						}},
+				},
+				err: nil,
+			},
+		},
+		"ValidToCompositeFieldPathPatch": {
+			reason: "Should correctly apply a ToCompositeFieldPath patch with valid settings",
+			args: args{
+				patch: Patch{
+					Type:          PatchTypeToCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
+					ToFieldPath:   pointer.StringPtr("objectMeta.labels"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cd",
+						Labels: map[string]string{
+							"Test": "blah",
+						},
+					},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+						Labels: map[string]string{
+							"Test": "blah",
+						},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cd",
+						Labels: map[string]string{
+							"Test": "blah",
+						}},
+				},
+				err: nil,
+			},
+		},
+		"ValidCombineFromComposite": {
+			reason: "Should correctly apply a CombineFromComposite patch with valid settings",
+			args: args{
+				patch: Patch{
+					Type: PatchTypeCombineFromComposite,
+					Combine: &Combine{
+						Variables: []CombineVariable{
+							{FromFieldPath: "objectMeta.labels.source1"},
+							{FromFieldPath: "objectMeta.labels.source2"},
+						},
+						Strategy: CombineStrategyString,
+						String:   &StringCombine{Format: "%s-%s"},
+					},
+					ToFieldPath: pointer.StringPtr("objectMeta.labels.destination"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+						Labels: map[string]string{
+							"source1": "foo",
+							"source2": "bar",
+						},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+			want: want{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cd",
+						Labels: map[string]string{
+							"destination": "foo-bar",
+						},
+					},
+				},
+				err: nil,
+			},
+		},
+		"CombineFromCompositeMissingToFieldPath": {
+			reason: "Should return an error if a CombineFromComposite patch does not specify a toFieldPath",
+			args: args{
+				patch: Patch{
+					Type: PatchTypeCombineFromComposite,
+					Combine: &Combine{
+						Variables: []CombineVariable{{FromFieldPath: "objectMeta.labels.source1"}},
+						Strategy:  CombineStrategyString,
+						String:    &StringCombine{Format: "%s"},
+					},
+				},
+				cp: &fake.Composite{
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				err: errors.Errorf(errRequiredField, "ToFieldPath", PatchTypeCombineFromComposite),
+			},
+		},
+		"CombineFromCompositeNoVariables": {
+			reason: "Should return an error if a CombineFromComposite patch does not specify any variables",
+			args: args{
+				patch: Patch{
+					Type: PatchTypeCombineFromComposite,
+					Combine: &Combine{
+						Strategy: CombineStrategyString,
+						String:   &StringCombine{Format: "%s"},
+					},
+					ToFieldPath: pointer.StringPtr("objectMeta.labels.destination"),
+				},
+				cp: &fake.Composite{
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+			want: want{
+				err: errors.New(errCombineRequiresVariables),
+			},
+		},
+		"CombineFromCompositeMissingOptionalVariable": {
+			reason: "A CombineFromComposite patch should be a no-op when an optional variable doesn't exist",
+			args: args{
+				patch: Patch{
+					Type: PatchTypeCombineFromComposite,
+					Combine: &Combine{
+						Variables: []CombineVariable{
+							{FromFieldPath: "objectMeta.labels.source1"},
+							{FromFieldPath: "objectMeta.labels.source2"},
+						},
+						Strategy: CombineStrategyString,
+						String:   &StringCombine{Format: "%s-%s"},
+					},
+					ToFieldPath: pointer.StringPtr("objectMeta.labels.destination"),
+					Policy: &PatchPolicy{
+						FromFieldPath: func() *FromFieldPathPolicy {
+							s := FromFieldPathPolicyOptional
+							return &s
+						}(),
+					},
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+						Labels: map[string]string{
+							"source1": "foo",
+						},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+			want: want{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+				err: nil,
+			},
+		},
+		"CombineFromCompositeMissingVariable": {
+			reason: "A CombineFromComposite patch should be a no-op when a variable doesn't exist and neither it nor its patch has a policy",
+			args: args{
+				patch: Patch{
+					Type: PatchTypeCombineFromComposite,
+					Combine: &Combine{
+						Variables: []CombineVariable{
+							{FromFieldPath: "objectMeta.labels.source1"},
+							{FromFieldPath: "objectMeta.labels.source2"},
+						},
+						Strategy: CombineStrategyString,
+						String:   &StringCombine{Format: "%s-%s"},
+					},
+					ToFieldPath: pointer.StringPtr("objectMeta.labels.destination"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+						Labels: map[string]string{
+							"source1": "foo",
+						},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+			want: want{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+		},
+		"CombineFromCompositeFromResourceName": {
+			reason: "A CombineFromComposite patch should return an error if a variable names a resource template",
+			args: args{
+				patch: Patch{
+					Type: PatchTypeCombineFromComposite,
+					Combine: &Combine{
+						Variables: []CombineVariable{
+							{FromResourceName: pointer.StringPtr("db"), FromFieldPath: "objectMeta.labels.source1"},
+						},
+						Strategy: CombineStrategyString,
+						String:   &StringCombine{Format: "%s"},
+					},
+					ToFieldPath: pointer.StringPtr("objectMeta.labels.destination"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta:                          metav1.ObjectMeta{Name: "cp"},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+			want: want{
+				err: errors.Errorf(errFmtCombineResourceName, 0, PatchTypeCombineToComposite),
+			},
+		},
+		"CombineFromCompositeMissingRequiredVariable": {
+			reason: "A CombineFromComposite patch should return an error when a required variable doesn't exist, even if the patch policy is optional",
+			args: args{
+				patch: Patch{
+					Type: PatchTypeCombineFromComposite,
+					Combine: &Combine{
+						Variables: []CombineVariable{
+							{FromFieldPath: "objectMeta.labels.source1"},
+							{
+								FromFieldPath: "objectMeta.labels.source2",
+								Policy: func() *FromFieldPathPolicy {
+									s := FromFieldPathPolicyRequired
+									return &s
+								}(),
+							},
+						},
+						Strategy: CombineStrategyString,
+						String:   &StringCombine{Format: "%s-%s"},
+					},
+					ToFieldPath: pointer.StringPtr("objectMeta.labels.destination"),
+					Policy: &PatchPolicy{
+						FromFieldPath: func() *FromFieldPathPolicy {
+							s := FromFieldPathPolicyOptional
+							return &s
+						}(),
+					},
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+						Labels: map[string]string{
+							"source1": "foo",
+						},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+			},
+			want: want{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+				},
+				err: errors.Wrapf(func() error {
+					p := fieldpath.Pave(map[string]interface{}{"objectMeta": map[string]interface{}{"labels": map[string]interface{}{}}})
+					_, err := p.GetValue("objectMeta.labels.source2")
+					return err
+				}(), errFmtCombineVariable, 1, "objectMeta.labels.source2", "the composite resource"),
+			},
+		},
+		"ValidCombineToComposite": {
+			reason: "Should correctly apply a CombineToComposite patch with valid settings",
+			args: args{
+				patch: Patch{
+					Type: PatchTypeCombineToComposite,
+					Combine: &Combine{
+						Variables: []CombineVariable{
+							{FromFieldPath: "objectMeta.labels.host"},
+							{FromFieldPath: "objectMeta.labels.port"},
+						},
+						Strategy: CombineStrategyString,
+						String:   &StringCombine{Format: "%s:%s"},
+					},
+					ToFieldPath: pointer.StringPtr("objectMeta.labels.endpoint"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cd",
+						Labels: map[string]string{
+							"host": "example.org",
+							"port": "5432",
+						},
+					},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+						Labels: map[string]string{
+							"endpoint": "example.org:5432",
+						},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				err: nil,
+			},
+		},
+		"CombineToCompositeMissingVariable": {
+			reason: "A CombineToComposite patch should return an error identifying the missing variable when neither it nor its patch has a policy",
+			args: args{
+				patch: Patch{
+					Type: PatchTypeCombineToComposite,
+					Combine: &Combine{
+						Variables: []CombineVariable{
+							{FromFieldPath: "objectMeta.labels.host"},
+							{FromFieldPath: "objectMeta.labels.port"},
+						},
+						Strategy: CombineStrategyString,
+						String:   &StringCombine{Format: "%s:%s"},
+					},
+					ToFieldPath: pointer.StringPtr("objectMeta.labels.endpoint"),
+				},
+				cp: &fake.Composite{
+					ObjectMeta:                          metav1.ObjectMeta{Name: "cp"},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cd",
+						Labels: map[string]string{"host": "example.org"},
+					},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					ObjectMeta:                          metav1.ObjectMeta{Name: "cp"},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				err: errors.Wrapf(func() error {
+					p := fieldpath.Pave(map[string]interface{}{"objectMeta": map[string]interface{}{"labels": map[string]interface{}{}}})
+					_, err := p.GetValue("objectMeta.labels.port")
+					return err
+				}(), errFmtCombineVariable, 1, "objectMeta.labels.port", "the composed resource"),
+			},
+		},
+		"CombineToCompositeMissingRequiredVariable": {
+			reason: "A CombineToComposite patch should return an error identifying the missing variable when the patch policy is required",
+			args: args{
+				patch: Patch{
+					Type: PatchTypeCombineToComposite,
+					Combine: &Combine{
+						Variables: []CombineVariable{
+							{FromFieldPath: "objectMeta.labels.host"},
+							{FromFieldPath: "objectMeta.labels.port"},
+						},
+						Strategy: CombineStrategyString,
+						String:   &StringCombine{Format: "%s:%s"},
+					},
+					ToFieldPath: pointer.StringPtr("objectMeta.labels.endpoint"),
+					Policy: &PatchPolicy{
+						FromFieldPath: func() *FromFieldPathPolicy {
+							s := FromFieldPathPolicyRequired
+							return &s
+						}(),
+					},
+				},
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cp",
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "cd",
+						Labels: map[string]string{
+							"port": "5432",
+						},
 					},
-					ConnectionDetailsLastPublishedTimer: lpt,
-				},
-				cd: &fake.Composed{
-					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
 				},
 			},
 			want: want{
-				cd: &fake.Composed{
+				cp: &fake.Composite{
 					ObjectMeta: metav1.ObjectMeta{
-						Name: "cd",
+						Name: "cp",
 					},
+					ConnectionDetailsLastPublishedTimer: lpt,
 				},
-				err: nil,
+				err: errors.Wrapf(func() error {
+					p := fieldpath.Pave(map[string]interface{}{"objectMeta": map[string]interface{}{"labels": map[string]interface{}{}}})
+					_, err := p.GetValue("objectMeta.labels.host")
+					return err
+				}(), errFmtCombineVariable, 0, "objectMeta.labels.host", "the composed resource"),
 			},
 		},
-		"MissingRequiredFieldPath": {
-			reason: "A FromFieldPath patch should return an error when a required fromFieldPath doesn't exist",
+		"MergeOptionsKeepMapKeys": {
+			reason: "A patch with merge options should keep keys that exist only in the destination object",
 			args: args{
 				patch: Patch{
 					Type:          PatchTypeFromCompositeFieldPath,
-					FromFieldPath: pointer.StringPtr("wat"),
+					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
 					Policy: &PatchPolicy{
-						FromFieldPath: func() *FromFieldPathPolicy {
-							s := FromFieldPathPolicyRequired
-							return &s
-						}(),
+						MergeOptions: &MergeOptions{},
 					},
-					ToFieldPath: pointer.StringPtr("wat"),
 				},
 				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cp",
+						Labels: map[string]string{"cp": "cp", "both": "cp"},
+					},
 					ConnectionDetailsLastPublishedTimer: lpt,
 				},
 				cd: &fake.Composed{
-					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cd",
+						Labels: map[string]string{"cd": "cd", "both": "cd"},
+					},
 				},
 			},
 			want: want{
 				cd: &fake.Composed{
 					ObjectMeta: metav1.ObjectMeta{
-						Name: "cd",
+						Name:   "cd",
+						Labels: map[string]string{"cp": "cp", "cd": "cd", "both": "cp"},
 					},
 				},
-				err: errNotFound("wat"),
 			},
 		},
-		"FilterExcludeCompositeFieldPathPatch": {
-			reason: "Should not apply the patch as the PatchType is not present in filter.",
+		"MergeOptionsKeepMapValues": {
+			reason: "A patch with the keepMapValues merge option should not overwrite values that exist in the destination object",
 			args: args{
 				patch: Patch{
 					Type:          PatchTypeFromCompositeFieldPath,
 					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
-					ToFieldPath:   pointer.StringPtr("objectMeta.labels"),
+					Policy: &PatchPolicy{
+						MergeOptions: &MergeOptions{KeepMapValues: pointer.BoolPtr(true)},
+					},
 				},
 				cp: &fake.Composite{
 					ObjectMeta: metav1.ObjectMeta{
-						Name: "cp",
-						Labels: map[string]string{
-							"Test": "blah",
-						},
+						Name:   "cp",
+						Labels: map[string]string{"cp": "cp", "both": "cp"},
 					},
 					ConnectionDetailsLastPublishedTimer: lpt,
 				},
 				cd: &fake.Composed{
-					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cd",
+						Labels: map[string]string{"cd": "cd", "both": "cd"},
+					},
 				},
-				only: []PatchType{PatchTypePatchSet},
 			},
 			want: want{
 				cd: &fake.Composed{
 					ObjectMeta: metav1.ObjectMeta{
-						Name: "cd",
+						Name:   "cd",
+						Labels: map[string]string{"cp": "cp", "cd": "cd", "both": "cd"},
 					},
 				},
-				err: nil,
 			},
 		},
-		"FilterIncludeCompositeFieldPathPatch": {
-			reason: "Should apply the patch as the PatchType is present in filter.",
+		"MergeOptionsReplaceSlice": {
+			reason: "A patch with merge options should replace arrays unless the appendSlice merge option is set",
 			args: args{
 				patch: Patch{
 					Type:          PatchTypeFromCompositeFieldPath,
-					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
-					ToFieldPath:   pointer.StringPtr("objectMeta.labels"),
+					FromFieldPath: pointer.StringPtr("objectMeta.finalizers"),
+					Policy: &PatchPolicy{
+						MergeOptions: &MergeOptions{},
+					},
 				},
 				cp: &fake.Composite{
 					ObjectMeta: metav1.ObjectMeta{
-						Name: "cp",
-						Labels: map[string]string{
-							"Test": "blah",
-						},
+						Name:       "cp",
+						Finalizers: []string{"a", "b"},
 					},
 					ConnectionDetailsLastPublishedTimer: lpt,
 				},
 				cd: &fake.Composed{
-					ObjectMeta: metav1.ObjectMeta{Name: "cd"},
+					ObjectMeta: metav1.ObjectMeta{
+						Name:       "cd",
+						Finalizers: []string{"b", "c"},
+					},
 				},
-				only: []PatchType{PatchTypeFromCompositeFieldPath},
 			},
 			want: want{
 				cd: &fake.Composed{
 					ObjectMeta: metav1.ObjectMeta{
-						Name: "cd",
-						Labels: map[string]string{
-							"Test": "blah",
-						}},
+						Name:       "cd",
+						Finalizers: []string{"a", "b"},
+					},
 				},
-				err: nil,
 			},
 		},
-		"DefaultToFieldCompositeFieldPathPatch": {
-			reason: "Should correctly default the ToFieldPath value if not specified.",
+		"MergeOptionsAppendSlice": {
+			reason: "A patch with the appendSlice merge option should append to arrays in the destination object",
 			args: args{
 				patch: Patch{
 					Type:          PatchTypeFromCompositeFieldPath,
-					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
+					FromFieldPath: pointer.StringPtr("objectMeta.finalizers"),
+					Policy: &PatchPolicy{
+						MergeOptions: &MergeOptions{AppendSlice: pointer.BoolPtr(true)},
+					},
 				},
 				cp: &fake.Composite{
 					ObjectMeta: metav1.ObjectMeta{
-						Name: "cp",
-						Labels: map[string]string{
-							"Test": "blah",
-						},
+						Name:       "cp",
+						Finalizers: []string{"a", "b"},
 					},
 					ConnectionDetailsLastPublishedTimer: lpt,
 				},
 				cd: &fake.Composed{
 					ObjectMeta: metav1.ObjectMeta{
-						Name: "cd",
+						Name:       "cd",
+						Finalizers: []string{"b", "c"},
 					},
 				},
 			},
 			want: want{
-				cp: &fake.Composite{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "cp",
-						Labels: map[string]string{
-							"Test": "blah",
-						},
-					},
-					ConnectionDetailsLastPublishedTimer: lpt,
-				},
 				cd: &fake.Composed{
 					ObjectMeta: metav1.ObjectMeta{
-						Name: "cd",
-						Labels: map[string]string{
-							"Test": "blah",
-						}},
+						Name:       "cd",
+						Finalizers: []string{"b", "c", "a", "b"},
+					},
 				},
-				err: nil,
 			},
 		},
-		"ValidToCompositeFieldPathPatch": {
-			reason: "Should correctly apply a ToCompositeFieldPath patch with valid settings",
+		"MergeOptionsAppendSliceDeduplicate": {
+			reason: "A patch with the appendSlice and deduplicate merge options should not append elements that already exist in the destination array",
 			args: args{
 				patch: Patch{
-					Type:          PatchTypeToCompositeFieldPath,
-					FromFieldPath: pointer.StringPtr("objectMeta.labels"),
-					ToFieldPath:   pointer.StringPtr("objectMeta.labels"),
+					Type:          PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("objectMeta.finalizers"),
+					Policy: &PatchPolicy{
+						MergeOptions: &MergeOptions{AppendSlice: pointer.BoolPtr(true), Deduplicate: pointer.BoolPtr(true)},
+					},
 				},
 				cp: &fake.Composite{
 					ObjectMeta: metav1.ObjectMeta{
-						Name: "cp",
+						Name:       "cp",
+						Finalizers: []string{"a", "b"},
 					},
 					ConnectionDetailsLastPublishedTimer: lpt,
 				},
 				cd: &fake.Composed{
 					ObjectMeta: metav1.ObjectMeta{
-						Name: "cd",
-						Labels: map[string]string{
-							"Test": "blah",
-						},
+						Name:       "cd",
+						Finalizers: []string{"b", "c"},
 					},
 				},
 			},
 			want: want{
-				cp: &fake.Composite{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "cp",
-						Labels: map[string]string{
-							"Test": "blah",
-						},
-					},
-					ConnectionDetailsLastPublishedTimer: lpt,
-				},
 				cd: &fake.Composed{
 					ObjectMeta: metav1.ObjectMeta{
-						Name: "cd",
-						Labels: map[string]string{
-							"Test": "blah",
-						}},
+						Name:       "cd",
+						Finalizers: []string{"b", "c", "a"},
+					},
 				},
-				err: nil,
 			},
 		},
 	}
@@ -810,6 +1995,191 @@ func TestPatchApply(t *testing.T) {
 	}
 }
 
+func TestApplyCombineToComposite(t *testing.T) {
+	now := metav1.NewTime(time.Unix(0, 0))
+	lpt := fake.ConnectionDetailsLastPublishedTimer{
+		Time: &now,
+	}
+
+	patch := Patch{
+		Type: PatchTypeCombineToComposite,
+		Combine: &Combine{
+			Variables: []CombineVariable{
+				{FromResourceName: pointer.StringPtr("server"), FromFieldPath: "objectMeta.labels.host"},
+				{FromFieldPath: "objectMeta.labels.port"},
+			},
+			Strategy: CombineStrategyString,
+			String:   &StringCombine{Format: "%s:%s"},
+		},
+		ToFieldPath: pointer.StringPtr("objectMeta.labels.endpoint"),
+	}
+
+	type args struct {
+		patch    Patch
+		cd       *fake.Composed
+		composed map[string]runtime.Object
+	}
+	type want struct {
+		cp  *fake.Composite
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"Success": {
+			reason: "Variables should be read from the composed resources they name, or from the patch's composed resource",
+			args: args{
+				patch: patch,
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd", Labels: map[string]string{"port": "5432"}},
+				},
+				composed: map[string]runtime.Object{
+					"server": &fake.Composed{
+						ObjectMeta: metav1.ObjectMeta{Name: "server", Labels: map[string]string{"host": "example.org"}},
+					},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					ObjectMeta:                          metav1.ObjectMeta{Name: "cp", Labels: map[string]string{"endpoint": "example.org:5432"}},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+			},
+		},
+		"ResourceMissing": {
+			reason: "An error naming the missing composed resource should be returned",
+			args: args{
+				patch: patch,
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd", Labels: map[string]string{"port": "5432"}},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					ObjectMeta:                          metav1.ObjectMeta{Name: "cp"},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				err: errors.Errorf(errFmtCombineResourceMissing, 0, "server"),
+			},
+		},
+		"FieldPathMissing": {
+			reason: "An error naming the missing field path and its composed resource should be returned",
+			args: args{
+				patch: patch,
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cd", Labels: map[string]string{"port": "5432"}},
+				},
+				composed: map[string]runtime.Object{
+					"server": &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "server"}},
+				},
+			},
+			want: want{
+				cp: &fake.Composite{
+					ObjectMeta:                          metav1.ObjectMeta{Name: "cp"},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				err: errors.Wrapf(func() error {
+					_, err := fieldpath.Pave(map[string]interface{}{"objectMeta": map[string]interface{}{}}).GetValue("objectMeta.labels.host")
+					return err
+				}(), errFmtCombineVariable, 0, "objectMeta.labels.host", `composed resource "server"`),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cp := &fake.Composite{
+				ObjectMeta:                          metav1.ObjectMeta{Name: "cp"},
+				ConnectionDetailsLastPublishedTimer: lpt,
+			}
+			err := tc.args.patch.ApplyCombineToComposite(cp, tc.args.cd, tc.args.composed)
+			if diff := cmp.Diff(tc.want.cp, cp); diff != "" {
+				t.Errorf("\n%s\nApplyCombineToComposite(cp): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nApplyCombineToComposite(err): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCombine(t *testing.T) {
+	type args struct {
+		c    *Combine
+		vars []interface{}
+	}
+	type want struct {
+		o   interface{}
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"UnsupportedStrategy": {
+			reason: "Should return an error if an unsupported strategy is specified",
+			args: args{
+				c: &Combine{Strategy: "wat"},
+			},
+			want: want{
+				err: errors.Errorf(errFmtCombineStrategyNotSupported, "wat"),
+			},
+		},
+		"MissingStringConfiguration": {
+			reason: "Should return an error if the string strategy is specified without configuration",
+			args: args{
+				c: &Combine{Strategy: CombineStrategyString},
+			},
+			want: want{
+				err: errors.Errorf(errFmtCombineConfigMissing, CombineStrategyString),
+			},
+		},
+		"StringFormat": {
+			reason: "Should combine variables using the supplied format string",
+			args: args{
+				c: &Combine{
+					Strategy: CombineStrategyString,
+					String:   &StringCombine{Format: "%s-%d"},
+				},
+				vars: []interface{}{"db", 5},
+			},
+			want: want{
+				o: "db-5",
+			},
+		},
+		"StringFormatExplicitIndexes": {
+			reason: "Should support explicit argument indexes when combining variables",
+			args: args{
+				c: &Combine{
+					Strategy: CombineStrategyString,
+					String:   &StringCombine{Format: "%[2]s.%[1]s"},
+				},
+				vars: []interface{}{"example.org", "db"},
+			},
+			want: want{
+				o: "db.example.org",
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.args.c.Combine(tc.args.vars)
+
+			if diff := cmp.Diff(tc.want.o, got); diff != "" {
+				t.Errorf("\n%s\nCombine(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nCombine(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
 func TestOptionalFieldPathNotFound(t *testing.T) {
 	errBoom := errors.New("boom")
 	errNotFound := func() error {
@@ -887,3 +2257,72 @@ func TestOptionalFieldPathNotFound(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeOptionsMerge(t *testing.T) {
+	type args struct {
+		mo       *MergeOptions
+		existing interface{}
+		patched  interface{}
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   interface{}
+	}{
+		"TypeMismatch": {
+			reason: "The patched value should replace an existing value of a different type",
+			args: args{
+				mo:       &MergeOptions{AppendSlice: pointer.BoolPtr(true)},
+				existing: map[string]interface{}{"a": "b"},
+				patched:  []interface{}{"a"},
+			},
+			want: []interface{}{"a"},
+		},
+		"NestedObjects": {
+			reason: "Nested objects should be deep merged",
+			args: args{
+				mo: &MergeOptions{},
+				existing: map[string]interface{}{
+					"tags": map[string]interface{}{"env": "dev", "team": "a"},
+					"size": "small",
+				},
+				patched: map[string]interface{}{
+					"tags": map[string]interface{}{"env": "prod"},
+					"size": "large",
+				},
+			},
+			want: map[string]interface{}{
+				"tags": map[string]interface{}{"env": "prod", "team": "a"},
+				"size": "large",
+			},
+		},
+		"NestedArrays": {
+			reason: "Nested arrays should be appended to when appendSlice is set, even if keepMapValues is set",
+			args: args{
+				mo: &MergeOptions{AppendSlice: pointer.BoolPtr(true), KeepMapValues: pointer.BoolPtr(true)},
+				existing: map[string]interface{}{
+					"rules": []interface{}{map[string]interface{}{"port": int64(80)}},
+					"size":  "small",
+				},
+				patched: map[string]interface{}{
+					"rules": []interface{}{map[string]interface{}{"port": int64(443)}},
+					"size":  "large",
+				},
+			},
+			want: map[string]interface{}{
+				"rules": []interface{}{map[string]interface{}{"port": int64(80)}, map[string]interface{}{"port": int64(443)}},
+				"size":  "small",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := tc.args.mo.Merge(tc.args.existing, tc.args.patched)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nMerge(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -49,7 +49,9 @@ type CompositeResourceDefinitionSpec struct {
 	ClaimNames *extv1.CustomResourceDefinitionNames `json:"claimNames,omitempty"`
 
 	// ConnectionSecretKeys is the list of keys that will be exposed to the end
-	// user of the defined kind.
+	// user of the defined kind. Keys may be glob patterns, such as
+	// 'kubeconfig*', in which case all keys matching the pattern are exposed.
+	// Use '*' to expose all keys. No keys are exposed if none are specified.
 	// +optional
 	ConnectionSecretKeys []string `json:"connectionSecretKeys,omitempty"`
 
@@ -218,8 +220,8 @@ func (in CompositeResourceDefinition) GetClaimGroupVersionKind() schema.GroupVer
 	return schema.GroupVersionKind{Group: in.Spec.Group, Version: v, Kind: in.Spec.ClaimNames.Kind}
 }
 
-// GetConnectionSecretKeys returns the set of allowed keys to filter the connection
-// secret.
+// GetConnectionSecretKeys returns the set of allowed key patterns to filter the
+// connection secret.
 func (in *CompositeResourceDefinition) GetConnectionSecretKeys() []string {
 	return in.Spec.ConnectionSecretKeys
 }
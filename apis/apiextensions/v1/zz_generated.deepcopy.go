@@ -23,9 +23,62 @@ package v1
 import (
 	commonv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Combine) DeepCopyInto(out *Combine) {
+	*out = *in
+	if in.Variables != nil {
+		in, out := &in.Variables, &out.Variables
+		*out = make([]CombineVariable, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.String != nil {
+		in, out := &in.String, &out.String
+		*out = new(StringCombine)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Combine.
+func (in *Combine) DeepCopy() *Combine {
+	if in == nil {
+		return nil
+	}
+	out := new(Combine)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CombineVariable) DeepCopyInto(out *CombineVariable) {
+	*out = *in
+	if in.FromResourceName != nil {
+		in, out := &in.FromResourceName, &out.FromResourceName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Policy != nil {
+		in, out := &in.Policy, &out.Policy
+		*out = new(FromFieldPathPolicy)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CombineVariable.
+func (in *CombineVariable) DeepCopy() *CombineVariable {
+	if in == nil {
+		return nil
+	}
+	out := new(CombineVariable)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ComposedTemplate) DeepCopyInto(out *ComposedTemplate) {
 	*out = *in
@@ -52,8 +105,30 @@ func (in *ComposedTemplate) DeepCopyInto(out *ComposedTemplate) {
 	if in.ReadinessChecks != nil {
 		in, out := &in.ReadinessChecks, &out.ReadinessChecks
 		*out = make([]ReadinessCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Condition != nil {
+		in, out := &in.Condition, &out.Condition
+		*out = new(ComposedTemplateCondition)
+		**out = **in
+	}
+	if in.ForEach != nil {
+		in, out := &in.ForEach, &out.ForEach
+		*out = new(ForEach)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.DeletionPolicy != nil {
+		in, out := &in.DeletionPolicy, &out.DeletionPolicy
+		*out = new(commonv1.DeletionPolicy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComposedTemplate.
@@ -66,6 +141,21 @@ func (in *ComposedTemplate) DeepCopy() *ComposedTemplate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComposedTemplateCondition) DeepCopyInto(out *ComposedTemplateCondition) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComposedTemplateCondition.
+func (in *ComposedTemplateCondition) DeepCopy() *ComposedTemplateCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(ComposedTemplateCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CompositeResourceDefinition) DeepCopyInto(out *CompositeResourceDefinition) {
 	*out = *in
@@ -325,6 +415,16 @@ func (in *CompositionSpec) DeepCopyInto(out *CompositionSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Environment != nil {
+		in, out := &in.Environment, &out.Environment
+		*out = new(EnvironmentConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DeletionOrder != nil {
+		in, out := &in.DeletionOrder, &out.DeletionOrder
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CompositionSpec.
@@ -381,6 +481,18 @@ func (in *ConnectionDetail) DeepCopyInto(out *ConnectionDetail) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Combine != nil {
+		in, out := &in.Combine, &out.Combine
+		*out = new(ConnectionDetailCombine)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Transforms != nil {
+		in, out := &in.Transforms, &out.Transforms
+		*out = make([]Transform, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionDetail.
@@ -393,6 +505,63 @@ func (in *ConnectionDetail) DeepCopy() *ConnectionDetail {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionDetailCombine) DeepCopyInto(out *ConnectionDetailCombine) {
+	*out = *in
+	if in.Variables != nil {
+		in, out := &in.Variables, &out.Variables
+		*out = make([]ConnectionDetailVariable, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.String != nil {
+		in, out := &in.String, &out.String
+		*out = new(StringCombine)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionDetailCombine.
+func (in *ConnectionDetailCombine) DeepCopy() *ConnectionDetailCombine {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionDetailCombine)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionDetailVariable) DeepCopyInto(out *ConnectionDetailVariable) {
+	*out = *in
+	if in.FromConnectionSecretKey != nil {
+		in, out := &in.FromConnectionSecretKey, &out.FromConnectionSecretKey
+		*out = new(string)
+		**out = **in
+	}
+	if in.FromFieldPath != nil {
+		in, out := &in.FromFieldPath, &out.FromFieldPath
+		*out = new(string)
+		**out = **in
+	}
+	if in.Value != nil {
+		in, out := &in.Value, &out.Value
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionDetailVariable.
+func (in *ConnectionDetailVariable) DeepCopy() *ConnectionDetailVariable {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionDetailVariable)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ConvertTransform) DeepCopyInto(out *ConvertTransform) {
 	*out = *in
@@ -408,16 +577,103 @@ func (in *ConvertTransform) DeepCopy() *ConvertTransform {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentConfiguration) DeepCopyInto(out *EnvironmentConfiguration) {
+	*out = *in
+	if in.EnvironmentConfigs != nil {
+		in, out := &in.EnvironmentConfigs, &out.EnvironmentConfigs
+		*out = make([]EnvironmentSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentConfiguration.
+func (in *EnvironmentConfiguration) DeepCopy() *EnvironmentConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentSource) DeepCopyInto(out *EnvironmentSource) {
+	*out = *in
+	if in.Ref != nil {
+		in, out := &in.Ref, &out.Ref
+		*out = new(EnvironmentSourceReference)
+		**out = **in
+	}
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentSource.
+func (in *EnvironmentSource) DeepCopy() *EnvironmentSource {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvironmentSourceReference) DeepCopyInto(out *EnvironmentSourceReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvironmentSourceReference.
+func (in *EnvironmentSourceReference) DeepCopy() *EnvironmentSourceReference {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvironmentSourceReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForEach) DeepCopyInto(out *ForEach) {
+	*out = *in
+	if in.KeyFieldPath != nil {
+		in, out := &in.KeyFieldPath, &out.KeyFieldPath
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForEach.
+func (in *ForEach) DeepCopy() *ForEach {
+	if in == nil {
+		return nil
+	}
+	out := new(ForEach)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MapTransform) DeepCopyInto(out *MapTransform) {
 	*out = *in
 	if in.Pairs != nil {
 		in, out := &in.Pairs, &out.Pairs
-		*out = make(map[string]string, len(*in))
+		*out = make(map[string]apiextensionsv1.JSON, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.FallbackValue != nil {
+		in, out := &in.FallbackValue, &out.FallbackValue
+		*out = new(apiextensionsv1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MapTransform.
@@ -430,6 +686,74 @@ func (in *MapTransform) DeepCopy() *MapTransform {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MatchConditionReadinessCheck) DeepCopyInto(out *MatchConditionReadinessCheck) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MatchConditionReadinessCheck.
+func (in *MatchConditionReadinessCheck) DeepCopy() *MatchConditionReadinessCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(MatchConditionReadinessCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MatchTransform) DeepCopyInto(out *MatchTransform) {
+	*out = *in
+	if in.Patterns != nil {
+		in, out := &in.Patterns, &out.Patterns
+		*out = make([]MatchTransformPattern, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FallbackValue != nil {
+		in, out := &in.FallbackValue, &out.FallbackValue
+		*out = new(apiextensionsv1.JSON)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MatchTransform.
+func (in *MatchTransform) DeepCopy() *MatchTransform {
+	if in == nil {
+		return nil
+	}
+	out := new(MatchTransform)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MatchTransformPattern) DeepCopyInto(out *MatchTransformPattern) {
+	*out = *in
+	if in.Literal != nil {
+		in, out := &in.Literal, &out.Literal
+		*out = new(string)
+		**out = **in
+	}
+	if in.Regexp != nil {
+		in, out := &in.Regexp, &out.Regexp
+		*out = new(string)
+		**out = **in
+	}
+	in.Result.DeepCopyInto(&out.Result)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MatchTransformPattern.
+func (in *MatchTransformPattern) DeepCopy() *MatchTransformPattern {
+	if in == nil {
+		return nil
+	}
+	out := new(MatchTransformPattern)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MathTransform) DeepCopyInto(out *MathTransform) {
 	*out = *in
@@ -438,6 +762,41 @@ func (in *MathTransform) DeepCopyInto(out *MathTransform) {
 		*out = new(int64)
 		**out = **in
 	}
+	if in.Add != nil {
+		in, out := &in.Add, &out.Add
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Subtract != nil {
+		in, out := &in.Subtract, &out.Subtract
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Divide != nil {
+		in, out := &in.Divide, &out.Divide
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Modulo != nil {
+		in, out := &in.Modulo, &out.Modulo
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ClampMin != nil {
+		in, out := &in.ClampMin, &out.ClampMin
+		*out = new(int64)
+		**out = **in
+	}
+	if in.ClampMax != nil {
+		in, out := &in.ClampMax, &out.ClampMax
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Round != nil {
+		in, out := &in.Round, &out.Round
+		*out = new(MathRoundingMode)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MathTransform.
@@ -450,6 +809,36 @@ func (in *MathTransform) DeepCopy() *MathTransform {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MergeOptions) DeepCopyInto(out *MergeOptions) {
+	*out = *in
+	if in.KeepMapValues != nil {
+		in, out := &in.KeepMapValues, &out.KeepMapValues
+		*out = new(bool)
+		**out = **in
+	}
+	if in.AppendSlice != nil {
+		in, out := &in.AppendSlice, &out.AppendSlice
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Deduplicate != nil {
+		in, out := &in.Deduplicate, &out.Deduplicate
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MergeOptions.
+func (in *MergeOptions) DeepCopy() *MergeOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(MergeOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Patch) DeepCopyInto(out *Patch) {
 	*out = *in
@@ -458,6 +847,16 @@ func (in *Patch) DeepCopyInto(out *Patch) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.FromResourceName != nil {
+		in, out := &in.FromResourceName, &out.FromResourceName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Combine != nil {
+		in, out := &in.Combine, &out.Combine
+		*out = new(Combine)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ToFieldPath != nil {
 		in, out := &in.ToFieldPath, &out.ToFieldPath
 		*out = new(string)
@@ -500,6 +899,11 @@ func (in *PatchPolicy) DeepCopyInto(out *PatchPolicy) {
 		*out = new(FromFieldPathPolicy)
 		**out = **in
 	}
+	if in.MergeOptions != nil {
+		in, out := &in.MergeOptions, &out.MergeOptions
+		*out = new(MergeOptions)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PatchPolicy.
@@ -537,6 +941,11 @@ func (in *PatchSet) DeepCopy() *PatchSet {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReadinessCheck) DeepCopyInto(out *ReadinessCheck) {
 	*out = *in
+	if in.MatchCondition != nil {
+		in, out := &in.MatchCondition, &out.MatchCondition
+		*out = new(MatchConditionReadinessCheck)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReadinessCheck.
@@ -549,9 +958,39 @@ func (in *ReadinessCheck) DeepCopy() *ReadinessCheck {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StringCombine) DeepCopyInto(out *StringCombine) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringCombine.
+func (in *StringCombine) DeepCopy() *StringCombine {
+	if in == nil {
+		return nil
+	}
+	out := new(StringCombine)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StringTransform) DeepCopyInto(out *StringTransform) {
 	*out = *in
+	if in.Convert != nil {
+		in, out := &in.Convert, &out.Convert
+		*out = new(StringConversionType)
+		**out = **in
+	}
+	if in.Trim != nil {
+		in, out := &in.Trim, &out.Trim
+		*out = new(string)
+		**out = **in
+	}
+	if in.Regexp != nil {
+		in, out := &in.Regexp, &out.Regexp
+		*out = new(StringTransformRegexp)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringTransform.
@@ -564,6 +1003,26 @@ func (in *StringTransform) DeepCopy() *StringTransform {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StringTransformRegexp) DeepCopyInto(out *StringTransformRegexp) {
+	*out = *in
+	if in.Group != nil {
+		in, out := &in.Group, &out.Group
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StringTransformRegexp.
+func (in *StringTransformRegexp) DeepCopy() *StringTransformRegexp {
+	if in == nil {
+		return nil
+	}
+	out := new(StringTransformRegexp)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Transform) DeepCopyInto(out *Transform) {
 	*out = *in
@@ -580,13 +1039,18 @@ func (in *Transform) DeepCopyInto(out *Transform) {
 	if in.String != nil {
 		in, out := &in.String, &out.String
 		*out = new(StringTransform)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Convert != nil {
 		in, out := &in.Convert, &out.Convert
 		*out = new(ConvertTransform)
 		**out = **in
 	}
+	if in.Match != nil {
+		in, out := &in.Match, &out.Match
+		*out = new(MatchTransform)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Transform.
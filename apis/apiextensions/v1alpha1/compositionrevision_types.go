@@ -0,0 +1,73 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+// Label keys.
+const (
+	// LabelCompositionName is the name of the Composition a revision was
+	// created from.
+	LabelCompositionName = "crossplane.io/composition-name"
+
+	// LabelCompositionSpecHash is a hash of the Composition spec a revision
+	// was created from.
+	LabelCompositionSpecHash = "crossplane.io/composition-spec-hash"
+)
+
+// CompositionRevisionSpec specifies the desired state of the composition
+// revision.
+type CompositionRevisionSpec struct {
+	// The spec of the Composition this revision was created from.
+	v1.CompositionSpec `json:",inline"`
+
+	// Revision number. Newer revisions have larger numbers.
+	// +immutable
+	Revision int64 `json:"revision"`
+}
+
+// +kubebuilder:object:root=true
+// +genclient
+// +genclient:nonNamespaced
+
+// A CompositionRevision is an immutable snapshot of the spec of a Composition.
+// A new revision is created each time the spec of a Composition changes.
+// Composite resources are composed using a revision of their Composition.
+// +kubebuilder:printcolumn:name="REVISION",type="string",JSONPath=".spec.revision"
+// +kubebuilder:printcolumn:name="XR-KIND",type="string",JSONPath=".spec.compositeTypeRef.kind"
+// +kubebuilder:printcolumn:name="XR-APIVERSION",type="string",JSONPath=".spec.compositeTypeRef.apiVersion"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:resource:scope=Cluster,categories=crossplane,shortName=comprev
+type CompositionRevision struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CompositionRevisionSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// CompositionRevisionList contains a list of CompositionRevisions.
+type CompositionRevisionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CompositionRevision `json:"items"`
+}
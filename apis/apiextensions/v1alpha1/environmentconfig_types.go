@@ -0,0 +1,50 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+// +genclient
+// +genclient:nonNamespaced
+
+// An EnvironmentConfig contains a set of arbitrary, unstructured values that
+// may be patched into composed resources. EnvironmentConfigs are typically
+// used to store per-cluster settings such as account IDs, network IDs, or
+// region defaults that are shared by many composite resources.
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:resource:scope=Cluster,categories=crossplane,shortName=envcfg
+type EnvironmentConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// The data of this EnvironmentConfig. Values may be any valid JSON.
+	// +optional
+	Data map[string]extv1.JSON `json:"data,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// EnvironmentConfigList contains a list of EnvironmentConfigs.
+type EnvironmentConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EnvironmentConfig `json:"items"`
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+)
+
+// A StoreType is a type of connection details store.
+type StoreType string
+
+// Supported store types.
+const (
+	// StoreTypeKubernetes stores connection details in Kubernetes Secrets.
+	StoreTypeKubernetes StoreType = "Kubernetes"
+
+	// StoreTypeVault stores connection details in a Vault KV secrets engine.
+	StoreTypeVault StoreType = "Vault"
+)
+
+// A VaultKVVersion is a version of the Vault KV secrets engine.
+type VaultKVVersion string
+
+// Supported Vault KV secrets engine versions.
+const (
+	VaultKVVersionV1 VaultKVVersion = "v1"
+	VaultKVVersionV2 VaultKVVersion = "v2"
+)
+
+// A VaultAuthMethod is a method of authenticating to Vault.
+type VaultAuthMethod string
+
+// Supported Vault authentication methods.
+const (
+	// VaultAuthMethodToken authenticates to Vault using a token read from a
+	// Kubernetes Secret.
+	VaultAuthMethodToken VaultAuthMethod = "Token"
+)
+
+// StoreConfigSpec configures a store of connection details.
+type StoreConfigSpec struct {
+	// Type of the store.
+	// +kubebuilder:validation:Enum=Kubernetes;Vault
+	// +kubebuilder:default=Kubernetes
+	Type StoreType `json:"type"`
+
+	// DefaultScope within which connection details are stored. For the
+	// Kubernetes store this is the namespace of connection Secrets. For the
+	// Vault store it is a path prefix within the KV secrets engine.
+	DefaultScope string `json:"defaultScope"`
+
+	// Vault configures a Vault store. Required if the type is Vault.
+	// +optional
+	Vault *VaultStoreConfig `json:"vault,omitempty"`
+}
+
+// VaultStoreConfig configures a Vault KV store.
+type VaultStoreConfig struct {
+	// Server is the address of the Vault server, e.g.
+	// https://vault.example.org:8200.
+	Server string `json:"server"`
+
+	// MountPath is the path at which the KV secrets engine is mounted, e.g.
+	// secret.
+	MountPath string `json:"mountPath"`
+
+	// Version of the KV secrets engine.
+	// +kubebuilder:validation:Enum=v1;v2
+	// +kubebuilder:default=v2
+	// +optional
+	Version *VaultKVVersion `json:"version,omitempty"`
+
+	// Auth configures how to authenticate to Vault.
+	Auth VaultAuthConfig `json:"auth"`
+}
+
+// VaultAuthConfig configures how to authenticate to Vault.
+type VaultAuthConfig struct {
+	// Method used to authenticate to Vault.
+	// +kubebuilder:validation:Enum=Token
+	Method VaultAuthMethod `json:"method"`
+
+	// Token configures token authentication. Required if the method is
+	// Token.
+	// +optional
+	Token *VaultTokenAuthConfig `json:"token,omitempty"`
+}
+
+// VaultTokenAuthConfig configures token authentication to Vault.
+type VaultTokenAuthConfig struct {
+	// SecretRef selects the key of a Kubernetes Secret containing the token.
+	SecretRef xpv1.SecretKeySelector `json:"secretRef"`
+}
+
+// +kubebuilder:object:root=true
+// +genclient
+// +genclient:nonNamespaced
+
+// A StoreConfig configures a store of composite resource connection details.
+// Composite resources select a StoreConfig using their
+// spec.publishConnectionDetailsTo field.
+// +kubebuilder:printcolumn:name="TYPE",type="string",JSONPath=".spec.type"
+// +kubebuilder:printcolumn:name="DEFAULT-SCOPE",type="string",JSONPath=".spec.defaultScope"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:resource:scope=Cluster,categories=crossplane
+type StoreConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec StoreConfigSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+
+// StoreConfigList contains a list of StoreConfigs.
+type StoreConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []StoreConfig `json:"items"`
+}
+
+// PublishConnectionDetailsTo configures where a composite resource publishes
+// its connection details, in addition to any connection Secret it writes.
+type PublishConnectionDetailsTo struct {
+	// Name under which connection details are stored, within the default
+	// scope of the StoreConfig.
+	Name string `json:"name"`
+
+	// ConfigRef references the StoreConfig used to store connection details.
+	// Defaults to the StoreConfig named default.
+	// +optional
+	ConfigRef *StoreConfigReference `json:"configRef,omitempty"`
+}
+
+// A StoreConfigReference references a StoreConfig.
+type StoreConfigReference struct {
+	// Name of the referenced StoreConfig.
+	Name string `json:"name"`
+}
+
+// ConnectionSecretMetadata configures the metadata of the connection Secret
+// of a composite resource or claim.
+type ConnectionSecretMetadata struct {
+	// Labels to add to the connection Secret.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations to add to the connection Secret.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Type of the connection Secret. Defaults to
+	// connection.crossplane.io/v1alpha1. The type of a Secret cannot be
+	// changed once it has been created.
+	// +optional
+	Type *corev1.SecretType `json:"type,omitempty"`
+}
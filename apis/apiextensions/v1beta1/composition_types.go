@@ -17,6 +17,8 @@ limitations under the License.
 package v1beta1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -46,6 +48,69 @@ type CompositionSpec struct {
 	// this composition will be created.
 	// +optional
 	WriteConnectionSecretsToNamespace *string `json:"writeConnectionSecretsToNamespace,omitempty"`
+
+	// Environment configures the environment from which composed resources
+	// may be patched using FromEnvironmentFieldPath patches.
+	// +optional
+	Environment *EnvironmentConfiguration `json:"environment,omitempty"`
+
+	// DeletionOrder is a list of resource template names. When a composite
+	// resource is deleted the composed resources of these templates are
+	// deleted first, in the listed order. The remaining composed resources are
+	// then deleted in the reverse of the order in which they are applied; i.e.
+	// after any resources that depend on them, and otherwise in reverse
+	// declaration order. Each composed resource is deleted only once the
+	// resource deleted before it no longer exists.
+	// +optional
+	DeletionOrder []string `json:"deletionOrder,omitempty"`
+}
+
+// EnvironmentConfiguration selects the EnvironmentConfigs that form the
+// environment of composite resources that use a Composition.
+type EnvironmentConfiguration struct {
+	// EnvironmentConfigs selects EnvironmentConfigs by reference or by label
+	// selector. The data of all selected EnvironmentConfigs is deep merged in
+	// order to form the environment, with later EnvironmentConfigs taking
+	// precedence. EnvironmentConfigs matched by a selector are merged in
+	// order of their names.
+	// +optional
+	EnvironmentConfigs []EnvironmentSource `json:"environmentConfigs,omitempty"`
+}
+
+// EnvironmentSourceType specifies how an EnvironmentSource selects
+// EnvironmentConfigs.
+type EnvironmentSourceType string
+
+// Accepted EnvironmentSourceTypes.
+const (
+	EnvironmentSourceTypeReference EnvironmentSourceType = "Reference"
+	EnvironmentSourceTypeSelector  EnvironmentSourceType = "Selector"
+)
+
+// An EnvironmentSource selects one or more EnvironmentConfigs.
+type EnvironmentSource struct {
+	// Type specifies whether EnvironmentConfigs are selected by reference or
+	// by label selector.
+	// +optional
+	// +kubebuilder:validation:Enum=Reference;Selector
+	// +kubebuilder:default=Reference
+	Type EnvironmentSourceType `json:"type,omitempty"`
+
+	// Ref is a reference to an EnvironmentConfig by name. Required when type
+	// is Reference.
+	// +optional
+	Ref *EnvironmentSourceReference `json:"ref,omitempty"`
+
+	// Selector selects EnvironmentConfigs by label. Required when type is
+	// Selector. All matching EnvironmentConfigs are selected.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// An EnvironmentSourceReference references an EnvironmentConfig by name.
+type EnvironmentSourceReference struct {
+	// Name of the referenced EnvironmentConfig.
+	Name string `json:"name"`
 }
 
 // A PatchSet is a set of patches that can be reused from all resources within
@@ -103,6 +168,110 @@ type ComposedTemplate struct {
 	// default readiness check is to have the "Ready" condition to be "True".
 	// +optional
 	ReadinessChecks []ReadinessCheck `json:"readinessChecks,omitempty"`
+
+	// Condition determines whether this template is included when composing
+	// resources. The condition is evaluated against the composite resource.
+	// Templates without a condition are always included. A composed resource
+	// that was created from this template is deleted if the condition is no
+	// longer met. Only named templates may specify a condition.
+	// +optional
+	Condition *ComposedTemplateCondition `json:"condition,omitempty"`
+
+	// ForEach expands this template into one template per element of an
+	// array field of the composite resource. FromElementFieldPath patches may
+	// be used to patch from each element. Composed resources are identified by
+	// the key of the element they were composed from, and are deleted when
+	// their element is removed from the array. Only named templates may
+	// specify forEach.
+	// +optional
+	ForEach *ForEach `json:"forEach,omitempty"`
+
+	// DependsOn lists the names of other resource templates whose composed
+	// resources must be ready before the composed resource of this template is
+	// applied. Dependencies must not be cyclic, and may not name a template
+	// that specifies forEach. A dependency on a template that is excluded by
+	// its condition is ignored. Only named templates may specify dependencies.
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// DeletionPolicy specifies what will happen to the composed resource of
+	// this template when its composite resource is deleted. The composed
+	// resource is deleted by default. It is instead orphaned, and thus
+	// retained, when the policy is Orphan. The deletion policy of the
+	// composite resource applies when this field is not set.
+	// +optional
+	// +kubebuilder:validation:Enum=Orphan;Delete
+	DeletionPolicy *xpv1.DeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// ForEachKeyType determines how the elements of a ForEach array are
+// identified.
+type ForEachKeyType string
+
+// ForEach key types.
+const (
+	ForEachKeyTypeIndex     ForEachKeyType = "Index"
+	ForEachKeyTypeValue     ForEachKeyType = "Value"
+	ForEachKeyTypeFieldPath ForEachKeyType = "FieldPath"
+)
+
+// ForEach configures how a template is expanded into one template per element
+// of an array field of the composite resource.
+type ForEach struct {
+	// FieldPath of the composite resource array to iterate over. No resources
+	// are composed if the field path does not exist.
+	FieldPath string `json:"fieldPath"`
+
+	// Key determines how each element is identified. Elements are identified
+	// by their index in the array when the key is Index, by their value when
+	// the key is Value, and by the value of the field at the keyFieldPath of
+	// each element when the key is FieldPath. Keys must be unique scalar
+	// values. Using a key other than Index allows elements to be reordered, or
+	// removed from the middle of the array, without affecting the composed
+	// resources of other elements.
+	// +optional
+	// +kubebuilder:validation:Enum=Index;Value;FieldPath
+	// +kubebuilder:default=Index
+	Key ForEachKeyType `json:"key,omitempty"`
+
+	// KeyFieldPath is the path of the field within each element whose value
+	// identifies the element. Required when key is FieldPath.
+	// +optional
+	KeyFieldPath *string `json:"keyFieldPath,omitempty"`
+}
+
+// ComposedTemplateConditionType is used for composed template condition types.
+type ComposedTemplateConditionType string
+
+// The possible values for composed template condition type.
+const (
+	ComposedTemplateConditionTypeFieldPathExists ComposedTemplateConditionType = "FieldPathExists"
+	ComposedTemplateConditionTypeMatchString     ComposedTemplateConditionType = "MatchString"
+	ComposedTemplateConditionTypeMatchInteger    ComposedTemplateConditionType = "MatchInteger"
+	ComposedTemplateConditionTypeMatchTrue       ComposedTemplateConditionType = "MatchTrue"
+	ComposedTemplateConditionTypeMatchFalse      ComposedTemplateConditionType = "MatchFalse"
+)
+
+// A ComposedTemplateCondition is a predicate that is evaluated against a field
+// of the composite resource. A condition is never met if its field path does
+// not exist.
+type ComposedTemplateCondition struct {
+	// Type indicates the type of predicate you'd like to use.
+	// +kubebuilder:validation:Enum=FieldPathExists;MatchString;MatchInteger;MatchTrue;MatchFalse
+	Type ComposedTemplateConditionType `json:"type"`
+
+	// FieldPath of the composite resource whose value will be used.
+	FieldPath string `json:"fieldPath"`
+
+	// MatchString is the value you'd like to match if you're using
+	// "MatchString" type.
+	// +optional
+	MatchString string `json:"matchString,omitempty"`
+
+	// MatchInteger is the value you'd like to match if you're using
+	// "MatchInteger" type.
+	// +optional
+	MatchInteger int64 `json:"matchInteger,omitempty"`
 }
 
 // ReadinessCheckType is used for readiness check types.
@@ -114,13 +283,19 @@ const (
 	ReadinessCheckTypeMatchString  ReadinessCheckType = "MatchString"
 	ReadinessCheckTypeMatchInteger ReadinessCheckType = "MatchInteger"
 	ReadinessCheckTypeNone         ReadinessCheckType = "None"
+
+	ReadinessCheckTypeMatchCondition                 ReadinessCheckType = "MatchCondition"
+	ReadinessCheckTypeMatchTrue                      ReadinessCheckType = "MatchTrue"
+	ReadinessCheckTypeMatchFalse                     ReadinessCheckType = "MatchFalse"
+	ReadinessCheckTypeMatchIntegerGreaterThanOrEqual ReadinessCheckType = "MatchIntegerGreaterThanOrEqual"
+	ReadinessCheckTypeMatchIntegerLessThanOrEqual    ReadinessCheckType = "MatchIntegerLessThanOrEqual"
 )
 
 // ReadinessCheck is used to indicate how to tell whether a resource is ready
 // for consumption
 type ReadinessCheck struct {
 	// Type indicates the type of probe you'd like to use.
-	// +kubebuilder:validation:Enum="MatchString";"MatchInteger";"NonEmpty";"None"
+	// +kubebuilder:validation:Enum="MatchString";"MatchInteger";"NonEmpty";"None";"MatchCondition";"MatchTrue";"MatchFalse";"MatchIntegerGreaterThanOrEqual";"MatchIntegerLessThanOrEqual"
 	Type ReadinessCheckType `json:"type"`
 
 	// FieldPath shows the path of the field whose value will be used.
@@ -132,8 +307,27 @@ type ReadinessCheck struct {
 	MatchString string `json:"matchString,omitempty"`
 
 	// MatchInt is the value you'd like to match if you're using "MatchInt" type.
+	// It is also the bound used by the "MatchIntegerGreaterThanOrEqual" and
+	// "MatchIntegerLessThanOrEqual" types.
 	// +optional
 	MatchInteger int64 `json:"matchInteger,omitempty"`
+
+	// MatchCondition is the condition you'd like to match if you're using
+	// "MatchCondition" type. The fieldPath is not used by this type.
+	// +optional
+	MatchCondition *MatchConditionReadinessCheck `json:"matchCondition,omitempty"`
+}
+
+// MatchConditionReadinessCheck is used to indicate how to tell whether a
+// resource is ready for consumption by matching one of its conditions.
+type MatchConditionReadinessCheck struct {
+	// Type indicates the type of condition you'd like to use.
+	// +kubebuilder:default="Ready"
+	Type xpv1.ConditionType `json:"type"`
+
+	// Status is the status of the condition you'd like to match.
+	// +kubebuilder:default="True"
+	Status corev1.ConditionStatus `json:"status"`
 }
 
 // A PatchType is a type of patch.
@@ -144,6 +338,12 @@ const (
 	PatchTypeFromCompositeFieldPath PatchType = "FromCompositeFieldPath" // Default
 	PatchTypePatchSet               PatchType = "PatchSet"
 	PatchTypeToCompositeFieldPath   PatchType = "ToCompositeFieldPath"
+	PatchTypeCombineFromComposite   PatchType = "CombineFromComposite"
+	PatchTypeCombineToComposite     PatchType = "CombineToComposite"
+
+	PatchTypeFromEnvironmentFieldPath PatchType = "FromEnvironmentFieldPath"
+	PatchTypeFromComposedFieldPath    PatchType = "FromComposedFieldPath"
+	PatchTypeFromElementFieldPath     PatchType = "FromElementFieldPath"
 )
 
 // Patch objects are applied between composite and composed resources. Their
@@ -154,19 +354,35 @@ type Patch struct {
 	// Type sets the patching behaviour to be used. Each patch type may require
 	// its' own fields to be set on the Patch object.
 	// +optional
-	// +kubebuilder:validation:Enum=FromCompositeFieldPath;PatchSet;ToCompositeFieldPath
+	// +kubebuilder:validation:Enum=FromCompositeFieldPath;PatchSet;ToCompositeFieldPath;CombineFromComposite;CombineToComposite;FromEnvironmentFieldPath;FromComposedFieldPath;FromElementFieldPath
 	// +kubebuilder:default=FromCompositeFieldPath
 	Type PatchType `json:"type,omitempty"`
 
 	// FromFieldPath is the path of the field on the resource whose value is
-	// to be used as input. Required when type is FromCompositeFieldPath or
-	// ToCompositeFieldPath.
+	// to be used as input. Required when type is FromCompositeFieldPath,
+	// ToCompositeFieldPath, FromEnvironmentFieldPath, FromComposedFieldPath,
+	// or FromElementFieldPath. The input of a FromElementFieldPath patch is an
+	// object with the fields 'element', 'index', and 'key'.
 	// +optional
 	FromFieldPath *string `json:"fromFieldPath,omitempty"`
 
+	// FromResourceName is the name of the resource template whose composed
+	// resource is to be used as input. The named template must be declared
+	// before the template of this patch. Required when type is
+	// FromComposedFieldPath.
+	// +optional
+	FromResourceName *string `json:"fromResourceName,omitempty"`
+
+	// Combine is the patch configuration for a CombineFromComposite or
+	// CombineToComposite patch. Required when type is CombineFromComposite or
+	// CombineToComposite.
+	// +optional
+	Combine *Combine `json:"combine,omitempty"`
+
 	// ToFieldPath is the path of the field on the resource whose value will
 	// be changed with the result of transforms. Leave empty if you'd like to
-	// propagate to the same path as fromFieldPath.
+	// propagate to the same path as fromFieldPath. Required when type is
+	// CombineFromComposite or CombineToComposite.
 	// +optional
 	ToFieldPath *string `json:"toFieldPath,omitempty"`
 
@@ -202,6 +418,96 @@ type PatchPolicy struct {
 	// +kubebuilder:validation:Enum=Optional;Required
 	// +optional
 	FromFieldPath *FromFieldPathPolicy `json:"fromFieldPath,omitempty"`
+
+	// MergeOptions specifies how to merge the patched value into any value
+	// that already exists at the toFieldPath. The existing value is replaced
+	// if no merge options are specified.
+	// +optional
+	MergeOptions *MergeOptions `json:"mergeOptions,omitempty"`
+}
+
+// MergeOptions specify how a patched value is merged into any existing value.
+// Objects are always deep merged when merge options are specified; keys that
+// exist only in the existing object are kept.
+type MergeOptions struct {
+	// KeepMapValues specifies that values that already exist in an object
+	// take precedence over patched values with the same key.
+	// +optional
+	KeepMapValues *bool `json:"keepMapValues,omitempty"`
+
+	// AppendSlice specifies that patched array elements are appended to any
+	// existing array, rather than replacing it.
+	// +optional
+	AppendSlice *bool `json:"appendSlice,omitempty"`
+
+	// Deduplicate specifies that patched array elements that are equal to an
+	// element of the existing array are not appended. Only applies when
+	// appendSlice is true.
+	// +optional
+	Deduplicate *bool `json:"deduplicate,omitempty"`
+}
+
+// A CombineVariable defines the source of a value that is combined with
+// others to form and patch an output value. Currently, this only supports
+// retrieving values from a field path.
+type CombineVariable struct {
+	// FromResourceName is the name of the resource template whose composed
+	// resource this variable is read from. Only supported by
+	// CombineToComposite patches, which otherwise read each variable from the
+	// composed resource of the patch's own template.
+	// +optional
+	FromResourceName *string `json:"fromResourceName,omitempty"`
+
+	// FromFieldPath is the path of the field on the source whose value is
+	// to be used as input.
+	FromFieldPath string `json:"fromFieldPath"`
+
+	// Policy specifies how to handle this variable's fromFieldPath not
+	// existing. 'Optional' means the patch will be a no-op if the specified
+	// fromFieldPath does not exist, while 'Required' means the patch will fail.
+	// Defaults to the fromFieldPath policy of the patch. If the patch does not
+	// specify one, variables of a CombineToComposite patch are 'Required' while
+	// those of a CombineFromComposite patch are 'Optional'.
+	// +kubebuilder:validation:Enum=Optional;Required
+	// +optional
+	Policy *FromFieldPathPolicy `json:"policy,omitempty"`
+}
+
+// A CombineStrategy determines what strategy will be applied to combine
+// variables.
+type CombineStrategy string
+
+// CombineStrategy strategy definitions.
+const (
+	CombineStrategyString CombineStrategy = "string"
+)
+
+// A Combine configures a patch that combines more than one input field into a
+// single output field.
+type Combine struct {
+	// Variables are the list of variables whose values will be retrieved and
+	// combined. Variables are passed to the combine strategy in order.
+	// +kubebuilder:validation:MinItems=1
+	Variables []CombineVariable `json:"variables"`
+
+	// Strategy defines the strategy to use to combine the input variable
+	// values. Currently only string is supported.
+	// +kubebuilder:validation:Enum=string
+	Strategy CombineStrategy `json:"strategy"`
+
+	// String declares that input variables should be combined into a single
+	// string, using the relevant settings for formatting purposes.
+	// +optional
+	String *StringCombine `json:"string,omitempty"`
+}
+
+// A StringCombine combines multiple input values into a single string.
+type StringCombine struct {
+	// Format the input using a Go format string. Variables are supplied as
+	// positional arguments in the order they are declared, so explicit
+	// argument indexes such as %[2]s may be used. See
+	// https://golang.org/pkg/fmt/ for details.
+	Format string `json:"fmt"`
 }
 
 // TransformType is type of the transform function to be chosen.
@@ -213,6 +519,7 @@ const (
 	TransformTypeMath    TransformType = "math"
 	TransformTypeString  TransformType = "string"
 	TransformTypeConvert TransformType = "convert"
+	TransformTypeMatch   TransformType = "match"
 )
 
 // Transform is a unit of process whose input is transformed into an output with
@@ -220,7 +527,7 @@ const (
 type Transform struct {
 
 	// Type of the transform to be run.
-	// +kubebuilder:validation:Enum=map;math;string;convert
+	// +kubebuilder:validation:Enum=map;math;string;convert;match
 	Type TransformType `json:"type"`
 
 	// Math is used to transform the input via mathematical operations such as
@@ -228,7 +535,10 @@ type Transform struct {
 	// +optional
 	Math *MathTransform `json:"math,omitempty"`
 
-	// Map uses the input as a key in the given map and returns the value.
+	// Map uses the input as a key in the given map and returns the value. The
+	// keys fallbackValue and fallbackTo are reserved; they configure what is
+	// returned if the input is not a key of the map, like those of a match
+	// transform.
 	// +optional
 	Map *MapTransform `json:"map,omitempty"`
 
@@ -240,30 +550,220 @@ type Transform struct {
 	// Convert is used to cast the input into the given output type.
 	// +optional
 	Convert *ConvertTransform `json:"convert,omitempty"`
+
+	// Match returns the result of the first of the given patterns to match the
+	// input, optionally falling back to a default value.
+	// +optional
+	Match *MatchTransform `json:"match,omitempty"`
 }
 
+// MathTransformType is the type of operation a MathTransform performs.
+type MathTransformType string
+
+// Accepted MathTransformTypes.
+const (
+	MathTransformTypeMultiply MathTransformType = "Multiply"
+	MathTransformTypeAdd      MathTransformType = "Add"
+	MathTransformTypeSubtract MathTransformType = "Subtract"
+	MathTransformTypeDivide   MathTransformType = "Divide"
+	MathTransformTypeModulo   MathTransformType = "Modulo"
+	MathTransformTypeClampMin MathTransformType = "ClampMin"
+	MathTransformTypeClampMax MathTransformType = "ClampMax"
+)
+
+// MathRoundingMode specifies how a MathTransform rounds a fractional result to
+// an integer.
+type MathRoundingMode string
+
+// Accepted MathRoundingModes.
+const (
+	MathRoundingModeTruncate MathRoundingMode = "Truncate"
+	MathRoundingModeFloor    MathRoundingMode = "Floor"
+	MathRoundingModeCeil     MathRoundingMode = "Ceil"
+	MathRoundingModeRound    MathRoundingMode = "Round"
+)
+
 // MathTransform conducts mathematical operations on the input with the given
 // configuration in its properties.
 type MathTransform struct {
+	// Type of the mathematical operation to perform. Each type requires the
+	// field of the same name to be set; e.g. the Add type requires add.
+	// Defaults to Multiply.
+	// +optional
+	// +kubebuilder:validation:Enum=Multiply;Add;Subtract;Divide;Modulo;ClampMin;ClampMax
+	Type MathTransformType `json:"type,omitempty"`
+
 	// Multiply the value.
 	// +optional
 	Multiply *int64 `json:"multiply,omitempty"`
+
+	// Add to the value.
+	// +optional
+	Add *int64 `json:"add,omitempty"`
+
+	// Subtract from the value.
+	// +optional
+	Subtract *int64 `json:"subtract,omitempty"`
+
+	// Divide the value. Must not be zero.
+	// +optional
+	Divide *int64 `json:"divide,omitempty"`
+
+	// Modulo returns the remainder of dividing the value. Must not be zero.
+	// +optional
+	Modulo *int64 `json:"modulo,omitempty"`
+
+	// ClampMin returns this value if the input is less than it.
+	// +optional
+	ClampMin *int64 `json:"clampMin,omitempty"`
+
+	// ClampMax returns this value if the input is greater than it.
+	// +optional
+	ClampMax *int64 `json:"clampMax,omitempty"`
+
+	// Round a fractional result to an integer using the supplied mode. Integer
+	// input always produces integer output; an integer division that has a
+	// remainder is truncated unless a different mode is supplied. Floating
+	// point input produces floating point output unless a mode is supplied.
+	// +optional
+	// +kubebuilder:validation:Enum=Truncate;Floor;Ceil;Round
+	Round *MathRoundingMode `json:"round,omitempty"`
 }
 
 // MapTransform returns a value for the input from the given map.
 type MapTransform struct {
 	// TODO(negz): Are Pairs really optional if a MapTransform was specified?
 
-	// Pairs is the map that will be used for transform.
+	// Pairs is the map that will be used for transform. Values may be any
+	// valid JSON, e.g. strings, numbers, arrays, or objects.
+	// +optional
+	Pairs map[string]extv1.JSON `json:",inline"`
+}
+
+// MatchTransformPatternType is the type of a MatchTransformPattern.
+type MatchTransformPatternType string
+
+// Accepted MatchTransformPatternTypes.
+const (
+	MatchTransformPatternTypeLiteral MatchTransformPatternType = "Literal"
+	MatchTransformPatternTypeRegexp  MatchTransformPatternType = "Regexp"
+)
+
+// MatchFallbackTo specifies what a MatchTransform or MapTransform returns when
+// it has no result for its input.
+type MatchFallbackTo string
+
+// Accepted MatchFallbackTos.
+const (
+	MatchFallbackToValue MatchFallbackTo = "Value"
+	MatchFallbackToInput MatchFallbackTo = "Input"
+)
+
+// A MatchTransform returns the result of the first of its patterns to match
+// the input.
+type MatchTransform struct {
+	// Patterns are evaluated in order. The result of the first pattern that
+	// matches the input is returned.
+	// +optional
+	Patterns []MatchTransformPattern `json:"patterns,omitempty"`
+
+	// FallbackValue is returned if no pattern matches the input and fallbackTo
+	// is Value. It may be any valid JSON. The transform returns an error if no
+	// pattern matches and no fallback value is supplied.
+	// +optional
+	FallbackValue *extv1.JSON `json:"fallbackValue,omitempty"`
+
+	// FallbackTo determines what is returned if no pattern matches the input;
+	// either the fallbackValue or the input itself. Defaults to Value.
+	// +optional
+	// +kubebuilder:validation:Enum=Value;Input
+	FallbackTo MatchFallbackTo `json:"fallbackTo,omitempty"`
+}
+
+// A MatchTransformPattern is a pattern that a MatchTransform may match.
+type MatchTransformPattern struct {
+	// Type of the pattern. Literal patterns match input that is exactly equal
+	// to the literal. Regexp patterns match input that matches the regular
+	// expression. Defaults to Literal.
+	// +optional
+	// +kubebuilder:validation:Enum=Literal;Regexp
+	Type MatchTransformPatternType `json:"type,omitempty"`
+
+	// Literal exactly matches the input string. Required by type Literal.
+	// +optional
+	Literal *string `json:"literal,omitempty"`
+
+	// Regexp matches the input string. Required by type Regexp. See
+	// https://pkg.go.dev/regexp/ for details.
 	// +optional
-	Pairs map[string]string `json:",inline"`
+	Regexp *string `json:"regexp,omitempty"`
+
+	// Result is returned if this pattern matches. It may be any valid JSON.
+	Result extv1.JSON `json:"result"`
 }
 
+// StringTransformType is the type of operation a StringTransform performs.
+type StringTransformType string
+
+// Accepted StringTransformTypes.
+const (
+	StringTransformTypeFormat     StringTransformType = "Format"
+	StringTransformTypeConvert    StringTransformType = "Convert"
+	StringTransformTypeTrimPrefix StringTransformType = "TrimPrefix"
+	StringTransformTypeTrimSuffix StringTransformType = "TrimSuffix"
+	StringTransformTypeRegexp     StringTransformType = "Regexp"
+)
+
+// StringConversionType is the type of conversion a StringTransform of type
+// Convert performs.
+type StringConversionType string
+
+// Accepted StringConversionTypes.
+const (
+	StringConversionTypeToUpper    StringConversionType = "ToUpper"
+	StringConversionTypeToLower    StringConversionType = "ToLower"
+	StringConversionTypeToBase64   StringConversionType = "ToBase64"
+	StringConversionTypeFromBase64 StringConversionType = "FromBase64"
+	StringConversionTypeToSha256   StringConversionType = "ToSha256"
+)
+
 // A StringTransform returns a string given the supplied input.
 type StringTransform struct {
+	// Type of the string transform to perform. Defaults to Format.
+	// +optional
+	// +kubebuilder:validation:Enum=Format;Convert;TrimPrefix;TrimSuffix;Regexp
+	Type StringTransformType `json:"type,omitempty"`
+
 	// Format the input using a Go format string. See
-	// https://golang.org/pkg/fmt/ for details.
-	Format string `json:"fmt"`
+	// https://golang.org/pkg/fmt/ for details. Required by type Format.
+	// +optional
+	Format string `json:"fmt,omitempty"`
+
+	// Convert the input string. Required by type Convert.
+	// +optional
+	// +kubebuilder:validation:Enum=ToUpper;ToLower;ToBase64;FromBase64;ToSha256
+	Convert *StringConversionType `json:"convert,omitempty"`
+
+	// Trim the supplied prefix or suffix from the input string. Required by
+	// types TrimPrefix and TrimSuffix.
+	// +optional
+	Trim *string `json:"trim,omitempty"`
+
+	// Regexp extracts a match from the input string. Required by type Regexp.
+	// +optional
+	Regexp *StringTransformRegexp `json:"regexp,omitempty"`
+}
+
+// A StringTransformRegexp extracts a match from the input using a regular
+// expression.
+type StringTransformRegexp struct {
+	// Match string. May optionally include submatches, aka capture groups.
+	// See https://pkg.go.dev/regexp/ for details.
+	Match string `json:"match"`
+
+	// Group number to match. 0 (the default) matches the entire expression.
+	// +optional
+	Group *int `json:"group,omitempty"`
 }
 
 // A ConvertTransform converts the input into a new object whose type is supplied.
@@ -282,6 +782,7 @@ const (
 	ConnectionDetailTypeFromConnectionSecretKey ConnectionDetailType = "FromConnectionSecretKey" // Default
 	ConnectionDetailTypeFromFieldPath           ConnectionDetailType = "FromFieldPath"
 	ConnectionDetailTypeFromValue               ConnectionDetailType = "FromValue"
+	ConnectionDetailTypeCombine                 ConnectionDetailType = "Combine"
 )
 
 // ConnectionDetail includes the information about the propagation of the connection
@@ -298,7 +799,7 @@ type ConnectionDetail struct {
 	// ConnectionDetail object. If the type is omitted Crossplane will attempt
 	// to infer it based on which other fields were specified.
 	// +optional
-	// +kubebuilder:validation:Enum=FromConnectionSecretKey;FromFieldPath;FromValue
+	// +kubebuilder:validation:Enum=FromConnectionSecretKey;FromFieldPath;FromValue;Combine
 	Type ConnectionDetailType `json:"type,omitempty"`
 
 	// FromConnectionSecretKey is the key that will be used to fetch the value
@@ -319,6 +820,55 @@ type ConnectionDetail struct {
 	// FromConnectionSecretKey when set.
 	// +optional
 	Value *string `json:"value,omitempty"`
+
+	// Combine configures a connection detail that combines several values
+	// into one, for example to build a connection URL. Name must be
+	// specified. Required when type is Combine.
+	// +optional
+	Combine *ConnectionDetailCombine `json:"combine,omitempty"`
+
+	// Transforms are the list of functions that are used to transform the
+	// value of the connection detail before it is propagated. Transforms are
+	// applied in order.
+	// +optional
+	Transforms []Transform `json:"transforms,omitempty"`
+}
+
+// A ConnectionDetailCombine combines several values into a single connection
+// detail.
+type ConnectionDetailCombine struct {
+	// Variables are the list of variables whose values will be retrieved and
+	// combined. Variables are passed to the combine strategy in order.
+	// +kubebuilder:validation:MinItems=1
+	Variables []ConnectionDetailVariable `json:"variables"`
+
+	// Strategy defines the strategy to use to combine the input variable
+	// values. Currently only string is supported.
+	// +kubebuilder:validation:Enum=string
+	Strategy CombineStrategy `json:"strategy"`
+
+	// String declares that input variables should be combined into a single
+	// string, using the relevant settings for formatting purposes.
+	// +optional
+	String *StringCombine `json:"string,omitempty"`
+}
+
+// A ConnectionDetailVariable defines the source of a value that is combined
+// with others to form a connection detail. Exactly one source must be set.
+type ConnectionDetailVariable struct {
+	// FromConnectionSecretKey is the key of the composed resource's connection
+	// secret whose value is to be used as input.
+	// +optional
+	FromConnectionSecretKey *string `json:"fromConnectionSecretKey,omitempty"`
+
+	// FromFieldPath is the path of the field on the composed resource whose
+	// value is to be used as input.
+	// +optional
+	FromFieldPath *string `json:"fromFieldPath,omitempty"`
+
+	// Value is a fixed value to be used as input.
+	// +optional
+	Value *string `json:"value,omitempty"`
 }
 
 // CompositionStatus shows the observed state of the composition.
@@ -18,6 +18,7 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/alecthomas/kong"
 	"github.com/spf13/afero"
@@ -46,10 +47,13 @@ func (v versionFlag) BeforeApply(app *kong.Kong) error { // nolint:unparam
 var cli struct {
 	Version versionFlag `short:"v" name:"version" help:"Print version and quit."`
 
-	Build   buildCmd   `cmd:"" help:"Build Crossplane packages."`
-	Install installCmd `cmd:"" help:"Install Crossplane packages."`
-	Update  updateCmd  `cmd:"" help:"Update Crossplane packages."`
-	Push    pushCmd    `cmd:"" help:"Push Crossplane packages."`
+	Build    buildCmd    `cmd:"" help:"Build Crossplane packages."`
+	Install  installCmd  `cmd:"" help:"Install Crossplane packages."`
+	Update   updateCmd   `cmd:"" help:"Update Crossplane packages."`
+	Push     pushCmd     `cmd:"" help:"Push Crossplane packages."`
+	Render   renderCmd   `cmd:"" help:"Render a composite resource without a Crossplane control plane."`
+	Test     testCmd     `cmd:"" help:"Test the Compositions in a Crossplane package."`
+	Validate validateCmd `cmd:"" help:"Validate Compositions against the schemas of the resources they compose."`
 }
 
 func main() {
@@ -59,12 +63,24 @@ func main() {
 	pushChild := &pushChild{
 		fs: afero.NewOsFs(),
 	}
+	renderChild := &renderChild{
+		fs:  afero.NewOsFs(),
+		out: os.Stdout,
+	}
+	testChild := &testChild{
+		fs:  afero.NewOsFs(),
+		out: os.Stdout,
+	}
+	validateChild := &validateChild{
+		fs:  afero.NewOsFs(),
+		out: os.Stdout,
+	}
 	ctx := kong.Parse(&cli,
 		kong.Name("kubectl crossplane"),
 		kong.Description("A command line tool for interacting with Crossplane."),
 		// Binding a variable to kong context makes it available to all commands
 		// at runtime.
-		kong.Bind(buildChild, pushChild),
+		kong.Bind(buildChild, pushChild, renderChild, testChild, validateChild),
 		kong.UsageOnError())
 	err := ctx.Run()
 	ctx.FatalIfErrorf(err)
@@ -0,0 +1,95 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	kunstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	syaml "sigs.k8s.io/yaml"
+)
+
+const (
+	errFmtOpenFile   = "cannot open %s"
+	errFmtReadFile   = "cannot read %s"
+	errFmtParseFile  = "cannot parse %s"
+	errFmtNotOneObj  = "%s must contain exactly one object, not %d"
+	errMarshalObject = "cannot marshal object"
+	errWriteObject   = "cannot write object"
+)
+
+// readObjects reads all of the YAML (or JSON) documents in the supplied file.
+// Empty documents are ignored.
+func readObjects(fs afero.Fs, path string) ([]*kunstructured.Unstructured, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, errFmtOpenFile, path)
+	}
+	defer func() { _ = f.Close() }()
+
+	objs := make([]*kunstructured.Unstructured, 0)
+	r := yaml.NewYAMLReader(bufio.NewReader(f))
+	for {
+		doc, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtReadFile, path)
+		}
+		u := &kunstructured.Unstructured{}
+		if err := syaml.Unmarshal(doc, &u.Object); err != nil {
+			return nil, errors.Wrapf(err, errFmtParseFile, path)
+		}
+		if len(u.Object) == 0 {
+			continue
+		}
+		objs = append(objs, u)
+	}
+	return objs, nil
+}
+
+// readObject reads the single YAML (or JSON) document in the supplied file.
+func readObject(fs afero.Fs, path string) (*kunstructured.Unstructured, error) {
+	objs, err := readObjects(fs, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(objs) != 1 {
+		return nil, errors.Errorf(errFmtNotOneObj, path, len(objs))
+	}
+	return objs[0], nil
+}
+
+// writeObjects writes the supplied objects to the supplied writer as a stream
+// of YAML documents.
+func writeObjects(w io.Writer, objs ...interface{}) error {
+	for _, o := range objs {
+		b, err := syaml.Marshal(o)
+		if err != nil {
+			return errors.Wrap(err, errMarshalObject)
+		}
+		if _, err := w.Write(append([]byte("---\n"), b...)); err != nil {
+			return errors.Wrap(err, errWriteObject)
+		}
+	}
+	return nil
+}
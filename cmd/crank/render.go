@@ -0,0 +1,116 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ucomposite "github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/render"
+)
+
+const (
+	errFmtConvertComposition = "cannot convert %s to a Composition"
+	errRender                = "cannot render composite resource"
+)
+
+// renderCmd renders a composite resource.
+type renderCmd struct {
+	CompositeResource string   `arg:"" type:"existingfile" help:"Path to a YAML file containing the composite resource to render."`
+	Composition       string   `arg:"" type:"existingfile" help:"Path to a YAML file containing the Composition to render the composite resource with."`
+	Observed          []string `short:"o" type:"existingfile" help:"Paths to YAML files containing observed composed resources, connection Secrets, and EnvironmentConfigs."`
+}
+
+// Run runs the render cmd.
+func (c *renderCmd) Run(child *renderChild) error {
+	xr, comp, observed, err := readRenderInputs(child.fs, c.CompositeResource, c.Composition, c.Observed)
+	if err != nil {
+		return err
+	}
+
+	out, err := render.Render(context.Background(), xr, comp, observed...)
+	if err != nil {
+		return errors.Wrap(err, errRender)
+	}
+
+	return writeRendered(child.out, out)
+}
+
+type renderChild struct {
+	fs  afero.Fs
+	out io.Writer
+}
+
+// readRenderInputs reads the composite resource, Composition, and any observed
+// resources needed to render a composite resource from the supplied files.
+func readRenderInputs(fs afero.Fs, xrPath, compPath string, observedPaths []string) (*ucomposite.Unstructured, *v1.Composition, []client.Object, error) {
+	u, err := readObject(fs, xrPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	xr := &ucomposite.Unstructured{Unstructured: *u}
+
+	u, err = readObject(fs, compPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	comp := &v1.Composition{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, comp); err != nil {
+		return nil, nil, nil, errors.Wrapf(err, errFmtConvertComposition, compPath)
+	}
+
+	observed, err := readObserved(fs, observedPaths)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return xr, comp, observed, nil
+}
+
+// readObserved reads the observed resources in the supplied files.
+func readObserved(fs afero.Fs, paths []string) ([]client.Object, error) {
+	observed := make([]client.Object, 0)
+	for _, p := range paths {
+		objs, err := readObjects(fs, p)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range objs {
+			observed = append(observed, o)
+		}
+	}
+	return observed, nil
+}
+
+// writeRendered writes the rendered composed resources, followed by the
+// rendered composite resource.
+func writeRendered(w io.Writer, out *render.Output) error {
+	objs := make([]interface{}, 0, len(out.Composed)+1)
+	for _, cd := range out.Composed {
+		objs = append(objs, cd.Object)
+	}
+	objs = append(objs, out.Composite.Object)
+	return writeObjects(w, objs...)
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+const (
+	renderXR = `
+apiVersion: example.org/v1
+kind: XCool
+metadata:
+  name: cool-xr
+spec:
+  size: large
+`
+	renderComposition = `
+apiVersion: apiextensions.crossplane.io/v1
+kind: Composition
+metadata:
+  name: cool
+spec:
+  compositeTypeRef:
+    apiVersion: example.org/v1
+    kind: XCool
+  resources:
+  - name: bucket
+    base:
+      apiVersion: example.org/v1
+      kind: Bucket
+    patches:
+    - fromFieldPath: spec.size
+      toFieldPath: spec.forProvider.size
+    readinessChecks:
+    - type: None
+`
+)
+
+func TestRender(t *testing.T) {
+	type args struct {
+		files map[string]string
+		cmd   renderCmd
+	}
+	type want struct {
+		docs int
+		err  error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoCompositeResource": {
+			reason: "We should return an error if the composite resource file does not exist.",
+			args: args{
+				files: map[string]string{},
+				cmd:   renderCmd{CompositeResource: "/xr.yaml", Composition: "/comp.yaml"},
+			},
+			want: want{
+				err: errors.Wrapf(&os.PathError{Op: "open", Path: "/xr.yaml", Err: os.ErrNotExist}, errFmtOpenFile, "/xr.yaml"),
+			},
+		},
+		"TooManyCompositeResources": {
+			reason: "We should return an error if the composite resource file contains more than one object.",
+			args: args{
+				files: map[string]string{
+					"/xr.yaml":   renderXR + "---" + renderXR,
+					"/comp.yaml": renderComposition,
+				},
+				cmd: renderCmd{CompositeResource: "/xr.yaml", Composition: "/comp.yaml"},
+			},
+			want: want{
+				err: errors.Errorf(errFmtNotOneObj, "/xr.yaml", 2),
+			},
+		},
+		"Success": {
+			reason: "We should write the rendered composed resource followed by the composite resource.",
+			args: args{
+				files: map[string]string{
+					"/xr.yaml":   renderXR,
+					"/comp.yaml": renderComposition,
+				},
+				cmd: renderCmd{CompositeResource: "/xr.yaml", Composition: "/comp.yaml"},
+			},
+			want: want{
+				docs: 2,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			for path, content := range tc.args.files {
+				if err := afero.WriteFile(fs, path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			out := &bytes.Buffer{}
+			err := tc.args.cmd.Run(&renderChild{fs: fs, out: out})
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRun(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.docs, bytes.Count(out.Bytes(), []byte("---\n"))); diff != "" {
+				t.Errorf("\n%s\nRun(...): -want documents, +got documents:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
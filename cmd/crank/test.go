@@ -0,0 +1,352 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+
+	ucomposite "github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/connection"
+	"github.com/crossplane/crossplane/internal/render"
+)
+
+// Files that make up a test case.
+const (
+	// The composite resource to render. Required.
+	testFileXR = "xr.yaml"
+
+	// Observed composed resources, connection secrets, and EnvironmentConfigs
+	// to render the composite resource with. Optional.
+	testFileObserved = "observed.yaml"
+
+	// The expected composed resources. Not checked if omitted.
+	testFileComposed = "composed.yaml"
+
+	// The expected connection details, as a map of keys to string values. Only
+	// the connection details that match the connectionSecretKeys of the
+	// package's CompositeResourceDefinition for the composite resource are
+	// included. All connection details are included if the package does not
+	// contain a CompositeResourceDefinition for the composite resource. Not
+	// checked if omitted.
+	testFileConnectionDetails = "connection-details.yaml"
+)
+
+const (
+	errFmtWalkPackage          = "cannot walk package directory %s"
+	errFmtReadTests            = "cannot read test directory %s"
+	errFmtNoComposition        = "no Composition found for composite resource %s %s"
+	errFmtNamedComposition     = "Composition %q not found"
+	errFmtAmbiguousComposition = "%d Compositions found for composite resource %s %s - set spec.compositionRef.name"
+	errFmtWriteGolden          = "cannot write %s"
+	errFmtDeleteGolden         = "cannot delete %s"
+	errFmtTestsFailed          = "%d of %d test cases failed"
+)
+
+// testCmd tests the Compositions in a package.
+type testCmd struct {
+	PackageRoot string `short:"f" help:"Path to package directory." default:"."`
+	Tests       string `help:"Path, specified relative to --package-root, to the directory containing test cases." default:"tests"`
+	Update      bool   `help:"Update the expected composed resources and connection details of each test case, rather than checking them."`
+}
+
+// Run runs the test cmd.
+func (c *testCmd) Run(child *testChild) error {
+	root, err := filepath.Abs(c.PackageRoot)
+	if err != nil {
+		return err
+	}
+	tests := filepath.Join(root, c.Tests)
+
+	p, err := readPackage(child.fs, root, tests)
+	if err != nil {
+		return err
+	}
+
+	entries, err := afero.ReadDir(child.fs, tests)
+	if err != nil {
+		return errors.Wrapf(err, errFmtReadTests, tests)
+	}
+
+	total, failed := 0, 0
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		total++
+
+		diff, err := runTestCase(child.fs, filepath.Join(tests, e.Name()), p, c.Update)
+		switch {
+		case err != nil:
+			failed++
+			fmt.Fprintf(child.out, "ERROR %s: %s\n", e.Name(), err)
+		case diff != "":
+			failed++
+			fmt.Fprintf(child.out, "FAIL %s:\n%s\n", e.Name(), diff)
+		case c.Update:
+			fmt.Fprintf(child.out, "UPDATED %s\n", e.Name())
+		default:
+			fmt.Fprintf(child.out, "PASS %s\n", e.Name())
+		}
+	}
+
+	if failed > 0 {
+		return errors.Errorf(errFmtTestsFailed, failed, total)
+	}
+	return nil
+}
+
+type testChild struct {
+	fs  afero.Fs
+	out io.Writer
+}
+
+// A testPackage contains the Compositions and CompositeResourceDefinitions of
+// the package under test.
+type testPackage struct {
+	comps []*v1.Composition
+	xrds  []v1.CompositeResourceDefinition
+}
+
+// readPackage reads all Compositions and CompositeResourceDefinitions in the
+// package at the supplied root, excluding the supplied tests directory.
+func readPackage(fs afero.Fs, root, tests string) (*testPackage, error) {
+	p := &testPackage{
+		comps: make([]*v1.Composition, 0),
+		xrds:  make([]v1.CompositeResourceDefinition, 0),
+	}
+	err := afero.Walk(fs, root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path == tests {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := filepath.Ext(path); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		objs, err := readObjects(fs, path)
+		if err != nil {
+			return err
+		}
+		for _, o := range objs {
+			switch o.GroupVersionKind() {
+			case v1.CompositionGroupVersionKind:
+				comp := &v1.Composition{}
+				if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.Object, comp); err != nil {
+					return errors.Wrapf(err, errFmtConvertComposition, path)
+				}
+				p.comps = append(p.comps, comp)
+			case v1.CompositeResourceDefinitionGroupVersionKind:
+				xrd := v1.CompositeResourceDefinition{}
+				if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.Object, &xrd); err != nil {
+					return errors.Wrapf(err, errFmtConvertXRD, path)
+				}
+				p.xrds = append(p.xrds, xrd)
+			}
+		}
+		return nil
+	})
+	return p, errors.Wrapf(err, errFmtWalkPackage, root)
+}
+
+// connectionSecretKeys returns the connectionSecretKeys of whichever of the
+// supplied CompositeResourceDefinitions defines the kind of the supplied
+// composite resource. It returns false if none of them defines its kind.
+func connectionSecretKeys(xrds []v1.CompositeResourceDefinition, xr *ucomposite.Unstructured) ([]string, bool) {
+	gvk := xr.GroupVersionKind()
+	for _, xrd := range xrds {
+		if xrd.Spec.Group == gvk.Group && xrd.Spec.Names.Kind == gvk.Kind {
+			return xrd.GetConnectionSecretKeys(), true
+		}
+	}
+	return nil, false
+}
+
+// selectComposition selects the Composition a test case's composite resource
+// should be rendered with. The Composition named by its compositionRef is used
+// if it has one, otherwise the only Composition of its type is used.
+func selectComposition(comps []*v1.Composition, xr *ucomposite.Unstructured) (*v1.Composition, error) {
+	if ref := xr.GetCompositionReference(); ref != nil && ref.Name != "" {
+		for _, comp := range comps {
+			if comp.GetName() == ref.Name {
+				return comp, nil
+			}
+		}
+		return nil, errors.Errorf(errFmtNamedComposition, ref.Name)
+	}
+
+	apiVersion, kind := xr.GroupVersionKind().ToAPIVersionAndKind()
+	matching := make([]*v1.Composition, 0)
+	for _, comp := range comps {
+		if comp.Spec.CompositeTypeRef.APIVersion == apiVersion && comp.Spec.CompositeTypeRef.Kind == kind {
+			matching = append(matching, comp)
+		}
+	}
+	switch len(matching) {
+	case 0:
+		return nil, errors.Errorf(errFmtNoComposition, apiVersion, kind)
+	case 1:
+		return matching[0], nil
+	}
+	return nil, errors.Errorf(errFmtAmbiguousComposition, len(matching), apiVersion, kind)
+}
+
+// runTestCase renders the test case in the supplied directory and returns a
+// diff between the expected and rendered results. The expected results are
+// replaced with the rendered results if update is true. Rendered connection
+// details are filtered by the connectionSecretKeys of the package's
+// CompositeResourceDefinition, if it has one, as they would be in-cluster.
+func runTestCase(fs afero.Fs, dir string, p *testPackage, update bool) (string, error) {
+	u, err := readObject(fs, filepath.Join(dir, testFileXR))
+	if err != nil {
+		return "", err
+	}
+	xr := &ucomposite.Unstructured{Unstructured: *u}
+
+	comp, err := selectComposition(p.comps, xr)
+	if err != nil {
+		return "", err
+	}
+
+	paths := make([]string, 0)
+	if ok, _ := afero.Exists(fs, filepath.Join(dir, testFileObserved)); ok {
+		paths = append(paths, filepath.Join(dir, testFileObserved))
+	}
+	obs, err := readObserved(fs, paths)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := render.Render(context.Background(), xr, comp, obs...)
+	if err != nil {
+		return "", errors.Wrap(err, errRender)
+	}
+
+	composed := make([]interface{}, len(out.Composed))
+	for i, cd := range out.Composed {
+		composed[i] = cd.Object
+	}
+	cd := out.ConnectionDetails
+	if keys, ok := connectionSecretKeys(p.xrds, xr); ok {
+		cd = connection.FilterKeys(keys, cd)
+	}
+	conn := make(map[string]string, len(cd))
+	for k, v := range cd {
+		conn[k] = string(v)
+	}
+
+	if update {
+		return "", writeGolden(fs, dir, composed, conn)
+	}
+	return diffGolden(fs, dir, composed, conn)
+}
+
+// writeGolden replaces the expected results of the test case in the supplied
+// directory. Any expected connection details are deleted if no connection
+// details were rendered, so that they don't become stale.
+func writeGolden(fs afero.Fs, dir string, composed []interface{}, conn map[string]string) error {
+	b := &bytes.Buffer{}
+	if err := writeObjects(b, composed...); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, testFileComposed)
+	if err := afero.WriteFile(fs, path, b.Bytes(), 0644); err != nil {
+		return errors.Wrapf(err, errFmtWriteGolden, path)
+	}
+
+	path = filepath.Join(dir, testFileConnectionDetails)
+	if len(conn) == 0 {
+		if err := fs.Remove(path); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, errFmtDeleteGolden, path)
+		}
+		return nil
+	}
+	cb, err := yaml.Marshal(conn)
+	if err != nil {
+		return errors.Wrap(err, errMarshalObject)
+	}
+	return errors.Wrapf(afero.WriteFile(fs, path, cb, 0644), errFmtWriteGolden, path)
+}
+
+func diffGolden(fs afero.Fs, dir string, composed []interface{}, conn map[string]string) (string, error) {
+	diff := ""
+
+	path := filepath.Join(dir, testFileComposed)
+	if ok, _ := afero.Exists(fs, path); ok {
+		objs, err := readObjects(fs, path)
+		if err != nil {
+			return "", err
+		}
+		want := make([]interface{}, len(objs))
+		for i, o := range objs {
+			want[i] = o.Object
+		}
+		// Normalize the rendered resources by round tripping them through
+		// YAML, so that they are compared with the same types as the
+		// expected resources. Numbers are float64 either way, for example.
+		got, err := normalize(composed)
+		if err != nil {
+			return "", err
+		}
+		if d := cmp.Diff(want, got, cmpopts.EquateEmpty()); d != "" {
+			diff += fmt.Sprintf("%s: -want, +got:\n%s", testFileComposed, d)
+		}
+	}
+
+	path = filepath.Join(dir, testFileConnectionDetails)
+	if ok, _ := afero.Exists(fs, path); ok {
+		b, err := afero.ReadFile(fs, path)
+		if err != nil {
+			return "", errors.Wrapf(err, errFmtReadFile, path)
+		}
+		want := map[string]string{}
+		if err := yaml.Unmarshal(b, &want); err != nil {
+			return "", errors.Wrapf(err, errFmtParseFile, path)
+		}
+		if d := cmp.Diff(want, conn, cmpopts.EquateEmpty()); d != "" {
+			diff += fmt.Sprintf("%s: -want, +got:\n%s", testFileConnectionDetails, d)
+		}
+	}
+
+	return diff, nil
+}
+
+func normalize(objs []interface{}) ([]interface{}, error) {
+	b, err := yaml.Marshal(objs)
+	if err != nil {
+		return nil, errors.Wrap(err, errMarshalObject)
+	}
+	out := make([]interface{}, 0)
+	return out, errors.Wrap(yaml.Unmarshal(b, &out), errMarshalObject)
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestTest(t *testing.T) {
+	composed := `---
+apiVersion: example.org/v1
+kind: Bucket
+metadata:
+  annotations:
+    crossplane.io/composition-resource-name: bucket
+  generateName: cool-xr-
+  labels:
+    crossplane.io/claim-name: ""
+    crossplane.io/claim-namespace: ""
+    crossplane.io/composite: cool-xr
+  name: cool-xr-b1a22
+  ownerReferences:
+  - apiVersion: example.org/v1
+    controller: true
+    kind: XCool
+    name: cool-xr
+    uid: ""
+spec:
+  forProvider:
+    size: large
+`
+
+	// A Composition that exposes two connection details, and an XRD that
+	// only allows one of them to be published.
+	connComposition := strings.Replace(renderComposition, `    readinessChecks:`, `    connectionDetails:
+    - name: username
+      value: admin
+    - name: password
+      value: hunter2
+    readinessChecks:`, 1)
+	connXRD := `
+apiVersion: apiextensions.crossplane.io/v1
+kind: CompositeResourceDefinition
+metadata:
+  name: xcools.example.org
+spec:
+  group: example.org
+  names:
+    kind: XCool
+    plural: xcools
+  connectionSecretKeys:
+  - password
+  versions:
+  - name: v1
+    served: true
+    referenceable: true
+`
+
+	type args struct {
+		files  map[string]string
+		update bool
+	}
+	type want struct {
+		out     []string
+		files   map[string]string
+		deleted []string
+		err     error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoTests": {
+			reason: "We should return an error if the tests directory does not exist.",
+			args: args{
+				files: map[string]string{
+					"/composition.yaml": renderComposition,
+				},
+			},
+			want: want{
+				err: errors.Wrapf(errors.New("open /tests: file does not exist"), errFmtReadTests, "/tests"),
+			},
+		},
+		"Pass": {
+			reason: "A test case whose rendered resources match its expected resources should pass.",
+			args: args{
+				files: map[string]string{
+					"/composition.yaml":           renderComposition,
+					"/tests/large/xr.yaml":        renderXR,
+					"/tests/large/composed.yaml":  composed,
+					"/tests/unchecked/xr.yaml":    renderXR,
+					"/tests/not-a-test-case.yaml": "",
+				},
+			},
+			want: want{
+				out: []string{"PASS large", "PASS unchecked"},
+			},
+		},
+		"Fail": {
+			reason: "A test case whose rendered resources don't match its expected resources should fail.",
+			args: args{
+				files: map[string]string{
+					"/composition.yaml":          renderComposition,
+					"/tests/large/xr.yaml":       renderXR,
+					"/tests/large/composed.yaml": strings.Replace(composed, "size: large", "size: small", 1),
+					"/tests/other/xr.yaml":       strings.Replace(renderXR, "XCool", "XOther", 1),
+				},
+			},
+			want: want{
+				out: []string{"FAIL large", "ERROR other: " + errors.Errorf(errFmtNoComposition, "example.org/v1", "XOther").Error()},
+				err: errors.Errorf(errFmtTestsFailed, 2, 2),
+			},
+		},
+		"Update": {
+			reason: "The expected resources of each test case should be written when updating.",
+			args: args{
+				files: map[string]string{
+					"/composition.yaml":          renderComposition,
+					"/tests/large/xr.yaml":       renderXR,
+					"/tests/large/composed.yaml": "stale",
+				},
+				update: true,
+			},
+			want: want{
+				out:   []string{"UPDATED large"},
+				files: map[string]string{"/tests/large/composed.yaml": composed},
+			},
+		},
+		"UpdateRemovesStaleConnectionDetails": {
+			reason: "Expected connection details should be deleted when updating a test case that renders none.",
+			args: args{
+				files: map[string]string{
+					"/composition.yaml":                    renderComposition,
+					"/tests/large/xr.yaml":                 renderXR,
+					"/tests/large/connection-details.yaml": "password: stale\n",
+				},
+				update: true,
+			},
+			want: want{
+				out:     []string{"UPDATED large"},
+				deleted: []string{"/tests/large/connection-details.yaml"},
+			},
+		},
+		"UpdateFilteredConnectionDetails": {
+			reason: "Only connection details allowed by the package's XRD should be written when updating.",
+			args: args{
+				files: map[string]string{
+					"/composition.yaml":    connComposition,
+					"/xrd.yaml":            connXRD,
+					"/tests/large/xr.yaml": renderXR,
+				},
+				update: true,
+			},
+			want: want{
+				out:   []string{"UPDATED large"},
+				files: map[string]string{"/tests/large/connection-details.yaml": "password: hunter2\n"},
+			},
+		},
+		"UpdateUnfilteredConnectionDetails": {
+			reason: "All connection details should be written when updating if the package has no XRD for the composite resource.",
+			args: args{
+				files: map[string]string{
+					"/composition.yaml":    connComposition,
+					"/tests/large/xr.yaml": renderXR,
+				},
+				update: true,
+			},
+			want: want{
+				out:   []string{"UPDATED large"},
+				files: map[string]string{"/tests/large/connection-details.yaml": "password: hunter2\nusername: admin\n"},
+			},
+		},
+		"FilteredConnectionDetails": {
+			reason: "A test case should compare only the connection details allowed by the package's XRD.",
+			args: args{
+				files: map[string]string{
+					"/composition.yaml":                    connComposition,
+					"/xrd.yaml":                            connXRD,
+					"/tests/large/xr.yaml":                 renderXR,
+					"/tests/large/connection-details.yaml": "password: hunter2\n",
+				},
+			},
+			want: want{
+				out: []string{"PASS large"},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			for path, content := range tc.args.files {
+				if err := afero.WriteFile(fs, path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			out := &bytes.Buffer{}
+			c := &testCmd{PackageRoot: "/", Tests: "tests", Update: tc.args.update}
+			err := c.Run(&testChild{fs: fs, out: out})
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRun(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			for _, line := range tc.want.out {
+				if !strings.Contains(out.String(), line) {
+					t.Errorf("\n%s\nRun(...): output does not contain %q:\n%s", tc.reason, line, out.String())
+				}
+			}
+			for path, want := range tc.want.files {
+				got, _ := afero.ReadFile(fs, path)
+				if diff := cmp.Diff(want, string(got)); diff != "" {
+					t.Errorf("\n%s\nRun(...): -want %s, +got %s:\n%s", tc.reason, path, path, diff)
+				}
+			}
+			for _, path := range tc.want.deleted {
+				if ok, _ := afero.Exists(fs, path); ok {
+					t.Errorf("\n%s\nRun(...): %s was not deleted", tc.reason, path)
+				}
+			}
+		})
+	}
+}
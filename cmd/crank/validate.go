@@ -0,0 +1,142 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/controller/apiextensions/composite"
+)
+
+const (
+	errFmtConvertXRD          = "cannot convert %s to a CompositeResourceDefinition"
+	errFmtConvertCRD          = "cannot convert %s to a CustomResourceDefinition"
+	errFmtWalkPath            = "cannot walk %s"
+	errLoadSchemas            = "cannot load schemas"
+	errFmtCompositionsInvalid = "%d of %d Compositions are invalid"
+)
+
+// validateCmd validates Compositions against the schemas of the resources they
+// compose.
+type validateCmd struct {
+	Paths []string `arg:"" type:"path" help:"Paths to YAML files, or directories of YAML files, containing the Compositions to validate and the CompositeResourceDefinitions and CustomResourceDefinitions that define the resources they compose."`
+}
+
+// Run runs the validate cmd.
+func (c *validateCmd) Run(child *validateChild) error {
+	in, err := readValidateInputs(child.fs, c.Paths)
+	if err != nil {
+		return err
+	}
+
+	sv, err := composite.NewSchemaValidator(in.xrds, in.crds)
+	if err != nil {
+		return errors.Wrap(err, errLoadSchemas)
+	}
+
+	invalid := 0
+	for _, comp := range in.comps {
+		if err := sv.Validate(comp); err != nil {
+			invalid++
+			fmt.Fprintf(child.out, "INVALID %s: %s\n", comp.GetName(), err)
+			continue
+		}
+		fmt.Fprintf(child.out, "VALID %s\n", comp.GetName())
+	}
+
+	if invalid > 0 {
+		return errors.Errorf(errFmtCompositionsInvalid, invalid, len(in.comps))
+	}
+	return nil
+}
+
+type validateChild struct {
+	fs  afero.Fs
+	out io.Writer
+}
+
+type validateInputs struct {
+	comps []*v1.Composition
+	xrds  []v1.CompositeResourceDefinition
+	crds  []extv1.CustomResourceDefinition
+}
+
+// readValidateInputs reads all Compositions, CompositeResourceDefinitions, and
+// CustomResourceDefinitions in the supplied files and directories. Objects of
+// any other kind are ignored.
+func readValidateInputs(fs afero.Fs, paths []string) (*validateInputs, error) {
+	in := &validateInputs{
+		comps: make([]*v1.Composition, 0),
+		xrds:  make([]v1.CompositeResourceDefinition, 0),
+		crds:  make([]extv1.CustomResourceDefinition, 0),
+	}
+	crdGVK := extv1.SchemeGroupVersion.WithKind("CustomResourceDefinition")
+
+	for _, root := range paths {
+		err := afero.Walk(fs, root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			if ext := filepath.Ext(path); path != root && ext != ".yaml" && ext != ".yml" {
+				return nil
+			}
+			objs, err := readObjects(fs, path)
+			if err != nil {
+				return err
+			}
+			for _, o := range objs {
+				switch o.GroupVersionKind() {
+				case v1.CompositionGroupVersionKind:
+					comp := &v1.Composition{}
+					if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.Object, comp); err != nil {
+						return errors.Wrapf(err, errFmtConvertComposition, path)
+					}
+					in.comps = append(in.comps, comp)
+				case v1.CompositeResourceDefinitionGroupVersionKind:
+					xrd := v1.CompositeResourceDefinition{}
+					if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.Object, &xrd); err != nil {
+						return errors.Wrapf(err, errFmtConvertXRD, path)
+					}
+					in.xrds = append(in.xrds, xrd)
+				case crdGVK:
+					crd := extv1.CustomResourceDefinition{}
+					if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.Object, &crd); err != nil {
+						return errors.Wrapf(err, errFmtConvertCRD, path)
+					}
+					in.crds = append(in.crds, crd)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtWalkPath, root)
+		}
+	}
+	return in, nil
+}
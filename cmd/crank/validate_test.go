@@ -0,0 +1,156 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestValidate(t *testing.T) {
+	xrd := `
+apiVersion: apiextensions.crossplane.io/v1
+kind: CompositeResourceDefinition
+metadata:
+  name: xcools.example.org
+spec:
+  group: example.org
+  names:
+    kind: XCool
+    plural: xcools
+  versions:
+  - name: v1
+    served: true
+    referenceable: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              size:
+                type: string
+`
+	crd := `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: buckets.example.org
+spec:
+  group: example.org
+  names:
+    kind: Bucket
+    plural: buckets
+  scope: Cluster
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              forProvider:
+                type: object
+                properties:
+                  size:
+                    type: string
+`
+
+	type want struct {
+		out []string
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		files  map[string]string
+		paths  []string
+		want   want
+	}{
+		"Valid": {
+			reason: "A Composition whose patches match the schemas of its resources should be valid.",
+			files: map[string]string{
+				"/package/composition.yaml": renderComposition,
+				"/package/xrd.yaml":         xrd,
+				"/package/README.md":        "not YAML",
+				"/crds/bucket.yaml":         crd,
+			},
+			paths: []string{"/package", "/crds/bucket.yaml"},
+			want: want{
+				out: []string{"VALID cool"},
+			},
+		},
+		"InvalidPatch": {
+			reason: "A Composition that patches a field that does not exist in a composed resource's schema should be invalid.",
+			files: map[string]string{
+				"/composition.yaml": strings.Replace(renderComposition, "spec.forProvider.size", "spec.forProvider.nope", 1),
+				"/definitions.yaml": xrd + "---" + crd,
+			},
+			paths: []string{"/composition.yaml", "/definitions.yaml"},
+			want: want{
+				out: []string{"INVALID cool", "spec.forProvider.nope"},
+				err: errors.Errorf(errFmtCompositionsInvalid, 1, 1),
+			},
+		},
+		"NoCompositeSchema": {
+			reason: "A Composition whose composite resource is not defined by any of the supplied XRDs should be invalid.",
+			files: map[string]string{
+				"/composition.yaml": renderComposition,
+			},
+			paths: []string{"/composition.yaml"},
+			want: want{
+				out: []string{"INVALID cool"},
+				err: errors.Errorf(errFmtCompositionsInvalid, 1, 1),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			for path, content := range tc.files {
+				if err := afero.WriteFile(fs, path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			out := &bytes.Buffer{}
+			c := &validateCmd{Paths: tc.paths}
+			err := c.Run(&validateChild{fs: fs, out: out})
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRun(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			for _, line := range tc.want.out {
+				if !strings.Contains(out.String(), line) {
+					t.Errorf("\n%s\nRun(...): output does not contain %q:\n%s", tc.reason, line, out.String())
+				}
+			}
+		})
+	}
+}
@@ -29,6 +29,7 @@ import (
 
 	"github.com/crossplane/crossplane/internal/controller/apiextensions"
 	"github.com/crossplane/crossplane/internal/controller/pkg"
+	"github.com/crossplane/crossplane/internal/webhook/composition"
 	"github.com/crossplane/crossplane/internal/xpkg"
 )
 
@@ -39,6 +40,9 @@ type Command struct {
 	CacheDir       string
 	LeaderElection bool
 	Sync           time.Duration
+
+	WebhookTLSCertDir string
+	WebhookPort       int
 }
 
 // FromKingpin produces the core Crossplane command from a Kingpin command.
@@ -49,6 +53,8 @@ func FromKingpin(cmd *kingpin.CmdClause) (*Command, *InitCommand) {
 	cmd.Flag("cache-dir", "Directory used for caching package images.").Short('c').Default("/cache").OverrideDefaultFromEnvar("CACHE_DIR").StringVar(&c.CacheDir)
 	cmd.Flag("sync", "Controller manager sync period duration such as 300ms, 1.5h or 2h45m").Short('s').Default("1h").DurationVar(&c.Sync)
 	cmd.Flag("leader-election", "Use leader election for the conroller manager.").Short('l').Default("false").OverrideDefaultFromEnvar("LEADER_ELECTION").BoolVar(&c.LeaderElection)
+	cmd.Flag("webhook-tls-cert-dir", "Directory containing the tls.crt and tls.key served by the validating webhook. Webhooks are disabled if unset.").OverrideDefaultFromEnvar("WEBHOOK_TLS_CERT_DIR").StringVar(&c.WebhookTLSCertDir)
+	cmd.Flag("webhook-port", "Port at which the validating webhook is served.").Default("9443").OverrideDefaultFromEnvar("WEBHOOK_PORT").IntVar(&c.WebhookPort)
 	initCmd := cmd.Command("init", "Make cluster ready for Crossplane controllers.")
 	init := &InitCommand{Name: initCmd.FullCommand()}
 	initCmd.Flag("provider", "Pre-install a Provider by giving its image URI. This argument can be repeated.").StringsVar(&init.Providers)
@@ -69,6 +75,8 @@ func (c *Command) Run(s *runtime.Scheme, log logging.Logger) error {
 		LeaderElection:   c.LeaderElection,
 		LeaderElectionID: "crossplane-leader-election-core",
 		SyncPeriod:       &c.Sync,
+		CertDir:          c.WebhookTLSCertDir,
+		Port:             c.WebhookPort,
 	})
 	if err != nil {
 		return errors.Wrap(err, "Cannot create manager")
@@ -78,6 +86,12 @@ func (c *Command) Run(s *runtime.Scheme, log logging.Logger) error {
 		return errors.Wrap(err, "Cannot setup API extension controllers")
 	}
 
+	if c.WebhookTLSCertDir != "" {
+		if err := composition.Setup(mgr, log); err != nil {
+			return errors.Wrap(err, "Cannot setup Composition validating webhook")
+		}
+	}
+
 	pkgCache := xpkg.NewImageCache(c.CacheDir, afero.NewOsFs())
 
 	if err := pkg.Setup(mgr, log, pkgCache, c.Namespace); err != nil {
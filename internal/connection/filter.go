@@ -0,0 +1,52 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connection
+
+import (
+	"path"
+
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+)
+
+// AllKeys is a connection secret key pattern that matches every key.
+const AllKeys = "*"
+
+// FilterKeys returns the supplied connection details whose keys match at least
+// one of the supplied patterns. Patterns use the syntax of path.Match, so a
+// pattern like 'kubeconfig*' matches both 'kubeconfig' and 'kubeconfig-admin'.
+// Malformed patterns match no keys. No connection details are returned if no
+// patterns are supplied; use AllKeys to return all connection details.
+func FilterKeys(patterns []string, c managed.ConnectionDetails) managed.ConnectionDetails {
+	out := managed.ConnectionDetails{}
+	for key, val := range c {
+		if MatchKey(patterns, key) {
+			out[key] = val
+		}
+	}
+	return out
+}
+
+// MatchKey returns true if the supplied connection secret key matches at least
+// one of the supplied patterns.
+func MatchKey(patterns []string, key string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, key); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connection
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+)
+
+func TestFilterKeys(t *testing.T) {
+	c := managed.ConnectionDetails{
+		"kubeconfig":       []byte("a"),
+		"kubeconfig-admin": []byte("b"),
+		"password":         []byte("c"),
+	}
+
+	cases := map[string]struct {
+		reason   string
+		patterns []string
+		want     managed.ConnectionDetails
+	}{
+		"NoPatterns": {
+			reason: "No connection details should be returned if no patterns are supplied.",
+			want:   managed.ConnectionDetails{},
+		},
+		"ExactKey": {
+			reason:   "A pattern without wildcards should match only the identical key.",
+			patterns: []string{"kubeconfig"},
+			want:     managed.ConnectionDetails{"kubeconfig": []byte("a")},
+		},
+		"Wildcard": {
+			reason:   "A pattern with wildcards should match all keys it describes.",
+			patterns: []string{"kubeconfig*"},
+			want: managed.ConnectionDetails{
+				"kubeconfig":       []byte("a"),
+				"kubeconfig-admin": []byte("b"),
+			},
+		},
+		"AllKeys": {
+			reason:   "The AllKeys pattern should match every key.",
+			patterns: []string{AllKeys},
+			want:     c,
+		},
+		"MalformedPattern": {
+			reason:   "A malformed pattern should match no keys.",
+			patterns: []string{"pass[", "password"},
+			want:     managed.ConnectionDetails{"password": []byte("c")},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := FilterKeys(tc.patterns, c)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nFilterKeys(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connection
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+
+	"github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
+)
+
+// FieldPathSecretMetadata is the field path at which the metadata of a
+// connection Secret is configured.
+const FieldPathSecretMetadata = "spec.connectionSecretMetadata"
+
+// GetSecretMetadata returns the connection Secret metadata configured by the
+// supplied object, if any. It always returns nil for objects that are not
+// unstructured.
+func GetSecretMetadata(o interface{}) *v1alpha1.ConnectionSecretMetadata {
+	u, ok := o.(unstructuredOwner)
+	if !ok {
+		return nil
+	}
+	md := &v1alpha1.ConnectionSecretMetadata{}
+	if err := fieldpath.Pave(u.GetUnstructured().Object).GetValueInto(FieldPathSecretMetadata, md); err != nil {
+		return nil
+	}
+	return md
+}
+
+// SetSecretMetadata adds the supplied labels and annotations to the supplied
+// Secret, and sets its type if one is configured.
+func SetSecretMetadata(s *corev1.Secret, md *v1alpha1.ConnectionSecretMetadata) {
+	if md == nil {
+		return
+	}
+	meta.AddLabels(s, md.Labels)
+	meta.AddAnnotations(s, md.Annotations)
+	if md.Type != nil {
+		s.Type = *md.Type
+	}
+}
+
+// SecretChanged returns true if the data of the desired Secret differs from
+// that of the current Secret, or if the current Secret is missing any of the
+// labels or annotations of the desired Secret. Labels and annotations that only
+// the current Secret has were added by something other than Crossplane, and are
+// ignored.
+func SecretChanged(current, desired *corev1.Secret) bool {
+	return !cmp.Equal(current.Data, desired.Data, cmpopts.EquateEmpty()) ||
+		!contains(current.GetLabels(), desired.GetLabels()) ||
+		!contains(current.GetAnnotations(), desired.GetAnnotations())
+}
+
+// MergeSecret makes the desired Secret a copy of the current Secret with the
+// data, labels, and annotations of the desired Secret. Data keys that the
+// desired Secret does not have are removed. Labels, annotations, finalizers,
+// and owner references added to the current Secret by something other than
+// Crossplane are preserved, as is its type, which is immutable.
+func MergeSecret(current, desired *corev1.Secret) {
+	om := current.ObjectMeta.DeepCopy()
+	meta.AddLabels(om, desired.GetLabels())
+	meta.AddAnnotations(om, desired.GetAnnotations())
+	if metav1.GetControllerOf(om) == nil {
+		if c := metav1.GetControllerOf(desired); c != nil {
+			om.OwnerReferences = append(om.OwnerReferences, *c)
+		}
+	}
+	desired.ObjectMeta = *om
+	desired.Type = current.Type
+}
+
+// contains returns true if have contains every key of want, with the same
+// value.
+func contains(have, want map[string]string) bool {
+	for k, v := range want {
+		if hv, ok := have[k]; !ok || hv != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,265 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connection
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+
+	"github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
+)
+
+func TestGetSecretMetadata(t *testing.T) {
+	opaque := corev1.SecretTypeOpaque
+
+	cases := map[string]struct {
+		reason string
+		o      interface{}
+		want   *v1alpha1.ConnectionSecretMetadata
+	}{
+		"NotUnstructured": {
+			reason: "Objects that are not unstructured do not configure connection secret metadata.",
+			o:      &fake.Composite{},
+			want:   nil,
+		},
+		"NotConfigured": {
+			reason: "Unstructured objects need not configure connection secret metadata.",
+			o:      composite.New(),
+			want:   nil,
+		},
+		"Configured": {
+			reason: "Configured connection secret metadata should be returned.",
+			o: func() interface{} {
+				xr := composite.New()
+				xr.Object["spec"] = map[string]interface{}{
+					"connectionSecretMetadata": map[string]interface{}{
+						"labels":      map[string]interface{}{"team": "platform"},
+						"annotations": map[string]interface{}{"owner": "dba"},
+						"type":        "Opaque",
+					},
+				}
+				return xr
+			}(),
+			want: &v1alpha1.ConnectionSecretMetadata{
+				Labels:      map[string]string{"team": "platform"},
+				Annotations: map[string]string{"owner": "dba"},
+				Type:        &opaque,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := GetSecretMetadata(tc.o)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nGetSecretMetadata(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestSetSecretMetadata(t *testing.T) {
+	opaque := corev1.SecretTypeOpaque
+
+	cases := map[string]struct {
+		reason string
+		s      *corev1.Secret
+		md     *v1alpha1.ConnectionSecretMetadata
+		want   *corev1.Secret
+	}{
+		"NoMetadata": {
+			reason: "The Secret should be unchanged when no metadata is configured.",
+			s:      &corev1.Secret{Type: "connection.crossplane.io/v1alpha1"},
+			want:   &corev1.Secret{Type: "connection.crossplane.io/v1alpha1"},
+		},
+		"Metadata": {
+			reason: "Configured labels and annotations should be added to the Secret, and its type set.",
+			s: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"existing": "label"}},
+				Type:       "connection.crossplane.io/v1alpha1",
+			},
+			md: &v1alpha1.ConnectionSecretMetadata{
+				Labels:      map[string]string{"team": "platform"},
+				Annotations: map[string]string{"owner": "dba"},
+				Type:        &opaque,
+			},
+			want: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      map[string]string{"existing": "label", "team": "platform"},
+					Annotations: map[string]string{"owner": "dba"},
+				},
+				Type: corev1.SecretTypeOpaque,
+			},
+		},
+		"NoType": {
+			reason: "The Secret's type should be unchanged when no type is configured.",
+			s:      &corev1.Secret{Type: "connection.crossplane.io/v1alpha1"},
+			md: &v1alpha1.ConnectionSecretMetadata{
+				Labels: map[string]string{"team": "platform"},
+			},
+			want: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "platform"}},
+				Type:       "connection.crossplane.io/v1alpha1",
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			SetSecretMetadata(tc.s, tc.md)
+			if diff := cmp.Diff(tc.want, tc.s); diff != "" {
+				t.Errorf("\n%s\nSetSecretMetadata(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestSecretChanged(t *testing.T) {
+	cases := map[string]struct {
+		reason  string
+		current *corev1.Secret
+		desired *corev1.Secret
+		want    bool
+	}{
+		"Unchanged": {
+			reason:  "Secrets with the same data, labels, and annotations are unchanged.",
+			current: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "platform"}}, Data: map[string][]byte{"password": []byte("hunter2")}},
+			desired: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "platform"}}, Data: map[string][]byte{"password": []byte("hunter2")}},
+			want:    false,
+		},
+		"EmptyIsNil": {
+			reason:  "Empty and nil data, labels, and annotations are equivalent.",
+			current: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{}}, Data: map[string][]byte{}},
+			desired: &corev1.Secret{},
+			want:    false,
+		},
+		"DataChanged": {
+			reason:  "Secrets with different data are changed.",
+			current: &corev1.Secret{Data: map[string][]byte{"password": []byte("hunter2")}},
+			desired: &corev1.Secret{Data: map[string][]byte{"password": []byte("correct-horse")}},
+			want:    true,
+		},
+		"LabelsChanged": {
+			reason:  "Secrets with different labels are changed.",
+			current: &corev1.Secret{},
+			desired: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "platform"}}},
+			want:    true,
+		},
+		"AnnotationsChanged": {
+			reason:  "Secrets with different annotations are changed.",
+			current: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"owner": "dba"}}},
+			desired: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"owner": "sre"}}},
+			want:    true,
+		},
+		"ForeignMetadata": {
+			reason:  "Labels and annotations that only the current Secret has were added by other tools, and don't make it changed.",
+			current: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "platform", "app": "argo"}, Annotations: map[string]string{"argocd.argoproj.io/tracking-id": "cool"}}},
+			desired: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "platform"}}},
+			want:    false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := SecretChanged(tc.current, tc.desired)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nSecretChanged(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestMergeSecret(t *testing.T) {
+	controller := metav1.OwnerReference{UID: "cool-uid", Controller: pointer.BoolPtr(true)}
+	owner := metav1.OwnerReference{UID: "other-uid"}
+
+	cases := map[string]struct {
+		reason  string
+		current *corev1.Secret
+		desired *corev1.Secret
+		want    *corev1.Secret
+	}{
+		"PreserveForeignMetadata": {
+			reason: "Metadata added by other tools should be preserved, while data and managed labels and annotations are replaced.",
+			current: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "cool",
+					ResourceVersion: "42",
+					Labels:          map[string]string{"team": "platform", "app": "argo"},
+					Annotations:     map[string]string{"argocd.argoproj.io/tracking-id": "cool"},
+					Finalizers:      []string{"example.org/protect"},
+					OwnerReferences: []metav1.OwnerReference{owner, controller},
+				},
+				Type: corev1.SecretTypeOpaque,
+				Data: map[string][]byte{"password": []byte("hunter2"), "stale": []byte("yes")},
+			},
+			desired: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "cool",
+					Labels:          map[string]string{"team": "databases"},
+					OwnerReferences: []metav1.OwnerReference{controller},
+				},
+				Type: resource.SecretTypeConnection,
+				Data: map[string][]byte{"password": []byte("correct-horse")},
+			},
+			want: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "cool",
+					ResourceVersion: "42",
+					Labels:          map[string]string{"team": "databases", "app": "argo"},
+					Annotations:     map[string]string{"argocd.argoproj.io/tracking-id": "cool"},
+					Finalizers:      []string{"example.org/protect"},
+					OwnerReferences: []metav1.OwnerReference{owner, controller},
+				},
+				Type: corev1.SecretTypeOpaque,
+				Data: map[string][]byte{"password": []byte("correct-horse")},
+			},
+		},
+		"AdoptUncontrolled": {
+			reason: "The desired controller reference should be added to a Secret that has no controller.",
+			current: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{owner}},
+				Type:       resource.SecretTypeConnection,
+			},
+			desired: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{controller}},
+				Type:       resource.SecretTypeConnection,
+			},
+			want: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{owner, controller}},
+				Type:       resource.SecretTypeConnection,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			MergeSecret(tc.current, tc.desired)
+			if diff := cmp.Diff(tc.want, tc.desired); diff != "" {
+				t.Errorf("\n%s\nMergeSecret(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
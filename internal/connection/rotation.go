@@ -0,0 +1,75 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connection
+
+import (
+	"bytes"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+)
+
+// FieldPathLastRotatedTime is the field path at which the time connection
+// details were last rotated is recorded.
+const FieldPathLastRotatedTime = "status.connectionDetails.lastRotatedTime"
+
+// Rotated returns true if replacing the current connection details with the
+// desired connection details would change or remove any of the current
+// connection details. Adding new connection details is not a rotation.
+func Rotated(current, desired map[string][]byte) bool {
+	for k, v := range current {
+		d, ok := desired[k]
+		if !ok || !bytes.Equal(v, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// An unstructuredOwner is a connection details owner that is backed by an
+// unstructured object, such as a composite resource or claim.
+type unstructuredOwner interface {
+	GetUnstructured() *unstructured.Unstructured
+}
+
+// GetLastRotatedTime returns the time at which the connection details of the
+// supplied object were last rotated, if ever. It always returns nil for
+// objects that are not unstructured.
+func GetLastRotatedTime(o interface{}) *metav1.Time {
+	u, ok := o.(unstructuredOwner)
+	if !ok {
+		return nil
+	}
+	t := &metav1.Time{}
+	if err := fieldpath.Pave(u.GetUnstructured().Object).GetValueInto(FieldPathLastRotatedTime, t); err != nil {
+		return nil
+	}
+	return t
+}
+
+// SetLastRotatedTime records the time at which the connection details of the
+// supplied object were last rotated. It is a no-op for objects that are not
+// unstructured.
+func SetLastRotatedTime(o interface{}, t *metav1.Time) {
+	u, ok := o.(unstructuredOwner)
+	if !ok {
+		return
+	}
+	_ = fieldpath.Pave(u.GetUnstructured().Object).SetValue(FieldPathLastRotatedTime, t)
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+)
+
+func TestRotated(t *testing.T) {
+	cases := map[string]struct {
+		reason  string
+		current map[string][]byte
+		desired map[string][]byte
+		want    bool
+	}{
+		"Unchanged": {
+			reason:  "Connection details that did not change were not rotated.",
+			current: map[string][]byte{"password": []byte("hunter2")},
+			desired: map[string][]byte{"password": []byte("hunter2")},
+			want:    false,
+		},
+		"Added": {
+			reason:  "Adding a connection detail is not a rotation.",
+			current: map[string][]byte{"password": []byte("hunter2")},
+			desired: map[string][]byte{"password": []byte("hunter2"), "username": []byte("admin")},
+			want:    false,
+		},
+		"Changed": {
+			reason:  "Changing a connection detail is a rotation.",
+			current: map[string][]byte{"password": []byte("hunter2")},
+			desired: map[string][]byte{"password": []byte("correct-horse")},
+			want:    true,
+		},
+		"Removed": {
+			reason:  "Removing a connection detail is a rotation.",
+			current: map[string][]byte{"password": []byte("hunter2"), "username": []byte("admin")},
+			desired: map[string][]byte{"password": []byte("hunter2")},
+			want:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := Rotated(tc.current, tc.desired)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nRotated(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestLastRotatedTime(t *testing.T) {
+	now := metav1.NewTime(time.Unix(1600000000, 0).UTC())
+
+	xr := composite.New()
+	if got := GetLastRotatedTime(xr); got != nil {
+		t.Errorf("GetLastRotatedTime(...): want nil, got %v", got)
+	}
+	SetLastRotatedTime(xr, &now)
+	if diff := cmp.Diff(&now, GetLastRotatedTime(xr)); diff != "" {
+		t.Errorf("GetLastRotatedTime(...): -want, +got:\n%s", diff)
+	}
+
+	// Objects that are not unstructured do not record a last rotated time.
+	cd := &fake.Composed{}
+	SetLastRotatedTime(cd, &now)
+	if got := GetLastRotatedTime(cd); got != nil {
+		t.Errorf("GetLastRotatedTime(...): want nil, got %v", got)
+	}
+}
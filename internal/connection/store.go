@@ -0,0 +1,225 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package connection filters connection details, tracks their rotation, and
+// stores them in the stores configured by StoreConfigs.
+package connection
+
+import (
+	"context"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	"github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
+	"github.com/crossplane/crossplane/internal/connection/vault"
+)
+
+const (
+	errNoScope            = "Kubernetes StoreConfig must specify a default scope"
+	errApplySecret        = "cannot apply connection secret"
+	errGetSecret          = "cannot get connection secret"
+	errDeleteSecret       = "cannot delete connection secret"
+	errNoVaultConfig      = "Vault StoreConfig must configure a Vault store"
+	errNoTokenAuth        = "Vault StoreConfig must configure token authentication"
+	errGetTokenSecret     = "cannot get Vault token secret"
+	errNewVaultStore      = "cannot create Vault store"
+	errFmtUnsupportedType = "unsupported StoreConfig type %q"
+	errFmtUnsupportedAuth = "unsupported Vault authentication method %q"
+	errFmtNoTokenKey      = "Vault token secret has no key %q"
+)
+
+// A WriteOption is called by a Store before it replaces connection details that
+// are already stored, with the current and desired connection details. It is
+// an alias so that Stores implemented in other packages, which this package
+// imports, need not import this package.
+type WriteOption = func(current, desired managed.ConnectionDetails)
+
+// A Store stores connection details on behalf of the resource that owns them.
+type Store interface {
+	// WriteKeyValues writes the supplied connection details under the
+	// supplied name, replacing any connection details already stored there.
+	// It returns true if the stored connection details changed. Stores record
+	// the supplied resource as the owner of the connection details, and
+	// refuse to replace connection details owned by another resource.
+	WriteKeyValues(ctx context.Context, so resource.ConnectionSecretOwner, name string, c managed.ConnectionDetails, wo ...WriteOption) (changed bool, err error)
+
+	// DeleteKeyValues deletes all connection details stored under the
+	// supplied name. It is not an error if no connection details are stored
+	// there. Only connection details owned by the supplied resource are
+	// deleted.
+	DeleteKeyValues(ctx context.Context, so resource.ConnectionSecretOwner, name string) error
+}
+
+// A StoreBuilder builds the Store configured by a StoreConfig.
+type StoreBuilder interface {
+	Build(ctx context.Context, cfg *v1alpha1.StoreConfig) (Store, error)
+}
+
+// A StoreBuilderFn builds the Store configured by a StoreConfig.
+type StoreBuilderFn func(ctx context.Context, cfg *v1alpha1.StoreConfig) (Store, error)
+
+// Build the Store configured by the supplied StoreConfig.
+func (fn StoreBuilderFn) Build(ctx context.Context, cfg *v1alpha1.StoreConfig) (Store, error) {
+	return fn(ctx, cfg)
+}
+
+// StoreBuilders build Stores using the StoreBuilder registered for the type of
+// the StoreConfig. Support for a new type of Store may be plugged in by
+// registering a StoreBuilder for it.
+type StoreBuilders map[v1alpha1.StoreType]StoreBuilder
+
+// Build the Store configured by the supplied StoreConfig.
+func (b StoreBuilders) Build(ctx context.Context, cfg *v1alpha1.StoreConfig) (Store, error) {
+	sb, ok := b[cfg.Spec.Type]
+	if !ok {
+		return nil, errors.Errorf(errFmtUnsupportedType, cfg.Spec.Type)
+	}
+	return sb.Build(ctx, cfg)
+}
+
+// NewStoreBuilders returns StoreBuilders for all supported types of Store.
+func NewStoreBuilders(c client.Client) StoreBuilders {
+	return StoreBuilders{
+		v1alpha1.StoreTypeKubernetes: StoreBuilderFn(func(_ context.Context, cfg *v1alpha1.StoreConfig) (Store, error) {
+			s, err := NewSecretStore(c, cfg)
+			if err != nil {
+				return nil, err
+			}
+			return s, nil
+		}),
+		v1alpha1.StoreTypeVault: NewVaultStoreBuilder(c),
+	}
+}
+
+// A SecretStore stores connection details in Kubernetes Secrets.
+type SecretStore struct {
+	client    resource.ClientApplicator
+	namespace string
+}
+
+// NewSecretStore returns a Store that writes connection details to Secrets in
+// the default scope of the supplied StoreConfig.
+func NewSecretStore(c client.Client, cfg *v1alpha1.StoreConfig) (*SecretStore, error) {
+	if cfg.Spec.DefaultScope == "" {
+		return nil, errors.New(errNoScope)
+	}
+	return &SecretStore{
+		client:    resource.ClientApplicator{Client: c, Applicator: resource.NewAPIUpdatingApplicator(c)},
+		namespace: cfg.Spec.DefaultScope,
+	}, nil
+}
+
+// WriteKeyValues writes the supplied connection details to the Secret with the
+// supplied name, which is controlled by the supplied resource. Any keys of the
+// Secret that are not included in the supplied connection details are removed.
+// A Secret controlled by another resource is never overwritten.
+func (s *SecretStore) WriteKeyValues(ctx context.Context, so resource.ConnectionSecretOwner, name string, c managed.ConnectionDetails, wo ...WriteOption) (bool, error) {
+	sc := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       s.namespace,
+			Name:            name,
+			OwnerReferences: []metav1.OwnerReference{meta.AsController(meta.TypedReferenceTo(so, so.GetObjectKind().GroupVersionKind()))},
+		},
+		Type: resource.SecretTypeConnection,
+		Data: c,
+	}
+	err := s.client.Apply(ctx, sc,
+		resource.ConnectionSecretMustBeControllableBy(so.GetUID()),
+		resource.AllowUpdateIf(func(current, desired runtime.Object) bool {
+			// We consider the update to be a no-op and don't allow it if the
+			// current and existing secret data are identical, and the
+			// current secret is already controlled by the resource.
+			cs, ds := current.(*corev1.Secret), desired.(*corev1.Secret)
+			for _, fn := range wo {
+				fn(cs.Data, ds.Data)
+			}
+			changed := metav1.GetControllerOf(cs) == nil || !cmp.Equal(cs.Data, ds.Data, cmpopts.EquateEmpty())
+
+			// We update the current secret rather than replacing it, so
+			// that we don't remove metadata added by other tools.
+			MergeSecret(cs, ds)
+			return changed
+		}),
+	)
+	if resource.IsNotAllowed(err) {
+		return false, nil
+	}
+	return err == nil, errors.Wrap(err, errApplySecret)
+}
+
+// DeleteKeyValues deletes the Secret with the supplied name, if the supplied
+// resource controls it.
+func (s *SecretStore) DeleteKeyValues(ctx context.Context, so resource.ConnectionSecretOwner, name string) error {
+	sc := &corev1.Secret{}
+	err := s.client.Get(ctx, types.NamespacedName{Namespace: s.namespace, Name: name}, sc)
+	if kerrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, errGetSecret)
+	}
+
+	// We never delete a Secret that this resource does not control.
+	if c := metav1.GetControllerOf(sc); c == nil || c.UID != so.GetUID() {
+		return nil
+	}
+	return errors.Wrap(resource.IgnoreNotFound(s.client.Delete(ctx, sc)), errDeleteSecret)
+}
+
+// NewVaultStoreBuilder returns a StoreBuilder that builds Vault stores. The
+// supplied client is used to read the Vault token.
+func NewVaultStoreBuilder(c client.Reader) StoreBuilderFn {
+	return func(ctx context.Context, cfg *v1alpha1.StoreConfig) (Store, error) {
+		if cfg.Spec.Vault == nil {
+			return nil, errors.New(errNoVaultConfig)
+		}
+		a := cfg.Spec.Vault.Auth
+		if a.Method != v1alpha1.VaultAuthMethodToken {
+			return nil, errors.Errorf(errFmtUnsupportedAuth, a.Method)
+		}
+		if a.Token == nil {
+			return nil, errors.New(errNoTokenAuth)
+		}
+
+		ref := a.Token.SecretRef
+		s := &corev1.Secret{}
+		if err := c.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, s); err != nil {
+			return nil, errors.Wrap(err, errGetTokenSecret)
+		}
+		token, ok := s.Data[ref.Key]
+		if !ok {
+			return nil, errors.Errorf(errFmtNoTokenKey, ref.Key)
+		}
+
+		vs, err := vault.NewStore(cfg, string(token))
+		if err != nil {
+			return nil, errors.Wrap(err, errNewVaultStore)
+		}
+		return vs, nil
+	}
+}
@@ -0,0 +1,341 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package connection
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
+)
+
+var errBoom = errors.New("boom")
+
+func TestStoreBuilders(t *testing.T) {
+	cfg := &v1alpha1.StoreConfig{Spec: v1alpha1.StoreConfigSpec{Type: "Wat"}}
+	_, err := StoreBuilders{}.Build(context.Background(), cfg)
+	if diff := cmp.Diff(errors.Errorf(errFmtUnsupportedType, "Wat"), err, test.EquateErrors()); diff != "" {
+		t.Errorf("Build(...): -want error, +got error:\n%s", diff)
+	}
+}
+
+func TestSecretStoreWriteKeyValues(t *testing.T) {
+	owner := &fake.MockConnectionSecretOwner{ObjectMeta: metav1.ObjectMeta{Name: "cool-xr", UID: "cool-uid"}}
+
+	type args struct {
+		applicator resource.Applicator
+		c          managed.ConnectionDetails
+	}
+	type want struct {
+		changed bool
+		err     error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"ApplyError": {
+			reason: "We should return any error encountered applying the Secret.",
+			args: args{
+				applicator: resource.ApplyFn(func(_ context.Context, _ client.Object, _ ...resource.ApplyOption) error { return errBoom }),
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errApplySecret),
+			},
+		},
+		"Unchanged": {
+			reason: "We should not report a change if the Secret was not updated.",
+			args: args{
+				applicator: resource.ApplyFn(func(ctx context.Context, o client.Object, _ ...resource.ApplyOption) error {
+					return resource.AllowUpdateIf(func(_, _ runtime.Object) bool { return false })(ctx, o, o)
+				}),
+			},
+			want: want{
+				changed: false,
+			},
+		},
+		"NotControllable": {
+			reason: "We should return an error rather than overwrite a Secret controlled by another resource.",
+			args: args{
+				applicator: resource.ApplyFn(func(ctx context.Context, o client.Object, ao ...resource.ApplyOption) error {
+					current := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+						OwnerReferences: []metav1.OwnerReference{{UID: "other-uid", Controller: pointer.BoolPtr(true)}},
+					}}
+					for _, fn := range ao {
+						if err := fn(ctx, current, o); err != nil {
+							return err
+						}
+					}
+					return nil
+				}),
+			},
+			want: want{
+				err: errors.Wrap(errors.Errorf("existing secret is not controlled by UID %q", "cool-uid"), errApplySecret),
+			},
+		},
+		"AdoptUncontrolled": {
+			reason: "We should take control of an existing connection Secret that is not controlled by any resource, even if its data is unchanged.",
+			args: args{
+				applicator: resource.ApplyFn(func(ctx context.Context, o client.Object, ao ...resource.ApplyOption) error {
+					current := &corev1.Secret{Type: resource.SecretTypeConnection, Data: managed.ConnectionDetails{"password": []byte("hunter2")}}
+					for _, fn := range ao {
+						if err := fn(ctx, current, o); err != nil {
+							return err
+						}
+					}
+					return nil
+				}),
+				c: managed.ConnectionDetails{"password": []byte("hunter2")},
+			},
+			want: want{
+				changed: true,
+			},
+		},
+		"Changed": {
+			reason: "We should apply a Secret containing the connection details to the default scope, controlled by the resource that owns them.",
+			args: args{
+				applicator: resource.ApplyFn(func(_ context.Context, o client.Object, _ ...resource.ApplyOption) error {
+					want := &corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{
+							Namespace: "crossplane-system",
+							Name:      "cool",
+							OwnerReferences: []metav1.OwnerReference{{
+								Name:       "cool-xr",
+								UID:        "cool-uid",
+								Controller: pointer.BoolPtr(true),
+							}},
+						},
+						Type: resource.SecretTypeConnection,
+						Data: managed.ConnectionDetails{"password": []byte("hunter2")},
+					}
+					if diff := cmp.Diff(want, o); diff != "" {
+						t.Errorf("Apply(...): -want, +got:\n%s", diff)
+					}
+					return nil
+				}),
+				c: managed.ConnectionDetails{"password": []byte("hunter2")},
+			},
+			want: want{
+				changed: true,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := &SecretStore{client: resource.ClientApplicator{Applicator: tc.args.applicator}, namespace: "crossplane-system"}
+			changed, err := s.WriteKeyValues(context.Background(), owner, "cool", tc.args.c)
+			if diff := cmp.Diff(tc.want.changed, changed); diff != "" {
+				t.Errorf("\n%s\ns.WriteKeyValues(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\ns.WriteKeyValues(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestSecretStoreDeleteKeyValues(t *testing.T) {
+	owner := &fake.MockConnectionSecretOwner{ObjectMeta: metav1.ObjectMeta{Name: "cool-xr", UID: "cool-uid"}}
+	controlledBy := func(uid types.UID) test.MockGetFn {
+		return test.NewMockGetFn(nil, func(obj client.Object) error {
+			if uid != "" {
+				obj.SetOwnerReferences([]metav1.OwnerReference{{UID: uid, Controller: pointer.BoolPtr(true)}})
+			}
+			return nil
+		})
+	}
+
+	cases := map[string]struct {
+		reason string
+		client client.Client
+		want   error
+	}{
+		"GetError": {
+			reason: "We should return any error encountered getting the Secret.",
+			client: &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+			want:   errors.Wrap(errBoom, errGetSecret),
+		},
+		"NotFound": {
+			reason: "We should not return an error if the Secret does not exist.",
+			client: &test.MockClient{MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "cool"))},
+		},
+		"Uncontrolled": {
+			reason: "We should not delete a Secret that is not controlled by any resource.",
+			client: &test.MockClient{
+				MockGet:    controlledBy(""),
+				MockDelete: test.NewMockDeleteFn(errors.New("unexpected delete")),
+			},
+		},
+		"ControlledByOther": {
+			reason: "We should not delete a Secret that is controlled by another resource.",
+			client: &test.MockClient{
+				MockGet:    controlledBy("other-uid"),
+				MockDelete: test.NewMockDeleteFn(errors.New("unexpected delete")),
+			},
+		},
+		"DeleteError": {
+			reason: "We should return any error encountered deleting the Secret.",
+			client: &test.MockClient{
+				MockGet:    controlledBy("cool-uid"),
+				MockDelete: test.NewMockDeleteFn(errBoom),
+			},
+			want: errors.Wrap(errBoom, errDeleteSecret),
+		},
+		"Success": {
+			reason: "We should delete the Secret from the default scope if the resource controls it.",
+			client: &test.MockClient{
+				MockGet: func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+					if key.Namespace != "crossplane-system" || key.Name != "cool" {
+						t.Errorf("Get(...): wrong Secret %s/%s", key.Namespace, key.Name)
+					}
+					return controlledBy("cool-uid")(ctx, key, obj)
+				},
+				MockDelete: test.NewMockDeleteFn(nil),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := &SecretStore{client: resource.ClientApplicator{Client: tc.client}, namespace: "crossplane-system"}
+			err := s.DeleteKeyValues(context.Background(), owner, "cool")
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\ns.DeleteKeyValues(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestNewSecretStore(t *testing.T) {
+	_, err := NewSecretStore(nil, &v1alpha1.StoreConfig{})
+	if diff := cmp.Diff(errors.New(errNoScope), err, test.EquateErrors()); diff != "" {
+		t.Errorf("NewSecretStore(...): -want error, +got error:\n%s", diff)
+	}
+}
+
+func TestVaultStoreBuilder(t *testing.T) {
+	vault := func(a v1alpha1.VaultAuthConfig) *v1alpha1.StoreConfig {
+		return &v1alpha1.StoreConfig{Spec: v1alpha1.StoreConfigSpec{
+			Type: v1alpha1.StoreTypeVault,
+			Vault: &v1alpha1.VaultStoreConfig{
+				Server:    "https://vault.example.org",
+				MountPath: "secret",
+				Auth:      a,
+			},
+		}}
+	}
+	token := v1alpha1.VaultAuthConfig{
+		Method: v1alpha1.VaultAuthMethodToken,
+		Token: &v1alpha1.VaultTokenAuthConfig{
+			SecretRef: xpv1.SecretKeySelector{
+				SecretReference: xpv1.SecretReference{Namespace: "crossplane-system", Name: "vault"},
+				Key:             "token",
+			},
+		},
+	}
+
+	type args struct {
+		client client.Reader
+		cfg    *v1alpha1.StoreConfig
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   error
+	}{
+		"NoVaultConfig": {
+			reason: "We should return an error if the StoreConfig does not configure Vault.",
+			args: args{
+				cfg: &v1alpha1.StoreConfig{},
+			},
+			want: errors.New(errNoVaultConfig),
+		},
+		"UnsupportedAuthMethod": {
+			reason: "We should return an error if the authentication method is not supported.",
+			args: args{
+				cfg: vault(v1alpha1.VaultAuthConfig{Method: "Wat"}),
+			},
+			want: errors.Errorf(errFmtUnsupportedAuth, "Wat"),
+		},
+		"NoTokenAuth": {
+			reason: "We should return an error if token authentication is not configured.",
+			args: args{
+				cfg: vault(v1alpha1.VaultAuthConfig{Method: v1alpha1.VaultAuthMethodToken}),
+			},
+			want: errors.New(errNoTokenAuth),
+		},
+		"GetTokenSecretError": {
+			reason: "We should return any error encountered getting the token Secret.",
+			args: args{
+				client: &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+				cfg:    vault(token),
+			},
+			want: errors.Wrap(errBoom, errGetTokenSecret),
+		},
+		"NoTokenKey": {
+			reason: "We should return an error if the token Secret does not contain the token key.",
+			args: args{
+				client: &test.MockClient{MockGet: test.NewMockGetFn(nil)},
+				cfg:    vault(token),
+			},
+			want: errors.Errorf(errFmtNoTokenKey, "token"),
+		},
+		"Success": {
+			reason: "We should build a Vault store using the token read from the Secret.",
+			args: args{
+				client: &test.MockClient{MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					obj.(*corev1.Secret).Data = map[string][]byte{"token": []byte("s.cool")}
+					return nil
+				})},
+				cfg: vault(token),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s, err := NewVaultStoreBuilder(tc.args.client).Build(context.Background(), tc.args.cfg)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nBuild(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if err == nil && s == nil {
+				t.Errorf("\n%s\nBuild(...): want store, got nil", tc.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package vault stores connection details in a Vault KV secrets engine.
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/pkg/errors"
+
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	"github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
+)
+
+// HeaderToken is the HTTP header used to authenticate to Vault.
+const HeaderToken = "X-Vault-Token"
+
+// KeyOwnerUID is the key of a secret under which the UID of the resource that
+// owns its connection details is stored. It contains a slash, so it can't be
+// the key of a connection detail that could also be published to a Kubernetes
+// Secret. Connection details with this key are rejected.
+const KeyOwnerUID = "crossplane.io/owner-uid"
+
+const (
+	errNoVaultConfig = "StoreConfig does not configure a Vault store"
+	errNewRequest    = "cannot create Vault request"
+	errDoRequest     = "cannot send Vault request"
+	errReadResponse  = "cannot read Vault response"
+	errMarshal       = "cannot marshal Vault request body"
+	errUnmarshal     = "cannot unmarshal Vault response body"
+	errRead          = "cannot read connection details from Vault"
+	errWrite         = "cannot write connection details to Vault"
+	errDelete        = "cannot delete connection details from Vault"
+
+	errFmtStatus      = "%s %s returned HTTP status %d: %s"
+	errFmtNotUTF8     = "value of connection detail %q is not valid UTF-8 and cannot be stored in Vault"
+	errFmtReservedKey = "connection detail key %q is reserved and cannot be stored in Vault"
+	errFmtNotOwner    = "cannot write connection details to %q: they are owned by another resource with UID %q"
+)
+
+// An Option configures a Store.
+type Option func(*Store)
+
+// WithHTTPClient specifies the HTTP client the Store should use to make
+// requests to Vault.
+func WithHTTPClient(c *http.Client) Option {
+	return func(s *Store) {
+		s.client = c
+	}
+}
+
+// A Store stores connection details in a Vault KV secrets engine. Each set of
+// connection details is stored as a single KV secret, with one key per
+// connection detail.
+type Store struct {
+	client  *http.Client
+	server  string
+	mount   string
+	version v1alpha1.VaultKVVersion
+	scope   string
+	token   string
+}
+
+// NewStore returns a Store configured by the supplied StoreConfig, which must
+// be of type Vault. The supplied token is used to authenticate to Vault.
+func NewStore(cfg *v1alpha1.StoreConfig, token string, o ...Option) (*Store, error) {
+	vc := cfg.Spec.Vault
+	if vc == nil {
+		return nil, errors.New(errNoVaultConfig)
+	}
+
+	s := &Store{
+		client:  http.DefaultClient,
+		server:  strings.TrimSuffix(vc.Server, "/"),
+		mount:   vc.MountPath,
+		version: v1alpha1.VaultKVVersionV2,
+		scope:   cfg.Spec.DefaultScope,
+		token:   token,
+	}
+	if vc.Version != nil {
+		s.version = *vc.Version
+	}
+
+	for _, fn := range o {
+		fn(s)
+	}
+	return s, nil
+}
+
+// ReadKeyValues reads the connection details stored under the supplied name.
+// No connection details are returned if none are stored.
+func (s *Store) ReadKeyValues(ctx context.Context, name string) (managed.ConnectionDetails, error) {
+	data, err := s.read(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	delete(data, KeyOwnerUID)
+	c := make(managed.ConnectionDetails, len(data))
+	for k, v := range data {
+		c[k] = []byte(v)
+	}
+	return c, nil
+}
+
+// WriteKeyValues writes the supplied connection details under the supplied
+// name, replacing any connection details already stored there. It returns
+// true if the stored connection details changed. Vault stores each connection
+// detail as a string, so connection details whose values are not valid UTF-8
+// are rejected rather than corrupted, as are those that use the reserved key
+// KeyOwnerUID. The UID of the supplied resource is
+// recorded as the owner of the connection details, and connection details
+// owned by another resource are never replaced. Each supplied function is
+// called with the current and desired connection details before they are
+// replaced.
+func (s *Store) WriteKeyValues(ctx context.Context, so resource.ConnectionSecretOwner, name string, c managed.ConnectionDetails, wo ...func(current, desired managed.ConnectionDetails)) (bool, error) {
+	if _, ok := c[KeyOwnerUID]; ok {
+		return false, errors.Errorf(errFmtReservedKey, KeyOwnerUID)
+	}
+	for k, v := range c {
+		if !utf8.Valid(v) {
+			return false, errors.Errorf(errFmtNotUTF8, k)
+		}
+	}
+	data, err := s.read(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	owner, owned := data[KeyOwnerUID]
+	if owned && owner != string(so.GetUID()) {
+		return false, errors.Errorf(errFmtNotOwner, name, owner)
+	}
+	delete(data, KeyOwnerUID)
+
+	current := make(managed.ConnectionDetails, len(data))
+	for k, v := range data {
+		current[k] = []byte(v)
+	}
+	if owned && cmp.Equal(current, c, cmpopts.EquateEmpty()) {
+		return false, nil
+	}
+	for _, fn := range wo {
+		fn(current, c)
+	}
+
+	data = make(map[string]string, len(c)+1)
+	for k, v := range c {
+		data[k] = string(v)
+	}
+	data[KeyOwnerUID] = string(so.GetUID())
+	var body interface{} = data
+	if s.version == v1alpha1.VaultKVVersionV2 {
+		body = map[string]interface{}{"data": data}
+	}
+	if _, err := s.do(ctx, http.MethodPost, s.dataPath(name), body, nil); err != nil {
+		return false, errors.Wrap(err, errWrite)
+	}
+	return true, nil
+}
+
+// DeleteKeyValues deletes the connection details stored under the supplied
+// name, if the supplied resource owns them. All versions of a KV version 2
+// secret are deleted.
+func (s *Store) DeleteKeyValues(ctx context.Context, so resource.ConnectionSecretOwner, name string) error {
+	data, err := s.read(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	// We never delete connection details that this resource does not own.
+	if owner, ok := data[KeyOwnerUID]; !ok || owner != string(so.GetUID()) {
+		return nil
+	}
+	_, err = s.do(ctx, http.MethodDelete, s.metadataPath(name), nil, nil)
+	return errors.Wrap(err, errDelete)
+}
+
+// read returns the data of the secret with the supplied name, including the
+// UID of its owner. No data is returned if the secret does not exist.
+func (s *Store) read(ctx context.Context, name string) (map[string]string, error) {
+	body := struct {
+		Data json.RawMessage `json:"data"`
+	}{}
+	found, err := s.do(ctx, http.MethodGet, s.dataPath(name), nil, &body)
+	if err != nil {
+		return nil, errors.Wrap(err, errRead)
+	}
+	data := map[string]string{}
+	if !found {
+		return data, nil
+	}
+
+	raw := body.Data
+	// KV version 2 wraps the secret's data in an object that also contains
+	// its metadata.
+	if s.version == v1alpha1.VaultKVVersionV2 {
+		v2 := struct {
+			Data json.RawMessage `json:"data"`
+		}{}
+		if err := json.Unmarshal(raw, &v2); err != nil {
+			return nil, errors.Wrap(errors.Wrap(err, errUnmarshal), errRead)
+		}
+		raw = v2.Data
+	}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, errors.Wrap(errors.Wrap(err, errUnmarshal), errRead)
+	}
+	return data, nil
+}
+
+// dataPath returns the API path at which the secret with the supplied name is
+// read and written.
+func (s *Store) dataPath(name string) string {
+	if s.version == v1alpha1.VaultKVVersionV2 {
+		return path.Join("/v1", s.mount, "data", s.scope, name)
+	}
+	return path.Join("/v1", s.mount, s.scope, name)
+}
+
+// metadataPath returns the API path at which the secret with the supplied name
+// is deleted. Deleting a KV version 2 secret's metadata deletes all of its
+// versions.
+func (s *Store) metadataPath(name string) string {
+	if s.version == v1alpha1.VaultKVVersionV2 {
+		return path.Join("/v1", s.mount, "metadata", s.scope, name)
+	}
+	return path.Join("/v1", s.mount, s.scope, name)
+}
+
+// do sends a request with the supplied body to Vault, and decodes the response
+// into the supplied value. It returns false if Vault returned 404 Not Found.
+func (s *Store) do(ctx context.Context, method, p string, in, out interface{}) (bool, error) {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return false, errors.Wrap(err, errMarshal)
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.server+p, body)
+	if err != nil {
+		return false, errors.Wrap(err, errNewRequest)
+	}
+	req.Header.Set(HeaderToken, s.token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	rsp, err := s.client.Do(req)
+	if err != nil {
+		return false, errors.Wrap(err, errDoRequest)
+	}
+	defer func() { _ = rsp.Body.Close() }()
+
+	b, err := ioutil.ReadAll(rsp.Body)
+	if err != nil {
+		return false, errors.Wrap(err, errReadResponse)
+	}
+
+	if rsp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return false, errors.Errorf(errFmtStatus, method, p, rsp.StatusCode, strings.TrimSpace(string(b)))
+	}
+
+	if out == nil || len(b) == 0 {
+		return true, nil
+	}
+	return true, errors.Wrap(json.Unmarshal(b, out), errUnmarshal)
+}
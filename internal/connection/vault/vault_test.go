@@ -0,0 +1,368 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
+)
+
+const token = "s.cool-token"
+
+var owner = &fake.MockConnectionSecretOwner{ObjectMeta: metav1.ObjectMeta{UID: "cool-uid"}}
+
+// kv is a minimal stand-in for a Vault server with a KV secrets engine
+// mounted at secret. It stores the request body of each write verbatim.
+type kv struct {
+	version v1alpha1.VaultKVVersion
+	secrets map[string]json.RawMessage
+	writes  int
+}
+
+func (s *kv) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get(HeaderToken) != token {
+		http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+		return
+	}
+
+	prefix := "/v1/secret/"
+	if s.version == v1alpha1.VaultKVVersionV2 {
+		prefix = "/v1/secret/data/"
+		if r.Method == http.MethodDelete {
+			prefix = "/v1/secret/metadata/"
+		}
+	}
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+		return
+	}
+	p := strings.TrimPrefix(r.URL.Path, prefix)
+
+	switch r.Method {
+	case http.MethodGet:
+		data, ok := s.secrets[p]
+		if !ok {
+			http.Error(w, `{"errors":[]}`, http.StatusNotFound)
+			return
+		}
+		// Version 2 writes wrap the data in an object, so reads return it
+		// wrapped twice.
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	case http.MethodPost:
+		body := json.RawMessage{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, `{"errors":["invalid body"]}`, http.StatusBadRequest)
+			return
+		}
+		s.secrets[p] = body
+		s.writes++
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		delete(s.secrets, p)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, `{"errors":[]}`, http.StatusMethodNotAllowed)
+	}
+}
+
+func cfg(server string, v v1alpha1.VaultKVVersion) *v1alpha1.StoreConfig {
+	return &v1alpha1.StoreConfig{
+		Spec: v1alpha1.StoreConfigSpec{
+			Type:         v1alpha1.StoreTypeVault,
+			DefaultScope: "crossplane",
+			Vault: &v1alpha1.VaultStoreConfig{
+				Server:    server + "/",
+				MountPath: "secret",
+				Version:   &v,
+			},
+		},
+	}
+}
+
+func TestNewStore(t *testing.T) {
+	_, err := NewStore(&v1alpha1.StoreConfig{}, token)
+	if diff := cmp.Diff(errors.New(errNoVaultConfig), err, test.EquateErrors()); diff != "" {
+		t.Errorf("NewStore(...): -want error, +got error:\n%s", diff)
+	}
+}
+
+func TestWriteKeyValues(t *testing.T) {
+	type args struct {
+		version  v1alpha1.VaultKVVersion
+		token    string
+		existing map[string]json.RawMessage
+		c        managed.ConnectionDetails
+	}
+	type want struct {
+		changed bool
+		writes  int
+		secret  json.RawMessage
+		read    managed.ConnectionDetails
+		current managed.ConnectionDetails
+		err     error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"PermissionDenied": {
+			reason: "We should return an error if Vault returns an unexpected status.",
+			args: args{
+				version: v1alpha1.VaultKVVersionV2,
+				token:   "wrong",
+				c:       managed.ConnectionDetails{"password": []byte("hunter2")},
+			},
+			want: want{
+				err: errors.Wrap(errors.Errorf(errFmtStatus, http.MethodGet, "/v1/secret/data/crossplane/cool", http.StatusForbidden, `{"errors":["permission denied"]}`), errRead),
+			},
+		},
+		"NotUTF8": {
+			reason: "We should return an error rather than write a connection detail that is not valid UTF-8.",
+			args: args{
+				version: v1alpha1.VaultKVVersionV2,
+				token:   token,
+				c:       managed.ConnectionDetails{"password": []byte("hunter2"), "key": {0xff, 0xfe, 0xfd}},
+			},
+			want: want{
+				err: errors.Errorf(errFmtNotUTF8, "key"),
+			},
+		},
+		"CreateV2": {
+			reason: "Connection details should be written to a new KV version 2 secret.",
+			args: args{
+				version: v1alpha1.VaultKVVersionV2,
+				token:   token,
+				c:       managed.ConnectionDetails{"password": []byte("hunter2")},
+			},
+			want: want{
+				changed: true,
+				writes:  1,
+				secret:  json.RawMessage(`{"data":{"crossplane.io/owner-uid":"cool-uid","password":"hunter2"}}`),
+				read:    managed.ConnectionDetails{"password": []byte("hunter2")},
+			},
+		},
+		"CreateV1": {
+			reason: "Connection details should be written to a new KV version 1 secret.",
+			args: args{
+				version: v1alpha1.VaultKVVersionV1,
+				token:   token,
+				c:       managed.ConnectionDetails{"password": []byte("hunter2")},
+			},
+			want: want{
+				changed: true,
+				writes:  1,
+				secret:  json.RawMessage(`{"crossplane.io/owner-uid":"cool-uid","password":"hunter2"}`),
+				read:    managed.ConnectionDetails{"password": []byte("hunter2")},
+			},
+		},
+		"Unchanged": {
+			reason: "Connection details that are already stored should not be written again.",
+			args: args{
+				version:  v1alpha1.VaultKVVersionV2,
+				token:    token,
+				existing: map[string]json.RawMessage{"crossplane/cool": json.RawMessage(`{"data":{"crossplane.io/owner-uid":"cool-uid","password":"hunter2"}}`)},
+				c:        managed.ConnectionDetails{"password": []byte("hunter2")},
+			},
+			want: want{
+				changed: false,
+				writes:  0,
+				secret:  json.RawMessage(`{"data":{"crossplane.io/owner-uid":"cool-uid","password":"hunter2"}}`),
+				read:    managed.ConnectionDetails{"password": []byte("hunter2")},
+			},
+		},
+		"Adopted": {
+			reason: "Connection details that no resource owns should be adopted, even if they are already stored.",
+			args: args{
+				version:  v1alpha1.VaultKVVersionV2,
+				token:    token,
+				existing: map[string]json.RawMessage{"crossplane/cool": json.RawMessage(`{"data":{"password":"hunter2"}}`)},
+				c:        managed.ConnectionDetails{"password": []byte("hunter2")},
+			},
+			want: want{
+				changed: true,
+				writes:  1,
+				secret:  json.RawMessage(`{"data":{"crossplane.io/owner-uid":"cool-uid","password":"hunter2"}}`),
+				read:    managed.ConnectionDetails{"password": []byte("hunter2")},
+				current: managed.ConnectionDetails{"password": []byte("hunter2")},
+			},
+		},
+		"OwnedByAnotherResource": {
+			reason: "We should return an error rather than replace connection details owned by another resource.",
+			args: args{
+				version:  v1alpha1.VaultKVVersionV2,
+				token:    token,
+				existing: map[string]json.RawMessage{"crossplane/cool": json.RawMessage(`{"data":{"crossplane.io/owner-uid":"other-uid","password":"hunter2"}}`)},
+				c:        managed.ConnectionDetails{"password": []byte("correct-horse")},
+			},
+			want: want{
+				secret: json.RawMessage(`{"data":{"crossplane.io/owner-uid":"other-uid","password":"hunter2"}}`),
+				err:    errors.Errorf(errFmtNotOwner, "cool", "other-uid"),
+			},
+		},
+		"ReservedKey": {
+			reason: "We should return an error rather than write a connection detail whose key is reserved.",
+			args: args{
+				version: v1alpha1.VaultKVVersionV2,
+				token:   token,
+				c:       managed.ConnectionDetails{KeyOwnerUID: []byte("other-uid")},
+			},
+			want: want{
+				err: errors.Errorf(errFmtReservedKey, KeyOwnerUID),
+			},
+		},
+		"Changed": {
+			reason: "Connection details should replace those that are already stored.",
+			args: args{
+				version:  v1alpha1.VaultKVVersionV2,
+				token:    token,
+				existing: map[string]json.RawMessage{"crossplane/cool": json.RawMessage(`{"data":{"crossplane.io/owner-uid":"cool-uid","password":"hunter2","user":"admin"}}`)},
+				c:        managed.ConnectionDetails{"password": []byte("correct-horse")},
+			},
+			want: want{
+				changed: true,
+				writes:  1,
+				secret:  json.RawMessage(`{"data":{"crossplane.io/owner-uid":"cool-uid","password":"correct-horse"}}`),
+				read:    managed.ConnectionDetails{"password": []byte("correct-horse")},
+				current: managed.ConnectionDetails{"password": []byte("hunter2"), "user": []byte("admin")},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			server := &kv{version: tc.args.version, secrets: map[string]json.RawMessage{}}
+			for k, v := range tc.args.existing {
+				server.secrets[k] = v
+			}
+			srv := httptest.NewServer(server)
+			defer srv.Close()
+
+			s, err := NewStore(cfg(srv.URL, tc.args.version), tc.args.token, WithHTTPClient(srv.Client()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var current managed.ConnectionDetails
+			changed, err := s.WriteKeyValues(context.Background(), owner, "cool", tc.args.c, func(c, _ managed.ConnectionDetails) {
+				current = c
+			})
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\ns.WriteKeyValues(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.changed, changed); diff != "" {
+				t.Errorf("\n%s\ns.WriteKeyValues(...): -want changed, +got changed:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.current, current, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("\n%s\ns.WriteKeyValues(...): -want current, +got current:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.writes, server.writes); diff != "" {
+				t.Errorf("\n%s\ns.WriteKeyValues(...): -want writes, +got writes:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(string(tc.want.secret), string(server.secrets["crossplane/cool"])); diff != "" {
+				t.Errorf("\n%s\ns.WriteKeyValues(...): -want secret, +got secret:\n%s", tc.reason, diff)
+			}
+			if tc.want.err != nil {
+				return
+			}
+
+			read, err := s.ReadKeyValues(context.Background(), "cool")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want.read, read); diff != "" {
+				t.Errorf("\n%s\ns.ReadKeyValues(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestDeleteKeyValues(t *testing.T) {
+	cases := map[string]struct {
+		reason   string
+		version  v1alpha1.VaultKVVersion
+		existing json.RawMessage
+		deleted  bool
+	}{
+		"DeleteV2": {
+			reason:   "All versions of a KV version 2 secret should be deleted.",
+			version:  v1alpha1.VaultKVVersionV2,
+			existing: json.RawMessage(`{"data":{"crossplane.io/owner-uid":"cool-uid","password":"hunter2"}}`),
+			deleted:  true,
+		},
+		"DeleteV1": {
+			reason:   "A KV version 1 secret should be deleted.",
+			version:  v1alpha1.VaultKVVersionV1,
+			existing: json.RawMessage(`{"crossplane.io/owner-uid":"cool-uid","password":"hunter2"}`),
+			deleted:  true,
+		},
+		"NotFound": {
+			reason:  "It should not be an error to delete a secret that does not exist.",
+			version: v1alpha1.VaultKVVersionV2,
+		},
+		"OwnedByAnotherResource": {
+			reason:   "A secret owned by another resource should not be deleted.",
+			version:  v1alpha1.VaultKVVersionV2,
+			existing: json.RawMessage(`{"data":{"crossplane.io/owner-uid":"other-uid","password":"hunter2"}}`),
+		},
+		"NotOwned": {
+			reason:   "A secret that no resource owns should not be deleted.",
+			version:  v1alpha1.VaultKVVersionV2,
+			existing: json.RawMessage(`{"data":{"password":"hunter2"}}`),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			server := &kv{version: tc.version, secrets: map[string]json.RawMessage{}}
+			if tc.existing != nil {
+				server.secrets["crossplane/cool"] = tc.existing
+			}
+			srv := httptest.NewServer(server)
+			defer srv.Close()
+
+			s, err := NewStore(cfg(srv.URL, tc.version), token, WithHTTPClient(srv.Client()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := s.DeleteKeyValues(context.Background(), owner, "cool"); err != nil {
+				t.Errorf("\n%s\ns.DeleteKeyValues(...): %s", tc.reason, err)
+			}
+			_, exists := server.secrets["crossplane/cool"]
+			if diff := cmp.Diff(tc.deleted, tc.existing != nil && !exists); diff != "" {
+				t.Errorf("\n%s\ns.DeleteKeyValues(...): -want deleted, +got deleted:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
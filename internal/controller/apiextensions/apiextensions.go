@@ -23,6 +23,7 @@ import (
 
 	"github.com/crossplane/crossplane/internal/controller/apiextensions/definition"
 	"github.com/crossplane/crossplane/internal/controller/apiextensions/offered"
+	"github.com/crossplane/crossplane/internal/controller/apiextensions/revision"
 )
 
 // Setup API extensions controllers.
@@ -30,6 +31,7 @@ func Setup(mgr ctrl.Manager, l logging.Logger) error {
 	for _, setup := range []func(ctrl.Manager, logging.Logger) error{
 		definition.Setup,
 		offered.Setup,
+		revision.Setup,
 	} {
 		if err := setup(mgr, l); err != nil {
 			return err
@@ -18,6 +18,7 @@ package claim
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -30,6 +31,8 @@ import (
 
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	"github.com/crossplane/crossplane/internal/connection"
 )
 
 // Error strings.
@@ -90,17 +93,29 @@ func (a *APIBinder) Bind(ctx context.Context, cm resource.CompositeClaim, cp res
 type APIConnectionPropagator struct {
 	client resource.ClientApplicator
 	typer  runtime.ObjectTyper
+	filter []string
 }
 
-// NewAPIConnectionPropagator returns a new APIConnectionPropagator.
+// NewAPIConnectionPropagator returns a new APIConnectionPropagator that
+// propagates all connection secret keys.
 func NewAPIConnectionPropagator(c client.Client, t runtime.ObjectTyper) *APIConnectionPropagator {
+	return NewAPIFilteredConnectionPropagator(c, t, []string{connection.AllKeys})
+}
+
+// NewAPIFilteredConnectionPropagator returns a new APIConnectionPropagator
+// that only propagates connection secret keys that match the supplied filter
+// patterns.
+func NewAPIFilteredConnectionPropagator(c client.Client, t runtime.ObjectTyper, filter []string) *APIConnectionPropagator {
 	return &APIConnectionPropagator{
 		client: resource.ClientApplicator{Client: c, Applicator: resource.NewAPIUpdatingApplicator(c)},
 		typer:  t,
+		filter: filter,
 	}
 }
 
-// PropagateConnection details from the supplied resource.
+// PropagateConnection details from the supplied resource. The time at which
+// the connection details of the claim were last rotated is recorded if
+// propagation changed or removed any existing key.
 func (a *APIConnectionPropagator) PropagateConnection(ctx context.Context, to resource.LocalConnectionSecretOwner, from resource.ConnectionSecretOwner) (bool, error) {
 	// Either from does not expose a connection secret, or to does not want one.
 	if from.GetWriteConnectionSecretToReference() == nil || to.GetWriteConnectionSecretToReference() == nil {
@@ -123,15 +138,27 @@ func (a *APIConnectionPropagator) PropagateConnection(ctx context.Context, to re
 		return false, errors.New(errSecretConflict)
 	}
 
+	// The claim's connection secret mirrors the composite resource's; keys
+	// that were removed from the latter are removed from the former.
 	ts := resource.LocalConnectionSecretFor(to, resource.MustGetKind(to, a.typer))
-	ts.Data = fs.Data
+	ts.Data = connection.FilterKeys(a.filter, fs.Data)
+	connection.SetSecretMetadata(ts, connection.GetSecretMetadata(to))
 
+	rotated := false
 	err := a.client.Apply(ctx, ts,
 		resource.ConnectionSecretMustBeControllableBy(to.GetUID()),
 		resource.AllowUpdateIf(func(current, desired runtime.Object) bool {
+			cs, ds := current.(*corev1.Secret), desired.(*corev1.Secret)
+			rotated = connection.Rotated(cs.Data, ds.Data)
+
 			// We consider the update to be a no-op and don't allow it if the
-			// current and existing secret data are identical.
-			return !cmp.Equal(current.(*corev1.Secret).Data, desired.(*corev1.Secret).Data, cmpopts.EquateEmpty())
+			// current secret already has the desired data and metadata.
+			changed := connection.SecretChanged(cs, ds)
+
+			// We update the current secret rather than replacing it, so
+			// that we don't remove metadata added by other tools.
+			connection.MergeSecret(cs, ds)
+			return changed
 		}),
 	)
 	if resource.IsNotAllowed(err) {
@@ -142,5 +169,8 @@ func (a *APIConnectionPropagator) PropagateConnection(ctx context.Context, to re
 		return false, errors.Wrap(err, errCreateOrUpdateSecret)
 	}
 
+	if rotated {
+		connection.SetLastRotatedTime(to, &metav1.Time{Time: time.Now()})
+	}
 	return true, nil
 }
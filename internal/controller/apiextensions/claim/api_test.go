@@ -25,12 +25,16 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/claim"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	"github.com/crossplane/crossplane/internal/connection"
 )
 
 var (
@@ -204,9 +208,25 @@ func TestPropagateConnection(t *testing.T) {
 		},
 	}
 
+	ucm := claim.New(claim.WithGroupVersionKind(schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "CoolClaim"}))
+	ucm.SetNamespace(cmcsns)
+	ucm.SetUID("cool-uid")
+	ucm.SetWriteConnectionSecretToReference(&xpv1.LocalSecretReference{Name: cmcsname})
+
+	mducm := claim.New(claim.WithGroupVersionKind(schema.GroupVersionKind{Group: "example.org", Version: "v1", Kind: "CoolClaim"}))
+	mducm.SetNamespace(cmcsns)
+	mducm.SetUID("cool-uid")
+	mducm.SetWriteConnectionSecretToReference(&xpv1.LocalSecretReference{Name: cmcsname})
+	mducm.Object["spec"].(map[string]interface{})["connectionSecretMetadata"] = map[string]interface{}{
+		"labels":      map[string]interface{}{"team": "platform"},
+		"annotations": map[string]interface{}{"owner": "dba"},
+		"type":        "Opaque",
+	}
+
 	type fields struct {
 		client resource.ClientApplicator
 		typer  runtime.ObjectTyper
+		filter []string
 	}
 
 	type args struct {
@@ -216,6 +236,7 @@ func TestPropagateConnection(t *testing.T) {
 	}
 	type want struct {
 		propagated bool
+		rotated    bool
 		err        error
 	}
 
@@ -320,7 +341,8 @@ func TestPropagateConnection(t *testing.T) {
 						return resource.AllowUpdateIf(func(_, _ runtime.Object) bool { return false })(ctx, o, o)
 					}),
 				},
-				typer: fake.SchemeWith(cp, cm),
+				typer:  fake.SchemeWith(cp, cm),
+				filter: []string{connection.AllKeys},
 			},
 			args: args{
 				to:   cm,
@@ -358,7 +380,8 @@ func TestPropagateConnection(t *testing.T) {
 						return nil
 					}),
 				},
-				typer: fake.SchemeWith(cp, cm),
+				typer:  fake.SchemeWith(cp, cm),
+				filter: []string{connection.AllKeys},
 			},
 			args: args{
 				to:   cm,
@@ -368,11 +391,123 @@ func TestPropagateConnection(t *testing.T) {
 				propagated: true,
 			},
 		},
+		"SuccessfulPublishFiltered": {
+			reason: "Only connection secret keys that match the filter patterns should be propagated to the claim secret",
+			fields: fields{
+				client: resource.ClientApplicator{
+					Client: &test.MockClient{
+						MockGet: test.NewMockGetFn(nil, func(o client.Object) error {
+							s := resource.ConnectionSecretFor(cp, fake.GVK(cp))
+							s.Data = map[string][]byte{"cool": {1}, "kubeconfig": {2}, "kubeconfig-admin": {3}}
+
+							*o.(*corev1.Secret) = *s
+							return nil
+						}),
+					},
+					Applicator: resource.ApplyFn(func(_ context.Context, o client.Object, _ ...resource.ApplyOption) error {
+						want := resource.LocalConnectionSecretFor(cm, fake.GVK(cm))
+						want.Data = map[string][]byte{"kubeconfig": {2}, "kubeconfig-admin": {3}}
+						if diff := cmp.Diff(want, o); diff != "" {
+							t.Errorf("-want, +got: %s", diff)
+						}
+
+						return nil
+					}),
+				},
+				typer:  fake.SchemeWith(cp, cm),
+				filter: []string{"kubeconfig*"},
+			},
+			args: args{
+				to:   cm,
+				from: cp,
+			},
+			want: want{
+				propagated: true,
+			},
+		},
+		"SuccessfulPropagateMetadata": {
+			reason: "The claim's connection secret metadata should be applied to the claim secret",
+			fields: fields{
+				client: resource.ClientApplicator{
+					Client: &test.MockClient{
+						MockGet: test.NewMockGetFn(nil, func(o client.Object) error {
+							s := resource.ConnectionSecretFor(cp, fake.GVK(cp))
+							s.Data = mgcsdata
+
+							*o.(*corev1.Secret) = *s
+							return nil
+						}),
+					},
+					Applicator: resource.ApplyFn(func(_ context.Context, o client.Object, _ ...resource.ApplyOption) error {
+						want := resource.LocalConnectionSecretFor(mducm, mducm.GetObjectKind().GroupVersionKind())
+						want.SetLabels(map[string]string{"team": "platform"})
+						want.SetAnnotations(map[string]string{"owner": "dba"})
+						want.Type = corev1.SecretTypeOpaque
+						want.Data = mgcsdata
+						if diff := cmp.Diff(want, o); diff != "" {
+							t.Errorf("-want, +got: %s", diff)
+						}
+						return nil
+					}),
+				},
+				typer:  runtime.NewScheme(),
+				filter: []string{connection.AllKeys},
+			},
+			args: args{
+				to:   mducm,
+				from: cp,
+			},
+			want: want{
+				propagated: true,
+			},
+		},
+		"SuccessfulRotation": {
+			reason: "Keys removed from the composite resource's secret should be removed from the claim secret, and the rotation recorded",
+			fields: fields{
+				client: resource.ClientApplicator{
+					Client: &test.MockClient{
+						MockGet: test.NewMockGetFn(nil, func(o client.Object) error {
+							s := resource.ConnectionSecretFor(cp, fake.GVK(cp))
+							s.Data = mgcsdata
+
+							*o.(*corev1.Secret) = *s
+							return nil
+						}),
+					},
+					Applicator: resource.ApplyFn(func(ctx context.Context, o client.Object, ao ...resource.ApplyOption) error {
+						current := resource.LocalConnectionSecretFor(ucm, ucm.GetObjectKind().GroupVersionKind())
+						current.Data = map[string][]byte{"cool": {1}, "stale": {2}}
+						for _, fn := range ao {
+							if err := fn(ctx, current, o); err != nil {
+								return err
+							}
+						}
+
+						want := resource.LocalConnectionSecretFor(ucm, ucm.GetObjectKind().GroupVersionKind())
+						want.Data = mgcsdata
+						if diff := cmp.Diff(want, o); diff != "" {
+							t.Errorf("-want, +got: %s", diff)
+						}
+						return nil
+					}),
+				},
+				typer:  runtime.NewScheme(),
+				filter: []string{connection.AllKeys},
+			},
+			args: args{
+				to:   ucm,
+				from: cp,
+			},
+			want: want{
+				propagated: true,
+				rotated:    true,
+			},
+		},
 	}
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			api := &APIConnectionPropagator{client: tc.fields.client, typer: tc.fields.typer}
+			api := &APIConnectionPropagator{client: tc.fields.client, typer: tc.fields.typer, filter: tc.fields.filter}
 			got, err := api.PropagateConnection(tc.args.ctx, tc.args.to, tc.args.from)
 			if diff := cmp.Diff(tc.want.propagated, got); diff != "" {
 				t.Errorf("\n%s\napi.PropagateConnection(...): -want, +got:\n%s", tc.reason, diff)
@@ -380,6 +515,9 @@ func TestPropagateConnection(t *testing.T) {
 			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
 				t.Errorf("\n%s\napi.PropagateConnection(...): -want error, +got error:\n%s", tc.reason, diff)
 			}
+			if diff := cmp.Diff(tc.want.rotated, connection.GetLastRotatedTime(tc.args.to) != nil); diff != "" {
+				t.Errorf("\n%s\napi.PropagateConnection(...): -want rotated, +got rotated:\n%s", tc.reason, diff)
+			}
 		})
 	}
 }
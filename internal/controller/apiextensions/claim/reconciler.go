@@ -38,6 +38,8 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/claim"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+
+	"github.com/crossplane/crossplane/internal/connection"
 )
 
 const (
@@ -65,6 +67,7 @@ const (
 	reasonCompositeConfigure event.Reason = "ConfigureCompositeResource"
 	reasonClaimConfigure     event.Reason = "ConfigureClaim"
 	reasonPropagate          event.Reason = "PropagateConnectionSecret"
+	reasonRotate             event.Reason = "RotateConnectionSecret"
 )
 
 // ControllerName returns the recommended name for controllers that use this
@@ -415,6 +418,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	log.Debug("Successfully bound composite resource")
 	record.Event(cm, event.Normal(reasonBind, "Successfully bound composite resource"))
 
+	rotated := connection.GetLastRotatedTime(cm)
 	propagated, err := r.composite.PropagateConnection(ctx, cm, cp)
 	if err != nil {
 		// If we didn't hit this error last time we'll be requeued implicitly
@@ -431,6 +435,12 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		log.Debug("Successfully propagated connection details from composite resource")
 		record.Event(cm, event.Normal(reasonPropagate, "Successfully propagated connection details from composite resource"))
 	}
+	if t := connection.GetLastRotatedTime(cm); t != nil && !t.Equal(rotated) {
+		// Consumers may watch for this event in order to restart when the
+		// connection details they use change.
+		log.Debug("Connection details were rotated")
+		record.Event(cm, event.Normal(reasonRotate, "Connection details were rotated"))
+	}
 
 	// We have a watch on both the claim and its composite, so there's no
 	// need to requeue here.
@@ -19,36 +19,74 @@ package composite
 import (
 	"context"
 	"math/rand"
+	"sort"
 	"time"
 
-	"github.com/google/go-cmp/cmp"
-	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kunstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/json"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
 	"github.com/crossplane/crossplane-runtime/pkg/meta"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
 
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
+	"github.com/crossplane/crossplane/internal/connection"
 	"github.com/crossplane/crossplane/internal/xcrd"
 )
 
 // Error strings.
 const (
-	errApplySecret = "cannot apply connection secret"
+	errGetConnectionSecret = "cannot get connection secret"
+	errApplySecret         = "cannot apply connection secret"
+	errDeleteSecret        = "cannot delete connection secret"
+
+	errFmtGetStoreConfig = "cannot get StoreConfig %q"
+	errFmtBuildStore     = "cannot build store configured by StoreConfig %q"
+	errFmtWriteStore     = "cannot write connection details to store configured by StoreConfig %q"
+	errFmtDeleteStore    = "cannot delete connection details from store configured by StoreConfig %q"
 
 	errNoCompatibleComposition  = "no compatible composition has been found"
 	errListCompositions         = "cannot list compositions"
 	errUpdateComposite          = "cannot update composite resource"
 	errCompositionNotCompatible = "referenced composition is not compatible with this composite resource"
 	errGetXRD                   = "cannot get composite resource definition"
+	errListEnvironmentConfigs   = "cannot list EnvironmentConfigs"
+	errListComposites           = "cannot list composite resources"
+	errUnmarshalEnvironment     = "cannot unmarshal EnvironmentConfig data"
+	errMarshalEnvironment       = "cannot marshal environment data"
+	errListRevisions            = "cannot list CompositionRevisions"
+
+	errFmtGetEnvironmentConfig     = "cannot get EnvironmentConfig %q"
+	errFmtEnvironmentSource        = "invalid environment source at index %d"
+	errFmtEnvironmentSourceMissing = "%s is required by environment source type %s"
+	errFmtEnvironmentSourceType    = "environment source type %s is not supported"
 )
 
+// defaultStoreConfig is the StoreConfig composite resources publish connection
+// details to if they don't reference one.
+const defaultStoreConfig = "default"
+
+// environmentTimeout bounds how long it may take to determine which composite
+// resources should be enqueued when an EnvironmentConfig changes.
+const environmentTimeout = 30 * time.Second
+
 // Event reasons.
 const (
 	reasonCompositionSelection event.Reason = "CompositionSelection"
@@ -57,18 +95,24 @@ const (
 // APIFilteredSecretPublisher publishes ConnectionDetails content after filtering
 // it through a set of permitted keys.
 type APIFilteredSecretPublisher struct {
-	client resource.Applicator
+	client resource.ClientApplicator
 	filter []string
 }
 
 // NewAPIFilteredSecretPublisher returns a ConnectionPublisher that only
-// publishes connection secret keys that are included in the supplied filter.
+// publishes connection secret keys that match the supplied filter patterns.
 func NewAPIFilteredSecretPublisher(c client.Client, filter []string) *APIFilteredSecretPublisher {
-	return &APIFilteredSecretPublisher{client: resource.NewAPIPatchingApplicator(c), filter: filter}
+	return &APIFilteredSecretPublisher{
+		client: resource.ClientApplicator{Client: c, Applicator: resource.NewAPIUpdatingApplicator(c)},
+		filter: filter,
+	}
 }
 
 // PublishConnection publishes the supplied ConnectionDetails to the Secret
-// referenced in the resource.
+// referenced in the resource. Any keys of the Secret that are not included in
+// the supplied ConnectionDetails are removed. The time at which the resource's
+// connection details were last rotated is recorded if publishing changed or
+// removed any existing key.
 func (a *APIFilteredSecretPublisher) PublishConnection(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) (bool, error) {
 	// This resource does not want to expose a connection secret.
 	if o.GetWriteConnectionSecretToReference() == nil {
@@ -76,23 +120,26 @@ func (a *APIFilteredSecretPublisher) PublishConnection(ctx context.Context, o re
 	}
 
 	s := resource.ConnectionSecretFor(o, o.GetObjectKind().GroupVersionKind())
-	m := map[string]bool{}
-	// TODO(muvaf): Should empty filter allow all keys?
-	for _, key := range a.filter {
-		m[key] = true
-	}
-	for key, val := range c {
-		if _, ok := m[key]; ok {
-			s.Data[key] = val
-		}
+	for key, val := range connection.FilterKeys(a.filter, c) {
+		s.Data[key] = val
 	}
+	connection.SetSecretMetadata(s, connection.GetSecretMetadata(o))
 
+	rotated := false
 	err := a.client.Apply(ctx, s,
 		resource.ConnectionSecretMustBeControllableBy(o.GetUID()),
 		resource.AllowUpdateIf(func(current, desired runtime.Object) bool {
+			cs, ds := current.(*corev1.Secret), desired.(*corev1.Secret)
+			rotated = connection.Rotated(cs.Data, ds.Data)
+
 			// We consider the update to be a no-op and don't allow it if the
-			// current and existing secret data are identical.
-			return !cmp.Equal(current.(*corev1.Secret).Data, desired.(*corev1.Secret).Data, cmpopts.EquateEmpty())
+			// current secret already has the desired data and metadata.
+			changed := connection.SecretChanged(cs, ds)
+
+			// We update the current secret rather than replacing it, so
+			// that we don't remove metadata added by other tools.
+			connection.MergeSecret(cs, ds)
+			return changed
 		}),
 	)
 	if resource.IsNotAllowed(err) {
@@ -103,13 +150,158 @@ func (a *APIFilteredSecretPublisher) PublishConnection(ctx context.Context, o re
 		return false, errors.Wrap(err, errApplySecret)
 	}
 
+	if rotated {
+		connection.SetLastRotatedTime(o, &metav1.Time{Time: time.Now()})
+	}
 	return true, nil
 }
 
-// UnpublishConnection is no-op since PublishConnection only creates resources
-// that will be garbage collected by Kubernetes when the managed resource is
-// deleted.
-func (a *APIFilteredSecretPublisher) UnpublishConnection(_ context.Context, _ resource.ConnectionSecretOwner, _ managed.ConnectionDetails) error {
+// UnpublishConnection deletes the Secret referenced in the resource, if the
+// resource controls it. Kubernetes would also garbage collect the Secret once
+// the resource is deleted, but we delete it explicitly so that consumers don't
+// keep using connection details of a resource that is being deleted.
+func (a *APIFilteredSecretPublisher) UnpublishConnection(ctx context.Context, o resource.ConnectionSecretOwner, _ managed.ConnectionDetails) error {
+	ref := o.GetWriteConnectionSecretToReference()
+
+	// This resource does not expose a connection secret.
+	if ref == nil {
+		return nil
+	}
+
+	s := &corev1.Secret{}
+	err := a.client.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, s)
+	if kerrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, errGetConnectionSecret)
+	}
+
+	// We never delete a Secret that this resource does not control.
+	if c := metav1.GetControllerOf(s); c == nil || c.UID != o.GetUID() {
+		return nil
+	}
+	return errors.Wrap(resource.IgnoreNotFound(a.client.Delete(ctx, s)), errDeleteSecret)
+}
+
+// An APIStorePublisher publishes connection details to the store configured by
+// the StoreConfig a composite resource references in its
+// spec.publishConnectionDetailsTo field.
+type APIStorePublisher struct {
+	client client.Reader
+	stores connection.StoreBuilder
+	filter []string
+}
+
+// NewAPIStorePublisher returns a ConnectionPublisher that only publishes
+// connection secret keys that match the supplied filter patterns.
+func NewAPIStorePublisher(c client.Client, filter []string) *APIStorePublisher {
+	return &APIStorePublisher{client: c, stores: connection.NewStoreBuilders(c), filter: filter}
+}
+
+// PublishConnection publishes the supplied ConnectionDetails to the store
+// configured by the StoreConfig the resource references. It records the time
+// at which the connection details were rotated if publishing changed or
+// removed any connection details already in the store.
+func (a *APIStorePublisher) PublishConnection(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) (bool, error) {
+	to := GetPublishConnectionDetailsTo(o)
+
+	// This resource does not want to publish connection details to a store.
+	if to == nil {
+		return false, nil
+	}
+
+	name := defaultStoreConfig
+	if to.ConfigRef != nil && to.ConfigRef.Name != "" {
+		name = to.ConfigRef.Name
+	}
+	cfg := &v1alpha1.StoreConfig{}
+	if err := a.client.Get(ctx, types.NamespacedName{Name: name}, cfg); err != nil {
+		return false, errors.Wrapf(err, errFmtGetStoreConfig, name)
+	}
+
+	s, err := a.stores.Build(ctx, cfg)
+	if err != nil {
+		return false, errors.Wrapf(err, errFmtBuildStore, name)
+	}
+
+	rotated := false
+	published, err := s.WriteKeyValues(ctx, o, to.Name, connection.FilterKeys(a.filter, c), func(current, desired managed.ConnectionDetails) {
+		rotated = connection.Rotated(current, desired)
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, errFmtWriteStore, name)
+	}
+
+	if published && rotated {
+		connection.SetLastRotatedTime(o, &metav1.Time{Time: time.Now()})
+	}
+	return published, nil
+}
+
+// UnpublishConnection deletes the connection details of the resource from the
+// store configured by the StoreConfig the resource references. There is
+// nothing to delete if the StoreConfig no longer exists.
+func (a *APIStorePublisher) UnpublishConnection(ctx context.Context, o resource.ConnectionSecretOwner, _ managed.ConnectionDetails) error {
+	to := GetPublishConnectionDetailsTo(o)
+
+	// This resource does not publish connection details to a store.
+	if to == nil {
+		return nil
+	}
+
+	name := defaultStoreConfig
+	if to.ConfigRef != nil && to.ConfigRef.Name != "" {
+		name = to.ConfigRef.Name
+	}
+	cfg := &v1alpha1.StoreConfig{}
+	err := a.client.Get(ctx, types.NamespacedName{Name: name}, cfg)
+	if kerrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, errFmtGetStoreConfig, name)
+	}
+
+	s, err := a.stores.Build(ctx, cfg)
+	if err != nil {
+		return errors.Wrapf(err, errFmtBuildStore, name)
+	}
+
+	return errors.Wrapf(s.DeleteKeyValues(ctx, o, to.Name), errFmtDeleteStore, name)
+}
+
+// A ConnectionPublisherChain publishes connection details using each of its
+// ConnectionPublishers in turn.
+type ConnectionPublisherChain []ConnectionPublisher
+
+// PublishConnection publishes the supplied ConnectionDetails using each
+// ConnectionPublisher in the chain. It returns true if any ConnectionPublisher
+// published connection details.
+func (pc ConnectionPublisherChain) PublishConnection(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) (bool, error) {
+	published := false
+	for _, p := range pc {
+		pub, err := p.PublishConnection(ctx, o, c)
+		if err != nil {
+			return published, err
+		}
+		published = published || pub
+	}
+	return published, nil
+}
+
+// UnpublishConnection unpublishes the supplied ConnectionDetails using each
+// ConnectionPublisher in the chain that is also a ConnectionUnpublisher.
+func (pc ConnectionPublisherChain) UnpublishConnection(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) error {
+	for _, p := range pc {
+		u, ok := p.(ConnectionUnpublisher)
+		if !ok {
+			continue
+		}
+		if err := u.UnpublishConnection(ctx, o, c); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -186,6 +378,63 @@ func (r *APILabelSelectorResolver) SelectComposition(ctx context.Context, cp res
 	return errors.Wrap(r.client.Update(ctx, cp), errUpdateComposite)
 }
 
+// NewAPIRevisionSelector returns an APIRevisionSelector.
+func NewAPIRevisionSelector(c client.Client) *APIRevisionSelector {
+	return &APIRevisionSelector{client: c}
+}
+
+// An APIRevisionSelector selects the revision of its Composition that a
+// composite resource should be composed from, according to its Composition
+// update policy.
+type APIRevisionSelector struct {
+	client client.Client
+}
+
+// SelectComposition selects the latest revision of the composite resource's
+// Composition, unless its update policy is Manual and it already references an
+// existing revision of that Composition. It is a no-op if the Composition has
+// no revisions.
+func (s *APIRevisionSelector) SelectComposition(ctx context.Context, cp resource.Composite) error {
+	ref := cp.GetCompositionReference()
+	if ref == nil {
+		return nil
+	}
+	current := GetCompositionRevisionReference(cp)
+	if current != nil && GetCompositionUpdatePolicy(cp) == CompositionUpdateManual {
+		rev := &v1alpha1.CompositionRevision{}
+		err := s.client.Get(ctx, types.NamespacedName{Name: current.Name}, rev)
+		if resource.IgnoreNotFound(err) != nil {
+			return errors.Wrap(err, errGetRev)
+		}
+
+		// A composite resource with a Manual update policy stays pinned to
+		// its revision, unless that revision no longer exists or belongs to
+		// a Composition other than the one it now references. In either
+		// case we treat it as though it referenced no revision.
+		if err == nil && rev.GetLabels()[v1alpha1.LabelCompositionName] == ref.Name {
+			return nil
+		}
+		current = nil
+	}
+
+	rl := &v1alpha1.CompositionRevisionList{}
+	if err := s.client.List(ctx, rl, client.MatchingLabels{v1alpha1.LabelCompositionName: ref.Name}); err != nil {
+		return errors.Wrap(err, errListRevisions)
+	}
+	var latest *v1alpha1.CompositionRevision
+	for i := range rl.Items {
+		if latest == nil || rl.Items[i].Spec.Revision > latest.Spec.Revision {
+			latest = &rl.Items[i]
+		}
+	}
+	if latest == nil || (current != nil && current.Name == latest.GetName()) {
+		return nil
+	}
+
+	SetCompositionRevisionReference(cp, &corev1.LocalObjectReference{Name: latest.GetName()})
+	return errors.Wrap(s.client.Update(ctx, cp), errUpdateComposite)
+}
+
 // NewAPIDefaultCompositionSelector returns a APIDefaultCompositionSelector.
 func NewAPIDefaultCompositionSelector(c client.Client, ref corev1.ObjectReference, r event.Recorder) *APIDefaultCompositionSelector {
 	return &APIDefaultCompositionSelector{client: c, defRef: ref, recorder: r}
@@ -320,3 +569,152 @@ func (c *APINamingConfigurator) Configure(ctx context.Context, cp resource.Compo
 	meta.AddLabels(cp, map[string]string{xcrd.LabelKeyNamePrefixForComposed: cp.GetName()})
 	return errors.Wrap(c.client.Update(ctx, cp), errUpdateComposite)
 }
+
+// NewAPIEnvironmentFetcher returns an EnvironmentFetcher that fetches the
+// EnvironmentConfigs selected by a composition.
+func NewAPIEnvironmentFetcher(c client.Reader) *APIEnvironmentFetcher {
+	return &APIEnvironmentFetcher{client: c}
+}
+
+// An APIEnvironmentFetcher fetches the EnvironmentConfigs selected by a
+// composition and merges them into a single environment.
+type APIEnvironmentFetcher struct {
+	client client.Reader
+}
+
+// FetchEnvironment of the supplied composite resource. The returned
+// environment is an EnvironmentConfig whose data is the deep merge of the data
+// of all EnvironmentConfigs selected by the supplied composition, in order. An
+// empty environment is returned if the composition selects no
+// EnvironmentConfigs.
+func (f *APIEnvironmentFetcher) FetchEnvironment(ctx context.Context, _ resource.Composite, comp *v1.Composition) (*v1alpha1.EnvironmentConfig, error) {
+	env := &v1alpha1.EnvironmentConfig{}
+	if comp.Spec.Environment == nil {
+		return env, nil
+	}
+
+	merged := map[string]interface{}{}
+	for i, src := range comp.Spec.Environment.EnvironmentConfigs {
+		ecs, err := f.selected(ctx, src)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtEnvironmentSource, i)
+		}
+		for _, ec := range ecs {
+			data := map[string]interface{}{}
+			for k, v := range ec.Data {
+				var val interface{}
+				if err := json.Unmarshal(v.Raw, &val); err != nil {
+					return nil, errors.Wrap(err, errUnmarshalEnvironment)
+				}
+				data[k] = val
+			}
+			merged = (&v1.MergeOptions{}).Merge(merged, data).(map[string]interface{})
+		}
+	}
+
+	env.Data = make(map[string]extv1.JSON, len(merged))
+	for k, v := range merged {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, errors.Wrap(err, errMarshalEnvironment)
+		}
+		env.Data[k] = extv1.JSON{Raw: raw}
+	}
+	return env, nil
+}
+
+// selected returns the EnvironmentConfigs selected by the supplied source.
+func (f *APIEnvironmentFetcher) selected(ctx context.Context, src v1.EnvironmentSource) ([]v1alpha1.EnvironmentConfig, error) {
+	t := src.Type
+	if t == "" {
+		t = v1.EnvironmentSourceTypeReference
+	}
+
+	switch t {
+	case v1.EnvironmentSourceTypeReference:
+		if src.Ref == nil {
+			return nil, errors.Errorf(errFmtEnvironmentSourceMissing, "ref", t)
+		}
+		ec := &v1alpha1.EnvironmentConfig{}
+		if err := f.client.Get(ctx, types.NamespacedName{Name: src.Ref.Name}, ec); err != nil {
+			return nil, errors.Wrapf(err, errFmtGetEnvironmentConfig, src.Ref.Name)
+		}
+		return []v1alpha1.EnvironmentConfig{*ec}, nil
+	case v1.EnvironmentSourceTypeSelector:
+		if src.Selector == nil {
+			return nil, errors.Errorf(errFmtEnvironmentSourceMissing, "selector", t)
+		}
+		sel, err := metav1.LabelSelectorAsSelector(src.Selector)
+		if err != nil {
+			return nil, errors.Wrap(err, errListEnvironmentConfigs)
+		}
+		l := &v1alpha1.EnvironmentConfigList{}
+		if err := f.client.List(ctx, l, client.MatchingLabelsSelector{Selector: sel}); err != nil {
+			return nil, errors.Wrap(err, errListEnvironmentConfigs)
+		}
+		sort.Slice(l.Items, func(i, j int) bool { return l.Items[i].GetName() < l.Items[j].GetName() })
+		return l.Items, nil
+	default:
+		return nil, errors.Errorf(errFmtEnvironmentSourceType, t)
+	}
+}
+
+// EnqueueForEnvironmentConfig returns an event handler that enqueues a request
+// for each composite resource of the supplied kind whose composition selects
+// the EnvironmentConfig that triggered the event.
+func EnqueueForEnvironmentConfig(c client.Reader, of resource.CompositeKind, log logging.Logger) handler.EventHandler {
+	return handler.EnqueueRequestsFromMapFunc(func(o client.Object) []reconcile.Request {
+		ctx, cancel := context.WithTimeout(context.Background(), environmentTimeout)
+		defer cancel()
+
+		cl := &v1.CompositionList{}
+		if err := c.List(ctx, cl); err != nil {
+			log.Debug(errListCompositions, "error", err)
+			return nil
+		}
+		comps := map[string]bool{}
+		for i := range cl.Items {
+			if SelectsEnvironmentConfig(&cl.Items[i], o) {
+				comps[cl.Items[i].GetName()] = true
+			}
+		}
+		if len(comps) == 0 {
+			return nil
+		}
+
+		l := &kunstructured.UnstructuredList{}
+		l.SetGroupVersionKind(schema.GroupVersionKind(of).GroupVersion().WithKind(of.Kind + "List"))
+		if err := c.List(ctx, l); err != nil {
+			log.Debug(errListComposites, "error", err)
+			return nil
+		}
+		reqs := make([]reconcile.Request, 0, len(l.Items))
+		for i := range l.Items {
+			cp := composite.Unstructured{Unstructured: l.Items[i]}
+			if ref := cp.GetCompositionReference(); ref != nil && comps[ref.Name] {
+				reqs = append(reqs, reconcile.Request{NamespacedName: types.NamespacedName{Name: cp.GetName()}})
+			}
+		}
+		return reqs
+	})
+}
+
+// SelectsEnvironmentConfig returns true if the supplied composition selects
+// the supplied EnvironmentConfig, either by reference or by label selector.
+func SelectsEnvironmentConfig(comp *v1.Composition, ec metav1.Object) bool {
+	if comp.Spec.Environment == nil {
+		return false
+	}
+	for _, src := range comp.Spec.Environment.EnvironmentConfigs {
+		switch {
+		case src.Ref != nil && src.Ref.Name == ec.GetName():
+			return true
+		case src.Selector != nil:
+			sel, err := metav1.LabelSelectorAsSelector(src.Selector)
+			if err == nil && sel.Matches(labels.Set(ec.GetLabels())) {
+				return true
+			}
+		}
+	}
+	return false
+}
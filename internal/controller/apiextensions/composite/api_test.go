@@ -22,20 +22,27 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
+	"github.com/crossplane/crossplane/internal/connection"
 	"github.com/crossplane/crossplane/internal/xcrd"
 )
 
@@ -59,9 +66,29 @@ func TestPublishConnection(t *testing.T) {
 	}
 	type want struct {
 		published bool
+		rotated   bool
 		err       error
 	}
 
+	xr := composite.New()
+	xr.SetUID("cool-uid")
+	xr.SetWriteConnectionSecretToReference(&xpv1.SecretReference{Namespace: "coolnamespace", Name: "coolsecret"})
+
+	// Publishing records the time at which connection details were rotated
+	// on the composite resource, so cases that rotate them must not share it.
+	rxr := composite.New()
+	rxr.SetUID("cool-uid")
+	rxr.SetWriteConnectionSecretToReference(&xpv1.SecretReference{Namespace: "coolnamespace", Name: "coolsecret"})
+
+	mdxr := composite.New()
+	mdxr.SetUID("cool-uid")
+	mdxr.SetWriteConnectionSecretToReference(&xpv1.SecretReference{Namespace: "coolnamespace", Name: "coolsecret"})
+	mdxr.Object["spec"].(map[string]interface{})["connectionSecretMetadata"] = map[string]interface{}{
+		"labels":      map[string]interface{}{"team": "platform"},
+		"annotations": map[string]interface{}{"owner": "dba"},
+		"type":        "Opaque",
+	}
+
 	cases := map[string]struct {
 		reason string
 		args   args
@@ -117,11 +144,140 @@ func TestPublishConnection(t *testing.T) {
 				published: true,
 			},
 		},
+		"SuccessfulPublishPattern": {
+			reason: "We should publish all connection secret keys that match a filter pattern.",
+			args: args{
+				applicator: resource.ApplyFn(func(_ context.Context, o client.Object, _ ...resource.ApplyOption) error {
+					want := resource.ConnectionSecretFor(owner, owner.GetObjectKind().GroupVersionKind())
+					want.Data = managed.ConnectionDetails{"kubeconfig": {41}, "kubeconfig-admin": {40}}
+					if diff := cmp.Diff(want, o); diff != "" {
+						t.Errorf("-want, +got:\n%s", diff)
+					}
+					return nil
+				}),
+				o:      owner,
+				c:      managed.ConnectionDetails{"cool": {42}, "kubeconfig": {41}, "kubeconfig-admin": {40}},
+				filter: []string{"kubeconfig*"},
+			},
+			want: want{
+				published: true,
+			},
+		},
+		"SuccessfulPublishMetadata": {
+			reason: "If only the connection secret's metadata changed we should publish it, without changing its immutable type.",
+			args: args{
+				applicator: resource.ApplyFn(func(ctx context.Context, o client.Object, ao ...resource.ApplyOption) error {
+					current := resource.ConnectionSecretFor(mdxr, mdxr.GetObjectKind().GroupVersionKind())
+					current.Data = managed.ConnectionDetails{"onlyme": {41}}
+					for _, fn := range ao {
+						if err := fn(ctx, current, o); err != nil {
+							return err
+						}
+					}
+					want := resource.ConnectionSecretFor(mdxr, mdxr.GetObjectKind().GroupVersionKind())
+					want.SetLabels(map[string]string{"team": "platform"})
+					want.SetAnnotations(map[string]string{"owner": "dba"})
+					want.Data = managed.ConnectionDetails{"onlyme": {41}}
+					if diff := cmp.Diff(want, o); diff != "" {
+						t.Errorf("-want, +got:\n%s", diff)
+					}
+					return nil
+				}),
+				o:      mdxr,
+				c:      managed.ConnectionDetails{"cool": {42}, "onlyme": {41}},
+				filter: []string{"onlyme"},
+			},
+			want: want{
+				published: true,
+			},
+		},
+		"ForeignMetadataNoOp": {
+			reason: "Labels, annotations, and finalizers added to the connection secret by other tools should not cause it to be updated.",
+			args: args{
+				applicator: resource.ApplyFn(func(ctx context.Context, o client.Object, ao ...resource.ApplyOption) error {
+					current := resource.ConnectionSecretFor(mdxr, mdxr.GetObjectKind().GroupVersionKind())
+					current.SetLabels(map[string]string{"team": "platform"})
+					current.SetAnnotations(map[string]string{"owner": "dba", "argocd.argoproj.io/tracking-id": "cool"})
+					current.SetFinalizers([]string{"example.org/protect"})
+					current.Data = managed.ConnectionDetails{"onlyme": {41}}
+					for _, fn := range ao {
+						if err := fn(ctx, current, o); err != nil {
+							return err
+						}
+					}
+					t.Errorf("Apply(...): unexpected update of connection secret")
+					return nil
+				}),
+				o:      mdxr,
+				c:      managed.ConnectionDetails{"cool": {42}, "onlyme": {41}},
+				filter: []string{"onlyme"},
+			},
+			want: want{
+				published: false,
+			},
+		},
+		"ForeignMetadataPreserved": {
+			reason: "Labels, annotations, and finalizers added to the connection secret by other tools should survive an update.",
+			args: args{
+				applicator: resource.ApplyFn(func(ctx context.Context, o client.Object, ao ...resource.ApplyOption) error {
+					current := resource.ConnectionSecretFor(xr, xr.GetObjectKind().GroupVersionKind())
+					current.SetAnnotations(map[string]string{"argocd.argoproj.io/tracking-id": "cool"})
+					current.SetFinalizers([]string{"example.org/protect"})
+					current.Data = managed.ConnectionDetails{"onlyme": {41}}
+					for _, fn := range ao {
+						if err := fn(ctx, current, o); err != nil {
+							return err
+						}
+					}
+					want := resource.ConnectionSecretFor(xr, xr.GetObjectKind().GroupVersionKind())
+					want.SetAnnotations(map[string]string{"argocd.argoproj.io/tracking-id": "cool"})
+					want.SetFinalizers([]string{"example.org/protect"})
+					want.Data = managed.ConnectionDetails{"onlyme": {41}, "new": {43}}
+					if diff := cmp.Diff(want, o); diff != "" {
+						t.Errorf("-want, +got:\n%s", diff)
+					}
+					return nil
+				}),
+				o:      xr,
+				c:      managed.ConnectionDetails{"onlyme": {41}, "new": {43}},
+				filter: []string{"onlyme", "new"},
+			},
+			want: want{
+				published: true,
+			},
+		},
+		"SuccessfulRotation": {
+			reason: "If publishing changed or removed existing keys we should record that the connection details were rotated.",
+			args: args{
+				applicator: resource.ApplyFn(func(ctx context.Context, o client.Object, ao ...resource.ApplyOption) error {
+					current := resource.ConnectionSecretFor(xr, xr.GetObjectKind().GroupVersionKind())
+					current.Data = managed.ConnectionDetails{"onlyme": {40}, "stale": {39}}
+					for _, fn := range ao {
+						if err := fn(ctx, current, o); err != nil {
+							return err
+						}
+					}
+					want := resource.ConnectionSecretFor(xr, xr.GetObjectKind().GroupVersionKind())
+					want.Data = managed.ConnectionDetails{"onlyme": {41}}
+					if diff := cmp.Diff(want, o); diff != "" {
+						t.Errorf("-want, +got:\n%s", diff)
+					}
+					return nil
+				}),
+				o:      rxr,
+				c:      managed.ConnectionDetails{"cool": {42}, "onlyme": {41}},
+				filter: []string{"onlyme"},
+			},
+			want: want{
+				published: true,
+				rotated:   true,
+			},
+		},
 	}
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			a := &APIFilteredSecretPublisher{tc.args.applicator, tc.args.filter}
+			a := &APIFilteredSecretPublisher{client: resource.ClientApplicator{Applicator: tc.args.applicator}, filter: tc.args.filter}
 			got, err := a.PublishConnection(context.Background(), tc.args.o, tc.args.c)
 			if diff := cmp.Diff(tc.want.published, got); diff != "" {
 				t.Errorf("\n%s\nPublish(...): -want, +got:\n%s", tc.reason, diff)
@@ -129,6 +285,442 @@ func TestPublishConnection(t *testing.T) {
 			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
 				t.Errorf("\n%s\nPublish(...): -want error, +got error:\n%s", tc.reason, diff)
 			}
+			if diff := cmp.Diff(tc.want.rotated, connection.GetLastRotatedTime(tc.args.o) != nil); diff != "" {
+				t.Errorf("\n%s\nPublish(...): -want rotated, +got rotated:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestUnpublishConnection(t *testing.T) {
+	owner := &fake.MockConnectionSecretOwner{
+		ObjectMeta: metav1.ObjectMeta{UID: "cool-uid"},
+		Ref: &xpv1.SecretReference{
+			Namespace: "coolnamespace",
+			Name:      "coolsecret",
+		},
+	}
+	controlled := func(uid types.UID) test.MockGetFn {
+		return test.NewMockGetFn(nil, func(obj client.Object) error {
+			obj.SetOwnerReferences([]metav1.OwnerReference{{UID: uid, Controller: pointer.BoolPtr(true)}})
+			return nil
+		})
+	}
+
+	cases := map[string]struct {
+		reason string
+		client client.Client
+		o      resource.ConnectionSecretOwner
+		want   error
+	}{
+		"ResourceDoesNotPublishSecret": {
+			reason: "A resource with a nil GetWriteConnectionSecretToReference has no secret to unpublish.",
+			o:      &fake.MockConnectionSecretOwner{},
+		},
+		"SecretNotFound": {
+			reason: "We should not return an error if the connection secret does not exist.",
+			client: &test.MockClient{MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "coolsecret"))},
+			o:      owner,
+		},
+		"GetSecretError": {
+			reason: "We should return any error encountered getting the connection secret.",
+			client: &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+			o:      owner,
+			want:   errors.Wrap(errBoom, errGetConnectionSecret),
+		},
+		"SecretNotControlled": {
+			reason: "We should not delete a connection secret the resource does not control.",
+			client: &test.MockClient{
+				MockGet: controlled("other-uid"),
+				MockDelete: func(_ context.Context, _ client.Object, _ ...client.DeleteOption) error {
+					t.Errorf("Delete(...): unexpected call for a secret that is not controlled by the resource")
+					return nil
+				},
+			},
+			o: owner,
+		},
+		"DeleteError": {
+			reason: "We should return any error encountered deleting the connection secret.",
+			client: &test.MockClient{
+				MockGet:    controlled("cool-uid"),
+				MockDelete: test.NewMockDeleteFn(errBoom),
+			},
+			o:    owner,
+			want: errors.Wrap(errBoom, errDeleteSecret),
+		},
+		"Success": {
+			reason: "We should delete a connection secret the resource controls.",
+			client: &test.MockClient{
+				MockGet:    controlled("cool-uid"),
+				MockDelete: test.NewMockDeleteFn(nil),
+			},
+			o: owner,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := &APIFilteredSecretPublisher{client: resource.ClientApplicator{Client: tc.client}}
+			err := a.UnpublishConnection(context.Background(), tc.o, nil)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nUnpublish(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+type mockStore struct {
+	MockWriteKeyValues  func(ctx context.Context, so resource.ConnectionSecretOwner, name string, c managed.ConnectionDetails, wo ...connection.WriteOption) (bool, error)
+	MockDeleteKeyValues func(ctx context.Context, so resource.ConnectionSecretOwner, name string) error
+}
+
+func (s *mockStore) WriteKeyValues(ctx context.Context, so resource.ConnectionSecretOwner, name string, c managed.ConnectionDetails, wo ...connection.WriteOption) (bool, error) {
+	return s.MockWriteKeyValues(ctx, so, name, c, wo...)
+}
+
+func (s *mockStore) DeleteKeyValues(ctx context.Context, so resource.ConnectionSecretOwner, name string) error {
+	return s.MockDeleteKeyValues(ctx, so, name)
+}
+
+func TestAPIStorePublisher(t *testing.T) {
+	xr := func(to map[string]interface{}) *composite.Unstructured {
+		cp := composite.New()
+		if to != nil {
+			_ = fieldpath.Pave(cp.Object).SetValue("spec.publishConnectionDetailsTo", to)
+		}
+		return cp
+	}
+
+	type args struct {
+		client client.Reader
+		stores connection.StoreBuilder
+		o      resource.ConnectionSecretOwner
+		filter []string
+		c      managed.ConnectionDetails
+	}
+	type want struct {
+		published bool
+		rotated   bool
+		err       error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"ResourceDoesNotPublishToStore": {
+			reason: "A composite resource without spec.publishConnectionDetailsTo should not publish to a store.",
+			args: args{
+				o: xr(nil),
+			},
+		},
+		"GetStoreConfigError": {
+			reason: "We should return any error encountered getting the default StoreConfig.",
+			args: args{
+				client: &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+				o:      xr(map[string]interface{}{"name": "cool"}),
+			},
+			want: want{
+				err: errors.Wrapf(errBoom, errFmtGetStoreConfig, defaultStoreConfig),
+			},
+		},
+		"BuildStoreError": {
+			reason: "We should return any error encountered building the store.",
+			args: args{
+				client: &test.MockClient{MockGet: test.NewMockGetFn(nil)},
+				stores: connection.StoreBuilderFn(func(_ context.Context, _ *v1alpha1.StoreConfig) (connection.Store, error) {
+					return nil, errBoom
+				}),
+				o: xr(map[string]interface{}{"name": "cool", "configRef": map[string]interface{}{"name": "vault"}}),
+			},
+			want: want{
+				err: errors.Wrapf(errBoom, errFmtBuildStore, "vault"),
+			},
+		},
+		"WriteError": {
+			reason: "We should return any error encountered writing to the store.",
+			args: args{
+				client: &test.MockClient{MockGet: test.NewMockGetFn(nil)},
+				stores: connection.StoreBuilderFn(func(_ context.Context, _ *v1alpha1.StoreConfig) (connection.Store, error) {
+					return &mockStore{MockWriteKeyValues: func(_ context.Context, _ resource.ConnectionSecretOwner, _ string, _ managed.ConnectionDetails, _ ...connection.WriteOption) (bool, error) {
+						return false, errBoom
+					}}, nil
+				}),
+				o: xr(map[string]interface{}{"name": "cool", "configRef": map[string]interface{}{"name": "vault"}}),
+			},
+			want: want{
+				err: errors.Wrapf(errBoom, errFmtWriteStore, "vault"),
+			},
+		},
+		"SuccessfulPublish": {
+			reason: "We should write filtered connection details to the store configured by the referenced StoreConfig.",
+			args: args{
+				client: &test.MockClient{MockGet: func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+					if key.Name != "vault" {
+						t.Errorf("Get(...): want StoreConfig vault, got %q", key.Name)
+					}
+					obj.(*v1alpha1.StoreConfig).Spec.Type = v1alpha1.StoreTypeVault
+					return nil
+				}},
+				stores: connection.StoreBuilderFn(func(_ context.Context, cfg *v1alpha1.StoreConfig) (connection.Store, error) {
+					if cfg.Spec.Type != v1alpha1.StoreTypeVault {
+						t.Errorf("Build(...): want StoreConfig of type Vault, got %q", cfg.Spec.Type)
+					}
+					return &mockStore{MockWriteKeyValues: func(_ context.Context, _ resource.ConnectionSecretOwner, name string, c managed.ConnectionDetails, _ ...connection.WriteOption) (bool, error) {
+						if diff := cmp.Diff("cool", name); diff != "" {
+							t.Errorf("WriteKeyValues(...): -want name, +got name:\n%s", diff)
+						}
+						if diff := cmp.Diff(managed.ConnectionDetails{"onlyme": {41}}, c); diff != "" {
+							t.Errorf("WriteKeyValues(...): -want, +got:\n%s", diff)
+						}
+						return true, nil
+					}}, nil
+				}),
+				o:      xr(map[string]interface{}{"name": "cool", "configRef": map[string]interface{}{"name": "vault"}}),
+				c:      managed.ConnectionDetails{"cool": {42}, "onlyme": {41}},
+				filter: []string{"onlyme"},
+			},
+			want: want{
+				published: true,
+			},
+		},
+		"SuccessfulRotation": {
+			reason: "If publishing changed or removed connection details already in the store we should record that the connection details were rotated.",
+			args: args{
+				client: &test.MockClient{MockGet: test.NewMockGetFn(nil)},
+				stores: connection.StoreBuilderFn(func(_ context.Context, _ *v1alpha1.StoreConfig) (connection.Store, error) {
+					return &mockStore{MockWriteKeyValues: func(_ context.Context, _ resource.ConnectionSecretOwner, _ string, c managed.ConnectionDetails, wo ...connection.WriteOption) (bool, error) {
+						for _, fn := range wo {
+							fn(managed.ConnectionDetails{"cool": {41}}, c)
+						}
+						return true, nil
+					}}, nil
+				}),
+				o:      xr(map[string]interface{}{"name": "cool"}),
+				c:      managed.ConnectionDetails{"cool": {42}},
+				filter: []string{"cool"},
+			},
+			want: want{
+				published: true,
+				rotated:   true,
+			},
+		},
+		"AddedKeysAreNotRotation": {
+			reason: "Adding connection details to those already in the store is not a rotation.",
+			args: args{
+				client: &test.MockClient{MockGet: test.NewMockGetFn(nil)},
+				stores: connection.StoreBuilderFn(func(_ context.Context, _ *v1alpha1.StoreConfig) (connection.Store, error) {
+					return &mockStore{MockWriteKeyValues: func(_ context.Context, _ resource.ConnectionSecretOwner, _ string, c managed.ConnectionDetails, wo ...connection.WriteOption) (bool, error) {
+						for _, fn := range wo {
+							fn(managed.ConnectionDetails{"cool": {42}}, c)
+						}
+						return true, nil
+					}}, nil
+				}),
+				o:      xr(map[string]interface{}{"name": "cool"}),
+				c:      managed.ConnectionDetails{"cool": {42}, "new": {43}},
+				filter: []string{"cool", "new"},
+			},
+			want: want{
+				published: true,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := &APIStorePublisher{client: tc.args.client, stores: tc.args.stores, filter: tc.args.filter}
+			got, err := a.PublishConnection(context.Background(), tc.args.o, tc.args.c)
+			if diff := cmp.Diff(tc.want.published, got); diff != "" {
+				t.Errorf("\n%s\nPublish(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.rotated, connection.GetLastRotatedTime(tc.args.o) != nil); diff != "" {
+				t.Errorf("\n%s\nPublish(...): -want rotated, +got rotated:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nPublish(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestConnectionPublisherChain(t *testing.T) {
+	publish := func(published bool, err error) ConnectionPublisher {
+		return ConnectionPublisherFn(func(_ context.Context, _ resource.ConnectionSecretOwner, _ managed.ConnectionDetails) (bool, error) {
+			return published, err
+		})
+	}
+
+	type want struct {
+		published bool
+		err       error
+	}
+
+	cases := map[string]struct {
+		reason string
+		chain  ConnectionPublisherChain
+		want   want
+	}{
+		"Error": {
+			reason: "We should return the first error encountered.",
+			chain:  ConnectionPublisherChain{publish(true, nil), publish(false, errBoom)},
+			want: want{
+				published: true,
+				err:       errBoom,
+			},
+		},
+		"NonePublished": {
+			reason: "We should return false if no publisher published.",
+			chain:  ConnectionPublisherChain{publish(false, nil), publish(false, nil)},
+			want: want{
+				published: false,
+			},
+		},
+		"SomePublished": {
+			reason: "We should return true if any publisher published.",
+			chain:  ConnectionPublisherChain{publish(false, nil), publish(true, nil)},
+			want: want{
+				published: true,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.chain.PublishConnection(context.Background(), &fake.MockConnectionSecretOwner{}, nil)
+			if diff := cmp.Diff(tc.want.published, got); diff != "" {
+				t.Errorf("\n%s\nPublish(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nPublish(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestAPIStorePublisherUnpublish(t *testing.T) {
+	xr := func(to map[string]interface{}) *composite.Unstructured {
+		cp := composite.New()
+		if to != nil {
+			_ = fieldpath.Pave(cp.Object).SetValue("spec.publishConnectionDetailsTo", to)
+		}
+		return cp
+	}
+
+	type args struct {
+		client client.Reader
+		stores connection.StoreBuilder
+		o      resource.ConnectionSecretOwner
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   error
+	}{
+		"ResourceDoesNotPublishToStore": {
+			reason: "A composite resource without spec.publishConnectionDetailsTo has nothing to unpublish.",
+			args: args{
+				o: xr(nil),
+			},
+		},
+		"StoreConfigNotFound": {
+			reason: "We should not return an error if the StoreConfig no longer exists.",
+			args: args{
+				client: &test.MockClient{MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, defaultStoreConfig))},
+				o:      xr(map[string]interface{}{"name": "cool"}),
+			},
+		},
+		"DeleteError": {
+			reason: "We should return any error encountered deleting from the store.",
+			args: args{
+				client: &test.MockClient{MockGet: test.NewMockGetFn(nil)},
+				stores: connection.StoreBuilderFn(func(_ context.Context, _ *v1alpha1.StoreConfig) (connection.Store, error) {
+					return &mockStore{MockDeleteKeyValues: func(_ context.Context, _ resource.ConnectionSecretOwner, _ string) error {
+						return errBoom
+					}}, nil
+				}),
+				o: xr(map[string]interface{}{"name": "cool", "configRef": map[string]interface{}{"name": "vault"}}),
+			},
+			want: errors.Wrapf(errBoom, errFmtDeleteStore, "vault"),
+		},
+		"SuccessfulUnpublish": {
+			reason: "We should delete the connection details from the store configured by the referenced StoreConfig.",
+			args: args{
+				client: &test.MockClient{MockGet: test.NewMockGetFn(nil)},
+				stores: connection.StoreBuilderFn(func(_ context.Context, _ *v1alpha1.StoreConfig) (connection.Store, error) {
+					return &mockStore{MockDeleteKeyValues: func(_ context.Context, _ resource.ConnectionSecretOwner, name string) error {
+						if diff := cmp.Diff("cool", name); diff != "" {
+							t.Errorf("DeleteKeyValues(...): -want name, +got name:\n%s", diff)
+						}
+						return nil
+					}}, nil
+				}),
+				o: xr(map[string]interface{}{"name": "cool"}),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := &APIStorePublisher{client: tc.args.client, stores: tc.args.stores}
+			err := a.UnpublishConnection(context.Background(), tc.args.o, nil)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nUnpublish(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+type publisherFns struct {
+	ConnectionPublisherFn
+	ConnectionUnpublisherFn
+}
+
+func TestConnectionPublisherChainUnpublish(t *testing.T) {
+	unpublished := 0
+	unpublish := func(err error) ConnectionPublisher {
+		return publisherFns{
+			ConnectionUnpublisherFn: func(_ context.Context, _ resource.ConnectionSecretOwner, _ managed.ConnectionDetails) error {
+				unpublished++
+				return err
+			},
+		}
+	}
+	publishOnly := ConnectionPublisherFn(func(_ context.Context, _ resource.ConnectionSecretOwner, _ managed.ConnectionDetails) (bool, error) {
+		return false, nil
+	})
+
+	cases := map[string]struct {
+		reason      string
+		chain       ConnectionPublisherChain
+		unpublished int
+		want        error
+	}{
+		"Error": {
+			reason:      "We should return the first error encountered.",
+			chain:       ConnectionPublisherChain{unpublish(errBoom), unpublish(nil)},
+			unpublished: 1,
+			want:        errBoom,
+		},
+		"SkipPublishOnly": {
+			reason:      "We should unpublish using each publisher that supports unpublishing.",
+			chain:       ConnectionPublisherChain{publishOnly, unpublish(nil), unpublish(nil)},
+			unpublished: 2,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			unpublished = 0
+			err := tc.chain.UnpublishConnection(context.Background(), &fake.MockConnectionSecretOwner{}, nil)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nUnpublish(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.unpublished, unpublished); diff != "" {
+				t.Errorf("\n%s\nUnpublish(...): -want unpublished, +got unpublished:\n%s", tc.reason, diff)
+			}
 		})
 	}
 }
@@ -569,6 +1161,158 @@ func TestAPIEnforcedCompositionSelector(t *testing.T) {
 	}
 }
 
+func TestAPIRevisionSelector(t *testing.T) {
+	xr := func(policy CompositionUpdatePolicy, rev string) *composite.Unstructured {
+		cr := composite.New()
+		cr.SetCompositionReference(&corev1.ObjectReference{Name: "cool-comp"})
+		if policy != "" {
+			_ = fieldpath.Pave(cr.Object).SetValue("spec.compositionUpdatePolicy", policy)
+		}
+		if rev != "" {
+			SetCompositionRevisionReference(cr, &corev1.LocalObjectReference{Name: rev})
+		}
+		return cr
+	}
+	revs := func(names ...string) test.MockListFn {
+		return func(_ context.Context, obj client.ObjectList, opts ...client.ListOption) error {
+			l := obj.(*v1alpha1.CompositionRevisionList)
+			for i, name := range names {
+				l.Items = append(l.Items, v1alpha1.CompositionRevision{
+					ObjectMeta: metav1.ObjectMeta{Name: name},
+					Spec:       v1alpha1.CompositionRevisionSpec{Revision: int64(i + 1)},
+				})
+			}
+			return nil
+		}
+	}
+	rev := func(comp string) test.MockGetFn {
+		return test.NewMockGetFn(nil, func(obj client.Object) error {
+			obj.SetLabels(map[string]string{v1alpha1.LabelCompositionName: comp})
+			return nil
+		})
+	}
+
+	type want struct {
+		rev *corev1.LocalObjectReference
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		kube   client.Client
+		cr     *composite.Unstructured
+		want   want
+	}{
+		"NoComposition": {
+			reason: "We should not select a revision before a Composition is selected.",
+			cr:     composite.New(),
+			want:   want{},
+		},
+		"ManualPinned": {
+			reason: "We should not change the revision of a composite resource with a Manual update policy.",
+			kube:   &test.MockClient{MockGet: rev("cool-comp")},
+			cr:     xr(CompositionUpdateManual, "cool-comp-1"),
+			want: want{
+				rev: &corev1.LocalObjectReference{Name: "cool-comp-1"},
+			},
+		},
+		"ManualPinnedGetError": {
+			reason: "We should return any error encountered getting the revision a composite resource with a Manual update policy references.",
+			kube:   &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+			cr:     xr(CompositionUpdateManual, "cool-comp-1"),
+			want: want{
+				rev: &corev1.LocalObjectReference{Name: "cool-comp-1"},
+				err: errors.Wrap(errBoom, errGetRev),
+			},
+		},
+		"ManualPinnedNotFound": {
+			reason: "We should select the latest revision for a composite resource with a Manual update policy that references a revision that no longer exists.",
+			kube: &test.MockClient{
+				MockGet:    test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "")),
+				MockList:   revs("cool-comp-1", "cool-comp-2"),
+				MockUpdate: test.NewMockUpdateFn(nil),
+			},
+			cr: xr(CompositionUpdateManual, "cool-comp-3"),
+			want: want{
+				rev: &corev1.LocalObjectReference{Name: "cool-comp-2"},
+			},
+		},
+		"ManualPinnedOtherComposition": {
+			reason: "We should select the latest revision for a composite resource with a Manual update policy that references a revision of a Composition other than the one it references.",
+			kube: &test.MockClient{
+				MockGet:    rev("previous-comp"),
+				MockList:   revs("cool-comp-1", "cool-comp-2"),
+				MockUpdate: test.NewMockUpdateFn(nil),
+			},
+			cr: xr(CompositionUpdateManual, "previous-comp-1"),
+			want: want{
+				rev: &corev1.LocalObjectReference{Name: "cool-comp-2"},
+			},
+		},
+		"ListError": {
+			reason: "We should return any error encountered listing revisions.",
+			kube:   &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+			cr:     xr("", ""),
+			want: want{
+				err: errors.Wrap(errBoom, errListRevisions),
+			},
+		},
+		"NoRevisions": {
+			reason: "We should not select a revision if the Composition has none.",
+			kube:   &test.MockClient{MockList: revs()},
+			cr:     xr("", ""),
+			want:   want{},
+		},
+		"ManualUnpinned": {
+			reason: "We should select the latest revision for a composite resource with a Manual update policy that references no revision.",
+			kube: &test.MockClient{
+				MockList:   revs("cool-comp-1", "cool-comp-2"),
+				MockUpdate: test.NewMockUpdateFn(nil),
+			},
+			cr: xr(CompositionUpdateManual, ""),
+			want: want{
+				rev: &corev1.LocalObjectReference{Name: "cool-comp-2"},
+			},
+		},
+		"AutomaticUpdate": {
+			reason: "We should select the latest revision for a composite resource with an Automatic update policy.",
+			kube: &test.MockClient{
+				MockList:   revs("cool-comp-1", "cool-comp-2"),
+				MockUpdate: test.NewMockUpdateFn(nil),
+			},
+			cr: xr(CompositionUpdateAutomatic, "cool-comp-1"),
+			want: want{
+				rev: &corev1.LocalObjectReference{Name: "cool-comp-2"},
+			},
+		},
+		"UpdateError": {
+			reason: "We should return any error encountered updating the composite resource.",
+			kube: &test.MockClient{
+				MockList:   revs("cool-comp-1"),
+				MockUpdate: test.NewMockUpdateFn(errBoom),
+			},
+			cr: xr("", ""),
+			want: want{
+				rev: &corev1.LocalObjectReference{Name: "cool-comp-1"},
+				err: errors.Wrap(errBoom, errUpdateComposite),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := NewAPIRevisionSelector(tc.kube)
+			err := s.SelectComposition(context.Background(), tc.cr)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nSelectComposition(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.rev, GetCompositionRevisionReference(tc.cr)); diff != "" {
+				t.Errorf("\n%s\nSelectComposition(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
 func TestAPINamingConfigurator(t *testing.T) {
 	type args struct {
 		kube client.Client
@@ -619,3 +1363,187 @@ func TestAPINamingConfigurator(t *testing.T) {
 		})
 	}
 }
+
+func TestAPIEnvironmentFetcher(t *testing.T) {
+	type args struct {
+		kube client.Client
+		comp *v1.Composition
+	}
+	type want struct {
+		env *v1alpha1.EnvironmentConfig
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoEnvironment": {
+			reason: "An empty environment should be returned if the composition selects no EnvironmentConfigs",
+			args: args{
+				comp: &v1.Composition{},
+			},
+			want: want{
+				env: &v1alpha1.EnvironmentConfig{},
+			},
+		},
+		"MissingRef": {
+			reason: "An error should be returned if a Reference source has no ref",
+			args: args{
+				comp: &v1.Composition{Spec: v1.CompositionSpec{Environment: &v1.EnvironmentConfiguration{
+					EnvironmentConfigs: []v1.EnvironmentSource{{}},
+				}}},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Errorf(errFmtEnvironmentSourceMissing, "ref", v1.EnvironmentSourceTypeReference), errFmtEnvironmentSource, 0),
+			},
+		},
+		"GetError": {
+			reason: "Errors getting a referenced EnvironmentConfig should be returned",
+			args: args{
+				kube: &test.MockClient{MockGet: test.NewMockGetFn(errBoom)},
+				comp: &v1.Composition{Spec: v1.CompositionSpec{Environment: &v1.EnvironmentConfiguration{
+					EnvironmentConfigs: []v1.EnvironmentSource{{Ref: &v1.EnvironmentSourceReference{Name: "a"}}},
+				}}},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Wrapf(errBoom, errFmtGetEnvironmentConfig, "a"), errFmtEnvironmentSource, 0),
+			},
+		},
+		"ListError": {
+			reason: "Errors listing selected EnvironmentConfigs should be returned",
+			args: args{
+				kube: &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+				comp: &v1.Composition{Spec: v1.CompositionSpec{Environment: &v1.EnvironmentConfiguration{
+					EnvironmentConfigs: []v1.EnvironmentSource{{
+						Type:     v1.EnvironmentSourceTypeSelector,
+						Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"cool": "true"}},
+					}},
+				}}},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Wrap(errBoom, errListEnvironmentConfigs), errFmtEnvironmentSource, 0),
+			},
+		},
+		"Success": {
+			reason: "The data of all selected EnvironmentConfigs should be deep merged in order",
+			args: args{
+				kube: &test.MockClient{
+					MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+						ec := obj.(*v1alpha1.EnvironmentConfig)
+						ec.Data = map[string]extv1.JSON{
+							"region": {Raw: []byte(`"us-east-1"`)},
+							"vpc":    {Raw: []byte(`{"id":"a","cidr":"10.0.0.0/16"}`)},
+						}
+						return nil
+					}),
+					MockList: test.NewMockListFn(nil, func(obj client.ObjectList) error {
+						l := obj.(*v1alpha1.EnvironmentConfigList)
+						l.Items = []v1alpha1.EnvironmentConfig{
+							{
+								ObjectMeta: metav1.ObjectMeta{Name: "c"},
+								Data:       map[string]extv1.JSON{"vpc": {Raw: []byte(`{"id":"c"}`)}},
+							},
+							{
+								ObjectMeta: metav1.ObjectMeta{Name: "b"},
+								Data: map[string]extv1.JSON{
+									"vpc":       {Raw: []byte(`{"id":"b"}`)},
+									"accountId": {Raw: []byte(`123`)},
+								},
+							},
+						}
+						return nil
+					}),
+				},
+				comp: &v1.Composition{Spec: v1.CompositionSpec{Environment: &v1.EnvironmentConfiguration{
+					EnvironmentConfigs: []v1.EnvironmentSource{
+						{Ref: &v1.EnvironmentSourceReference{Name: "a"}},
+						{
+							Type:     v1.EnvironmentSourceTypeSelector,
+							Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"cool": "true"}},
+						},
+					},
+				}}},
+			},
+			want: want{
+				env: &v1alpha1.EnvironmentConfig{Data: map[string]extv1.JSON{
+					"region":    {Raw: []byte(`"us-east-1"`)},
+					"vpc":       {Raw: []byte(`{"cidr":"10.0.0.0/16","id":"c"}`)},
+					"accountId": {Raw: []byte(`123`)},
+				}},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			f := NewAPIEnvironmentFetcher(tc.args.kube)
+			env, err := f.FetchEnvironment(context.Background(), &fake.Composite{}, tc.args.comp)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nFetchEnvironment(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.env, env); diff != "" {
+				t.Errorf("\n%s\nFetchEnvironment(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestSelectsEnvironmentConfig(t *testing.T) {
+	ec := &v1alpha1.EnvironmentConfig{ObjectMeta: metav1.ObjectMeta{
+		Name:   "cool",
+		Labels: map[string]string{"env": "prod"},
+	}}
+
+	cases := map[string]struct {
+		reason string
+		comp   *v1.Composition
+		want   bool
+	}{
+		"NoEnvironment": {
+			reason: "A composition with no environment selects no EnvironmentConfigs",
+			comp:   &v1.Composition{},
+			want:   false,
+		},
+		"Reference": {
+			reason: "A composition selects an EnvironmentConfig it references by name",
+			comp: &v1.Composition{Spec: v1.CompositionSpec{Environment: &v1.EnvironmentConfiguration{
+				EnvironmentConfigs: []v1.EnvironmentSource{{Ref: &v1.EnvironmentSourceReference{Name: "cool"}}},
+			}}},
+			want: true,
+		},
+		"MatchingSelector": {
+			reason: "A composition selects an EnvironmentConfig matched by its selector",
+			comp: &v1.Composition{Spec: v1.CompositionSpec{Environment: &v1.EnvironmentConfiguration{
+				EnvironmentConfigs: []v1.EnvironmentSource{{
+					Type:     v1.EnvironmentSourceTypeSelector,
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+				}},
+			}}},
+			want: true,
+		},
+		"NotSelected": {
+			reason: "A composition does not select an EnvironmentConfig it neither references nor matches",
+			comp: &v1.Composition{Spec: v1.CompositionSpec{Environment: &v1.EnvironmentConfiguration{
+				EnvironmentConfigs: []v1.EnvironmentSource{
+					{Ref: &v1.EnvironmentSourceReference{Name: "uncool"}},
+					{
+						Type:     v1.EnvironmentSourceTypeSelector,
+						Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "dev"}},
+					},
+				},
+			}}},
+			want: false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := SelectsEnvironmentConfig(tc.comp, ec)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nSelectsEnvironmentConfig(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -19,14 +19,19 @@ package composite
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kunstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
@@ -36,6 +41,8 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
+	"github.com/crossplane/crossplane/internal/dag"
 	"github.com/crossplane/crossplane/internal/xcrd"
 )
 
@@ -53,16 +60,56 @@ const (
 	errNamePrefix  = "name prefix is not found in labels"
 	errKindChanged = "cannot change the kind of an existing composed resource"
 	errName        = "cannot use dry-run create to name composed resource"
-
-	errFmtPatch          = "cannot apply the patch at index %d"
-	errFmtConnDetailKey  = "connection detail of type %q key is not set"
-	errFmtConnDetailVal  = "connection detail of type %q value is not set"
-	errFmtConnDetailPath = "connection detail of type %q fromFieldPath is not set"
+	errMarshalBase = "cannot marshal base template"
+	errDependsOn   = "resource template dependencies are invalid"
+	errOrphan      = "cannot orphan composed resource"
+	errDeleteCD    = "cannot delete composed resource"
+
+	errComposedPatchName    = "cannot patch from a composed resource without a fromResourceName"
+	errFmtPatch             = "cannot apply the patch at index %d"
+	errFmtConditionName     = "resource at index %d must be named to specify a condition"
+	errFmtForEachName       = "resource at index %d must be named to specify forEach"
+	errFmtForEachKeyPath    = "resource at index %d must specify a keyFieldPath to use forEach key type %s"
+	errFmtElementPatch      = "patch at index %d of resource at index %d cannot patch from an element of a resource that does not specify forEach"
+	errFmtDependsOnName     = "resource at index %d must be named to specify dependsOn"
+	errFmtDependsOnUnknown  = "resource at index %d depends on unknown resource %q"
+	errFmtDependsOnForEach  = "resource at index %d cannot depend on resource %q, which specifies forEach"
+	errFmtDeletionOrder     = "deletion order entry at index %d refers to unknown resource %q"
+	errFmtDeletionOrderDup  = "deletion order entry at index %d refers to resource %q more than once"
+	errFmtForEach           = "cannot expand forEach of resource template %q"
+	errFmtForEachNotArray   = "%s: not an array"
+	errFmtForEachKeyType    = "forEach key type %q is unsupported"
+	errFmtForEachKey        = "cannot determine key of element at index %d"
+	errFmtForEachKeyScalar  = "key of element at index %d is not a scalar value"
+	errFmtForEachDuplicate  = "key %q of element at index %d is not unique"
+	errFmtForEachElement    = "cannot patch from element at index %d"
+	errFmtConditionType     = "condition type %q is unsupported"
+	errFmtCondition         = "cannot evaluate condition of resource template %q"
+	errFmtComposedPatchName = "patch at index %d of resource at index %d must specify a fromResourceName"
+	errFmtComposedPatch     = "patch at index %d of resource at index %d cannot patch from resource %q, which is not declared before it"
+	errFmtCombineResource   = "variable at index %d of patch at index %d of resource at index %d cannot be read from resource %q, which is not declared"
+	errFmtComposedMissing   = "composed resource %q is not yet available"
+	errFmtComposedFieldPath = "field of composed resource %q is not yet available"
+	errFmtResourceTransform = "transform at index %d of patch at index %d of resource at index %d is invalid"
+	errFmtPatchSetTransform = "transform at index %d of patch at index %d of patch set %q is invalid"
+	errFmtConnDetailKey     = "connection detail of type %q key is not set"
+	errFmtConnDetailVal     = "connection detail of type %q value is not set"
+	errFmtConnDetailPath    = "connection detail of type %q fromFieldPath is not set"
+	errFmtConnDetailCombine = "connection detail of type %q combine is not set"
+	errFmtConnDetailVar     = "variable at index %d of connection detail %q must specify a source"
+	errFmtMatchCondition    = "readiness check at index %d: matchCondition is required by the MatchCondition type"
+
+	errFmtConnDetailCombineFailed = "cannot combine variables of connection detail %q"
+	errFmtConnDetailTransform     = "transform at index %d of connection detail %q failed"
+	errFmtConnDetailBytes         = "cannot encode value of connection detail %q"
+
+	errFmtConnDetailTransformInvalid = "transform at index %d of connection detail at index %d of resource at index %d is invalid"
 )
 
 // Annotation keys.
 const (
 	AnnotationKeyCompositionResourceName = "crossplane.io/composition-resource-name"
+	AnnotationKeyOrphanedFrom            = "crossplane.io/orphaned-from"
 )
 
 // SetCompositionResourceName sets the name of the composition template used to
@@ -93,6 +140,21 @@ func (fn CompositionValidatorFn) Validate(comp *v1.Composition) error {
 // A ValidationChain runs multiple validations.
 type ValidationChain []CompositionValidator
 
+// DefaultValidationChain returns the validations that must pass before a
+// Composition may be used to compose resources.
+func DefaultValidationChain() ValidationChain {
+	return ValidationChain{
+		CompositionValidatorFn(RejectMixedTemplates),
+		CompositionValidatorFn(RejectDuplicateNames),
+		CompositionValidatorFn(RejectInvalidTransforms),
+		CompositionValidatorFn(RejectInvalidComposedPatches),
+		CompositionValidatorFn(RejectAnonymousConditionalTemplates),
+		CompositionValidatorFn(RejectInvalidForEach),
+		CompositionValidatorFn(RejectInvalidDependencies),
+		CompositionValidatorFn(RejectInvalidDeletionOrder),
+	}
+}
+
 // Validate the supplied Composition.
 func (vs ValidationChain) Validate(comp *v1.Composition) error {
 	for _, v := range vs {
@@ -149,6 +211,416 @@ func RejectDuplicateNames(comp *v1.Composition) error {
 	return nil
 }
 
+// RejectInvalidTransforms validates that all transforms of all patches and
+// connection details within the supplied Composition are correctly configured.
+// It catches errors such as a math transform that divides by zero before any
+// composed resource is rendered.
+func RejectInvalidTransforms(comp *v1.Composition) error {
+	for _, ps := range comp.Spec.PatchSets {
+		for j, p := range ps.Patches {
+			for k, t := range p.Transforms {
+				if err := t.Validate(); err != nil {
+					return errors.Wrapf(err, errFmtPatchSetTransform, k, j, ps.Name)
+				}
+			}
+		}
+	}
+	for i, tmpl := range comp.Spec.Resources {
+		for j, p := range tmpl.Patches {
+			for k, t := range p.Transforms {
+				if err := t.Validate(); err != nil {
+					return errors.Wrapf(err, errFmtResourceTransform, k, j, i)
+				}
+			}
+		}
+		for j, d := range tmpl.ConnectionDetails {
+			for k, t := range d.Transforms {
+				if err := t.Validate(); err != nil {
+					return errors.Wrapf(err, errFmtConnDetailTransformInvalid, k, j, i)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// RejectInvalidComposedPatches validates that all FromComposedFieldPath
+// patches within the supplied Composition patch from a named resource template
+// that is declared before the template of the patch. This ensures composed
+// resources can be applied in the order their templates are declared. It also
+// validates that the variables of CombineToComposite patches are only read
+// from named resource templates that are declared, in any order.
+func RejectInvalidComposedPatches(comp *v1.Composition) error {
+	named := map[string]bool{}
+	for _, tmpl := range comp.Spec.Resources {
+		if tmpl.Name != nil && tmpl.ForEach == nil {
+			named[*tmpl.Name] = true
+		}
+	}
+
+	declared := map[string]bool{}
+	for i, tmpl := range comp.Spec.Resources {
+		for j, p := range tmpl.Patches {
+			if p.Type == v1.PatchTypeCombineToComposite && p.Combine != nil {
+				for k, v := range p.Combine.Variables {
+					if v.FromResourceName != nil && !named[*v.FromResourceName] {
+						return errors.Errorf(errFmtCombineResource, k, j, i, *v.FromResourceName)
+					}
+				}
+			}
+			if p.Type != v1.PatchTypeFromComposedFieldPath {
+				continue
+			}
+			if p.FromResourceName == nil {
+				return errors.Errorf(errFmtComposedPatchName, j, i)
+			}
+			if !declared[*p.FromResourceName] {
+				return errors.Errorf(errFmtComposedPatch, j, i, *p.FromResourceName)
+			}
+		}
+		// Templates that specify forEach are expanded into templates with
+		// different names, so they can't be patched from by name.
+		if tmpl.Name != nil && tmpl.ForEach == nil {
+			declared[*tmpl.Name] = true
+		}
+	}
+	return nil
+}
+
+// RejectAnonymousConditionalTemplates validates that all templates that
+// specify a condition within the supplied Composition are named. Anonymous
+// templates are associated with composed resources by order, which would no
+// longer be possible once a template was excluded by its condition.
+func RejectAnonymousConditionalTemplates(comp *v1.Composition) error {
+	for i, tmpl := range comp.Spec.Resources {
+		if tmpl.Condition != nil && tmpl.Name == nil {
+			return errors.Errorf(errFmtConditionName, i)
+		}
+	}
+	return nil
+}
+
+// RejectInvalidForEach validates that all templates that specify forEach
+// within the supplied Composition are named and have a valid key, and that
+// FromElementFieldPath patches are only used by such templates.
+func RejectInvalidForEach(comp *v1.Composition) error {
+	for i, tmpl := range comp.Spec.Resources {
+		if tmpl.ForEach == nil {
+			for j, p := range tmpl.Patches {
+				if p.Type == v1.PatchTypeFromElementFieldPath {
+					return errors.Errorf(errFmtElementPatch, j, i)
+				}
+			}
+			continue
+		}
+		if tmpl.Name == nil {
+			return errors.Errorf(errFmtForEachName, i)
+		}
+		if tmpl.ForEach.Key == v1.ForEachKeyTypeFieldPath && tmpl.ForEach.KeyFieldPath == nil {
+			return errors.Errorf(errFmtForEachKeyPath, i, tmpl.ForEach.Key)
+		}
+	}
+	return nil
+}
+
+// RejectInvalidDependencies validates that all templates that specify
+// dependsOn within the supplied Composition are named, that they depend only on
+// known templates that do not specify forEach, and that dependencies are not
+// cyclic. Templates that patch from other composed resources are considered to
+// depend on them for the purposes of cycle detection.
+func RejectInvalidDependencies(comp *v1.Composition) error {
+	named := map[string]v1.ComposedTemplate{}
+	for _, tmpl := range comp.Spec.Resources {
+		if tmpl.Name != nil {
+			named[*tmpl.Name] = tmpl
+		}
+	}
+
+	nodes := make([]dag.Node, 0, len(named))
+	for i, tmpl := range comp.Spec.Resources {
+		if tmpl.Name == nil {
+			if len(tmpl.DependsOn) > 0 {
+				return errors.Errorf(errFmtDependsOnName, i)
+			}
+			continue
+		}
+		for _, name := range tmpl.DependsOn {
+			dt, ok := named[name]
+			if !ok {
+				return errors.Errorf(errFmtDependsOnUnknown, i, name)
+			}
+			if dt.ForEach != nil {
+				return errors.Errorf(errFmtDependsOnForEach, i, name)
+			}
+		}
+		n := &templateNode{name: *tmpl.Name}
+		for _, name := range dependencies(tmpl) {
+			_ = n.AddNeighbors(&templateNode{name: name})
+		}
+		nodes = append(nodes, n)
+	}
+
+	d := dag.NewMapDag()
+	if _, err := d.Init(nodes); err != nil {
+		return errors.Wrap(err, errDependsOn)
+	}
+	_, err := d.Sort()
+	return errors.Wrap(err, errDependsOn)
+}
+
+// A templateNode is a named resource template in a graph of dependencies.
+type templateNode struct {
+	name      string
+	neighbors []dag.Node
+}
+
+// Identifier of this template.
+func (n *templateNode) Identifier() string {
+	return n.name
+}
+
+// Neighbors of this template; i.e. the templates it depends on.
+func (n *templateNode) Neighbors() []dag.Node {
+	return n.neighbors
+}
+
+// AddNeighbors adds the supplied templates as dependencies of this template,
+// unless they already are.
+func (n *templateNode) AddNeighbors(nodes ...dag.Node) error {
+	for _, add := range nodes {
+		exists := false
+		for _, e := range n.neighbors {
+			if e.Identifier() == add.Identifier() {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			n.neighbors = append(n.neighbors, add)
+		}
+	}
+	return nil
+}
+
+// dependencies returns the names of the templates the supplied template
+// depends on, including those of the resources it patches from.
+func dependencies(t v1.ComposedTemplate) []string {
+	deps := append([]string{}, t.DependsOn...)
+	for _, p := range t.Patches {
+		if p.Type == v1.PatchTypeFromComposedFieldPath && p.FromResourceName != nil {
+			deps = append(deps, *p.FromResourceName)
+		}
+	}
+	return deps
+}
+
+// applyOrder returns the indices of the supplied template associations in the
+// order their composed resources should be applied. Resources are ordered
+// after the resources they depend on, and otherwise in the order their
+// templates are declared.
+func applyOrder(tas []TemplateAssociation) []int {
+	index := map[string]int{}
+	for i, ta := range tas {
+		if ta.Template.Name != nil {
+			index[*ta.Template.Name] = i
+		}
+	}
+
+	order := make([]int, 0, len(tas))
+	visited := make([]bool, len(tas))
+	var visit func(i int)
+	visit = func(i int) {
+		if visited[i] {
+			return
+		}
+		// Marking the template visited before its dependencies ensures we
+		// terminate even if dependencies are cyclic, which validation should
+		// have prevented.
+		visited[i] = true
+		for _, name := range dependencies(tas[i].Template) {
+			if j, ok := index[name]; ok {
+				visit(j)
+			}
+		}
+		order = append(order, i)
+	}
+	for i := range tas {
+		visit(i)
+	}
+	return order
+}
+
+// blockedOn returns the names of the included templates the supplied template
+// depends on whose composed resources are not yet ready.
+func blockedOn(t v1.ComposedTemplate, included, ready map[string]bool) []string {
+	var blocked []string
+	for _, name := range t.DependsOn {
+		if included[name] && !ready[name] {
+			blocked = append(blocked, name)
+		}
+	}
+	return blocked
+}
+
+// ExpandForEach expands each of the supplied templates that specifies forEach
+// into one template per element of the composite resource array it iterates
+// over. Each expanded template is named after its original template and the
+// key of its element, e.g. "bucket[0]". The FromElementFieldPath patches of
+// each expanded template are applied to its base. Templates that do not
+// specify forEach are returned as is.
+func ExpandForEach(cr resource.Composite, ts []v1.ComposedTemplate) ([]v1.ComposedTemplate, error) {
+	var paved *fieldpath.Paved
+	out := make([]v1.ComposedTemplate, 0, len(ts))
+	for i, t := range ts {
+		if t.ForEach == nil {
+			out = append(out, t)
+			continue
+		}
+		if paved == nil {
+			p, err := fieldpath.PaveObject(cr)
+			if err != nil {
+				return nil, err
+			}
+			paved = p
+		}
+		ets, err := expandForEach(paved, t)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtForEach, templateName(t, i))
+		}
+		out = append(out, ets...)
+	}
+	return out, nil
+}
+
+func expandForEach(cr *fieldpath.Paved, t v1.ComposedTemplate) ([]v1.ComposedTemplate, error) { //nolint:gocyclo
+	// This function is a little over our complexity goal due to the error
+	// checking required for each element.
+
+	v, err := cr.GetValue(t.ForEach.FieldPath)
+	if fieldpath.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	elements, ok := v.([]interface{})
+	if !ok {
+		return nil, errors.Errorf(errFmtForEachNotArray, t.ForEach.FieldPath)
+	}
+
+	seen := map[string]bool{}
+	out := make([]v1.ComposedTemplate, len(elements))
+	for i, e := range elements {
+		key, err := forEachKey(t.ForEach, e, i)
+		if err != nil {
+			return nil, err
+		}
+		if seen[key] {
+			return nil, errors.Errorf(errFmtForEachDuplicate, key, i)
+		}
+		seen[key] = true
+
+		base := map[string]interface{}{}
+		if err := json.Unmarshal(t.Base.Raw, &base); err != nil {
+			return nil, errors.Wrap(err, errUnmarshal)
+		}
+		from := &kunstructured.Unstructured{Object: map[string]interface{}{
+			"element": e,
+			"index":   int64(i),
+			"key":     key,
+		}}
+		to := &kunstructured.Unstructured{Object: base}
+		for j, p := range t.Patches {
+			if err := p.Apply(from, to, v1.PatchTypeFromElementFieldPath); err != nil {
+				return nil, errors.Wrapf(errors.Wrapf(err, errFmtPatch, j), errFmtForEachElement, i)
+			}
+		}
+		raw, err := json.Marshal(base)
+		if err != nil {
+			return nil, errors.Wrap(err, errMarshalBase)
+		}
+
+		et := *t.DeepCopy()
+		et.Name = pointer.StringPtr(fmt.Sprintf("%s[%s]", *t.Name, key))
+		et.ForEach = nil
+		et.Base = runtime.RawExtension{Raw: raw}
+		out[i] = et
+	}
+	return out, nil
+}
+
+// forEachKey returns the key that identifies the supplied element.
+func forEachKey(fe *v1.ForEach, e interface{}, i int) (string, error) {
+	var v interface{}
+	switch fe.Key {
+	case v1.ForEachKeyTypeIndex, "":
+		return strconv.Itoa(i), nil
+	case v1.ForEachKeyTypeValue:
+		v = e
+	case v1.ForEachKeyTypeFieldPath:
+		m, ok := e.(map[string]interface{})
+		if !ok || fe.KeyFieldPath == nil {
+			return "", errors.Errorf(errFmtForEachKey, i)
+		}
+		kv, err := fieldpath.Pave(m).GetValue(*fe.KeyFieldPath)
+		if err != nil {
+			return "", errors.Wrapf(err, errFmtForEachKey, i)
+		}
+		v = kv
+	default:
+		return "", errors.Errorf(errFmtForEachKeyType, fe.Key)
+	}
+
+	switch v.(type) {
+	case string, bool, int64, float64:
+		return fmt.Sprint(v), nil
+	}
+	return "", errors.Errorf(errFmtForEachKeyScalar, i)
+}
+
+// IsIncluded returns true if the supplied template should be used to compose a
+// resource for the supplied composite resource; i.e. if it has no condition or
+// if its condition is met.
+func IsIncluded(cr resource.Composite, t v1.ComposedTemplate) (bool, error) {
+	c := t.Condition
+	if c == nil {
+		return true, nil
+	}
+
+	paved, err := fieldpath.PaveObject(cr)
+	if err != nil {
+		return false, err
+	}
+
+	switch c.Type {
+	case v1.ComposedTemplateConditionTypeFieldPathExists:
+		_, err := paved.GetValue(c.FieldPath)
+		if resource.Ignore(fieldpath.IsNotFound, err) != nil {
+			return false, err
+		}
+		return !fieldpath.IsNotFound(err), nil
+	case v1.ComposedTemplateConditionTypeMatchString:
+		val, err := paved.GetString(c.FieldPath)
+		if resource.Ignore(fieldpath.IsNotFound, err) != nil {
+			return false, err
+		}
+		return !fieldpath.IsNotFound(err) && val == c.MatchString, nil
+	case v1.ComposedTemplateConditionTypeMatchInteger:
+		val, err := paved.GetInteger(c.FieldPath)
+		if resource.Ignore(fieldpath.IsNotFound, err) != nil {
+			return false, err
+		}
+		return !fieldpath.IsNotFound(err) && val == c.MatchInteger, nil
+	case v1.ComposedTemplateConditionTypeMatchTrue, v1.ComposedTemplateConditionTypeMatchFalse:
+		val, err := paved.GetBool(c.FieldPath)
+		if resource.Ignore(fieldpath.IsNotFound, err) != nil {
+			return false, err
+		}
+		return !fieldpath.IsNotFound(err) && val == (c.Type == v1.ComposedTemplateConditionTypeMatchTrue), nil
+	}
+	return false, errors.Errorf(errFmtConditionType, c.Type)
+}
+
 // A TemplateAssociation associates a composed resource template with a composed
 // resource. If no such resource exists the reference will be empty.
 type TemplateAssociation struct {
@@ -197,7 +669,9 @@ func (fn CompositionTemplateAssociatorFn) AssociateTemplates(ctx context.Context
 // template or existing composed resource can't be associated by name it falls
 // back to associating them by order. If it encounters a referenced resource
 // that corresponds to a non-existent template the resource will be garbage
-// collected (i.e. deleted).
+// collected (i.e. deleted). Templates whose condition is not met are excluded
+// from the returned associations, and any resource previously composed from
+// such a template is also garbage collected.
 type GarbageCollectingAssociator struct {
 	client client.Client
 }
@@ -209,24 +683,428 @@ func NewGarbageCollectingAssociator(c client.Client) *GarbageCollectingAssociato
 }
 
 // AssociateTemplates with composed resources.
-func (a *GarbageCollectingAssociator) AssociateTemplates(ctx context.Context, cr resource.Composite, comp *v1.Composition) ([]TemplateAssociation, error) { //nolint:gocyclo
+func (a *GarbageCollectingAssociator) AssociateTemplates(ctx context.Context, cr resource.Composite, comp *v1.Composition) ([]TemplateAssociation, error) {
+	tas, err := a.associate(ctx, cr, comp)
+	if err != nil {
+		return nil, err
+	}
+
+	included := make([]TemplateAssociation, 0, len(tas))
+	for i, ta := range tas {
+		ok, err := IsIncluded(cr, ta.Template)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtCondition, templateName(ta.Template, i))
+		}
+		if ok {
+			included = append(included, ta)
+			continue
+		}
+		if err := a.garbageCollect(ctx, cr, ta.Reference); err != nil {
+			return nil, err
+		}
+	}
+	return included, nil
+}
+
+// garbageCollect the referenced composed resource, if it exists and is
+// controlled by the supplied composite resource.
+func (a *GarbageCollectingAssociator) garbageCollect(ctx context.Context, cr resource.Composite, ref corev1.ObjectReference) error {
+	if ref.Name == "" {
+		return nil
+	}
+	cd := composed.New(composed.FromReference(ref))
+	err := a.client.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, cd)
+	if kerrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, errGetComposed)
+	}
+	if c := metav1.GetControllerOf(cd); c != nil && c.UID != cr.GetUID() {
+		return nil
+	}
+	return errors.Wrap(resource.IgnoreNotFound(a.client.Delete(ctx, cd)), errGCComposed)
+}
+
+// GetCompositeDeletionPolicy returns the deletion policy of the supplied
+// composite resource. Composite resources delete their composed resources by
+// default.
+func GetCompositeDeletionPolicy(cr resource.Composite) xpv1.DeletionPolicy {
+	u, ok := cr.(unstructuredComposite)
+	if !ok {
+		return xpv1.DeletionDelete
+	}
+	p, err := fieldpath.Pave(u.GetUnstructured().Object).GetString("spec.deletionPolicy")
+	if err != nil || p == "" {
+		return xpv1.DeletionDelete
+	}
+	return xpv1.DeletionPolicy(p)
+}
+
+// GetDeletionPolicy returns the deletion policy of resources composed from the
+// supplied template. The template's deletion policy takes precedence over that
+// of the supplied composite resource.
+func GetDeletionPolicy(cr resource.Composite, t v1.ComposedTemplate) xpv1.DeletionPolicy {
+	if t.DeletionPolicy != nil {
+		return *t.DeletionPolicy
+	}
+	return GetCompositeDeletionPolicy(cr)
+}
+
+// A CompositionUpdatePolicy determines which revision of its Composition a
+// composite resource is composed from.
+type CompositionUpdatePolicy string
+
+// Composition update policies.
+const (
+	// CompositionUpdateAutomatic composite resources are composed from the
+	// latest revision of their Composition.
+	CompositionUpdateAutomatic CompositionUpdatePolicy = "Automatic"
+
+	// CompositionUpdateManual composite resources are composed from the
+	// revision of their Composition they reference until that reference is
+	// updated.
+	CompositionUpdateManual CompositionUpdatePolicy = "Manual"
+)
+
+// GetCompositionUpdatePolicy returns the Composition update policy of the
+// supplied composite resource. Composite resources are updated automatically by
+// default.
+func GetCompositionUpdatePolicy(cr resource.Composite) CompositionUpdatePolicy {
+	u, ok := cr.(unstructuredComposite)
+	if !ok {
+		return CompositionUpdateAutomatic
+	}
+	p, err := fieldpath.Pave(u.GetUnstructured().Object).GetString("spec.compositionUpdatePolicy")
+	if err != nil || p == "" {
+		return CompositionUpdateAutomatic
+	}
+	return CompositionUpdatePolicy(p)
+}
+
+// GetCompositionRevisionReference returns a reference to the revision of its
+// Composition that the supplied composite resource is composed from, if any.
+func GetCompositionRevisionReference(cr resource.Composite) *corev1.LocalObjectReference {
+	u, ok := cr.(unstructuredComposite)
+	if !ok {
+		return nil
+	}
+	ref := &corev1.LocalObjectReference{}
+	if err := fieldpath.Pave(u.GetUnstructured().Object).GetValueInto("spec.compositionRevisionRef", ref); err != nil {
+		return nil
+	}
+	return ref
+}
+
+// SetCompositionRevisionReference sets the reference to the revision of its
+// Composition that the supplied composite resource is composed from. It is a
+// no-op for composite resources that are not unstructured.
+func SetCompositionRevisionReference(cr resource.Composite, ref *corev1.LocalObjectReference) {
+	u, ok := cr.(unstructuredComposite)
+	if !ok {
+		return
+	}
+	_ = fieldpath.Pave(u.GetUnstructured().Object).SetValue("spec.compositionRevisionRef", ref)
+}
+
+// GetPublishConnectionDetailsTo returns where the supplied composite resource
+// publishes its connection details, if anywhere other than its connection
+// secret.
+func GetPublishConnectionDetailsTo(o resource.ConnectionSecretOwner) *v1alpha1.PublishConnectionDetailsTo {
+	u, ok := o.(unstructuredComposite)
+	if !ok {
+		return nil
+	}
+	to := &v1alpha1.PublishConnectionDetailsTo{}
+	if err := fieldpath.Pave(u.GetUnstructured().Object).GetValueInto("spec.publishConnectionDetailsTo", to); err != nil {
+		return nil
+	}
+	return to
+}
+
+// An APIOrphaner orphans the composed resources of a composite resource that
+// is being deleted by removing any owner references to it, and annotating them
+// with its name. Composed resources that should be orphaned are not rendered
+// with owner references, but those composed before their deletion policy was
+// set to Orphan may still have them.
+type APIOrphaner struct {
+	client client.Client
+}
+
+// NewAPIOrphaner returns an Orphaner that orphans composed resources according
+// to the deletion policies of their templates and composite resource.
+func NewAPIOrphaner(c client.Client) *APIOrphaner {
+	return &APIOrphaner{client: c}
+}
+
+// Orphan the composed resources of the supplied composite resource whose
+// deletion policy is Orphan, returning references to those it orphaned. Only
+// the composite resource's deletion policy applies if its Composition no
+// longer exists.
+func (o *APIOrphaner) Orphan(ctx context.Context, cr resource.Composite) ([]corev1.ObjectReference, error) {
+	comp, err := getComposition(ctx, o.client, cr)
+	if err != nil {
+		return nil, err
+	}
+
+	orphaned := []corev1.ObjectReference{}
+	for i, ref := range cr.GetResourceReferences() {
+		if ref.Name == "" {
+			continue
+		}
+		cd := composed.New(composed.FromReference(ref))
+		err := o.client.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, cd)
+		if kerrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, errGetComposed)
+		}
+
+		// We don't orphan composed resources controlled by another resource.
+		if c := metav1.GetControllerOf(cd); c != nil && c.UID != cr.GetUID() {
+			continue
+		}
+
+		p := GetCompositeDeletionPolicy(cr)
+		if j := templateIndexOf(comp.Spec.Resources, cd, i); j >= 0 {
+			p = GetDeletionPolicy(cr, comp.Spec.Resources[j])
+		}
+		if p != xpv1.DeletionOrphan {
+			continue
+		}
+
+		refs := []metav1.OwnerReference{}
+		for _, or := range cd.GetOwnerReferences() {
+			if or.UID != cr.GetUID() {
+				refs = append(refs, or)
+			}
+		}
+		cd.SetOwnerReferences(refs)
+		meta.AddAnnotations(cd, map[string]string{AnnotationKeyOrphanedFrom: cr.GetName()})
+		if err := o.client.Update(ctx, cd); err != nil {
+			return nil, errors.Wrap(err, errOrphan)
+		}
+		orphaned = append(orphaned, ref)
+	}
+	return orphaned, nil
+}
+
+// getComposition returns the Composition of the supplied composite resource.
+// The spec of the CompositionRevision the composite resource references, if
+// any, takes precedence over that of the Composition, so that the resource is
+// deleted the same way it was composed. An empty Composition is returned if
+// neither exists.
+func getComposition(ctx context.Context, c client.Reader, cr resource.Composite) (*v1.Composition, error) {
+	comp := &v1.Composition{}
+	ref := cr.GetCompositionReference()
+	if ref == nil {
+		return comp, nil
+	}
+	if err := c.Get(ctx, meta.NamespacedNameOf(ref), comp); resource.IgnoreNotFound(err) != nil {
+		return nil, errors.Wrap(err, errGetComp)
+	}
+
+	rref := GetCompositionRevisionReference(cr)
+	if rref == nil {
+		return comp, nil
+	}
+	rev := &v1alpha1.CompositionRevision{}
+	err := c.Get(ctx, types.NamespacedName{Name: rref.Name}, rev)
+	if kerrors.IsNotFound(err) {
+		return comp, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errGetRev)
+	}
+	if rev.GetLabels()[v1alpha1.LabelCompositionName] != ref.Name {
+		// The composite resource can't have been composed from a revision of
+		// a different Composition, so we fall back to its Composition rather
+		// than block its deletion.
+		return comp, nil
+	}
+	comp.Spec = rev.Spec.CompositionSpec
+	return comp, nil
+}
+
+// templateIndexOf returns the index of the template the supplied composed
+// resource was composed from, or -1 if there is none. Resources composed from
+// a forEach template are associated with the template that was expanded.
+// Resources composed from anonymous templates are associated by the index of
+// their resource reference.
+func templateIndexOf(ts []v1.ComposedTemplate, cd resource.Composed, i int) int {
+	name := GetCompositionResourceName(cd)
+	for j, t := range ts {
+		if t.Name == nil {
+			continue
+		}
+		if name == *t.Name || (t.ForEach != nil && strings.HasPrefix(name, *t.Name+"[")) {
+			return j
+		}
+	}
+	if name == "" && i < len(ts) && ts[i].Name == nil {
+		return i
+	}
+	return -1
+}
+
+// RejectInvalidDeletionOrder validates that the deletion order of the supplied
+// Composition refers only to named templates, and to each at most once.
+func RejectInvalidDeletionOrder(comp *v1.Composition) error {
+	named := map[string]bool{}
+	for _, t := range comp.Spec.Resources {
+		if t.Name != nil {
+			named[*t.Name] = true
+		}
+	}
+	seen := map[string]bool{}
+	for i, name := range comp.Spec.DeletionOrder {
+		if !named[name] {
+			return errors.Errorf(errFmtDeletionOrder, i, name)
+		}
+		if seen[name] {
+			return errors.Errorf(errFmtDeletionOrderDup, i, name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// deletionOrder returns the indices of the supplied templates in the order in
+// which their composed resources should be deleted. Templates named by the
+// explicit order come first. The remaining templates follow in the reverse of
+// their apply order.
+func deletionOrder(ts []v1.ComposedTemplate, explicit []string) []int {
+	tas := make([]TemplateAssociation, len(ts))
+	index := map[string]int{}
+	for i, t := range ts {
+		tas[i] = TemplateAssociation{Template: t}
+		if t.Name != nil {
+			index[*t.Name] = i
+		}
+	}
+
+	order := make([]int, 0, len(ts))
+	first := map[int]bool{}
+	for _, name := range explicit {
+		if i, ok := index[name]; ok && !first[i] {
+			order = append(order, i)
+			first[i] = true
+		}
+	}
+	ao := applyOrder(tas)
+	for k := len(ao) - 1; k >= 0; k-- {
+		if !first[ao[k]] {
+			order = append(order, ao[k])
+		}
+	}
+	return order
+}
+
+// An APIComposedDeleter deletes the composed resources of a composite resource
+// that is being deleted, one at a time.
+type APIComposedDeleter struct {
+	client client.Client
+}
+
+// NewAPIComposedDeleter returns a ComposedDeleter that deletes composed
+// resources in the deletion order of their Composition.
+func NewAPIComposedDeleter(c client.Client) *APIComposedDeleter {
+	return &APIComposedDeleter{client: c}
+}
+
+// DeleteComposed deletes the first of the supplied composite resource's
+// extant composed resources in deletion order, unless it is already being
+// deleted. It returns references to all extant composed resources that are
+// controlled by the composite resource, in deletion order. Resources that do
+// not correspond to a template of the composite resource's Composition are
+// deleted last, in reverse order of reference.
+func (d *APIComposedDeleter) DeleteComposed(ctx context.Context, cr resource.Composite) ([]corev1.ObjectReference, error) {
+	comp, err := getComposition(ctx, d.client, cr)
+	if err != nil {
+		return nil, err
+	}
+
+	position := map[int]int{}
+	for p, i := range deletionOrder(comp.Spec.Resources, comp.Spec.DeletionOrder) {
+		position[i] = p
+	}
+
+	type extant struct {
+		cd       *composed.Unstructured
+		ref      corev1.ObjectReference
+		position int
+	}
+	refs := cr.GetResourceReferences()
+	ex := make([]extant, 0, len(refs))
+	for i := len(refs) - 1; i >= 0; i-- {
+		ref := refs[i]
+		if ref.Name == "" {
+			continue
+		}
+		cd := composed.New(composed.FromReference(ref))
+		err := d.client.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, cd)
+		if kerrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, errGetComposed)
+		}
+
+		// We don't delete the composed resources we don't control, including
+		// those we orphaned.
+		if !metav1.IsControlledBy(cd, cr) {
+			continue
+		}
+
+		p := len(comp.Spec.Resources)
+		if j := templateIndexOf(comp.Spec.Resources, cd, i); j >= 0 {
+			p = position[j]
+		}
+		ex = append(ex, extant{cd: cd, ref: ref, position: p})
+	}
+
+	// We iterated our references in reverse, so a stable sort preserves the
+	// reverse order of resources at the same position; e.g. those composed
+	// from the same forEach template.
+	sort.SliceStable(ex, func(i, j int) bool { return ex[i].position < ex[j].position })
+
+	remaining := make([]corev1.ObjectReference, len(ex))
+	for i := range ex {
+		remaining[i] = ex[i].ref
+	}
+	if len(ex) == 0 || meta.WasDeleted(ex[0].cd) {
+		return remaining, nil
+	}
+	if err := d.client.Delete(ctx, ex[0].cd); resource.IgnoreNotFound(err) != nil {
+		return nil, errors.Wrap(err, errDeleteCD)
+	}
+	return remaining, nil
+}
+
+func (a *GarbageCollectingAssociator) associate(ctx context.Context, cr resource.Composite, comp *v1.Composition) ([]TemplateAssociation, error) { //nolint:gocyclo
 	// NOTE(negz): This method is a little over our complexity goal. Be wary of
 	// making it more complex.
 
+	rs, err := ExpandForEach(cr, comp.Spec.Resources)
+	if err != nil {
+		return nil, err
+	}
+
 	templates := map[string]int{}
-	for i, t := range comp.Spec.Resources {
+	for i, t := range rs {
 		if t.Name == nil {
 			// If our templates aren't named we fall back to assuming that the
 			// existing resource reference array (if any) already matches the
 			// order of our resource template array.
-			return AssociateByOrder(comp.Spec.Resources, cr.GetResourceReferences()), nil
+			return AssociateByOrder(rs, cr.GetResourceReferences()), nil
 		}
 		templates[*t.Name] = i
 	}
 
-	tas := make([]TemplateAssociation, len(comp.Spec.Resources))
-	for i := range comp.Spec.Resources {
-		tas[i] = TemplateAssociation{Template: comp.Spec.Resources[i]}
+	tas := make([]TemplateAssociation, len(rs))
+	for i := range rs {
+		tas[i] = TemplateAssociation{Template: rs[i]}
 	}
 
 	for _, ref := range cr.GetResourceReferences() {
@@ -252,7 +1130,7 @@ func (a *GarbageCollectingAssociator) AssociateTemplates(ctx context.Context, cr
 			// reference array already matches the order of our resource
 			// template array. Existing composed resources should be annotated
 			// at render time with the name of the template used to create them.
-			return AssociateByOrder(comp.Spec.Resources, cr.GetResourceReferences()), nil
+			return AssociateByOrder(rs, cr.GetResourceReferences()), nil
 		}
 
 		// Inject the reference to this existing resource into the references
@@ -285,6 +1163,72 @@ type Observation struct {
 	Ready             bool
 }
 
+// A ComposedResourceStatus reports the status of a composed resource in the
+// status of its composite resource.
+type ComposedResourceStatus struct {
+	TemplateName string `json:"templateName,omitempty"`
+	Kind         string `json:"kind,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Ready        bool   `json:"ready"`
+	Synced       bool   `json:"synced"`
+	Message      string `json:"message,omitempty"`
+
+	// BlockedOn lists the names of the templates of any composed resources
+	// that must be ready before this composed resource can be applied.
+	BlockedOn []string `json:"blockedOn,omitempty"`
+}
+
+// NewComposedResourceStatus returns the status of the supplied composed
+// resource, which was composed using the supplied template at the supplied
+// index. The returned status is not ready; its message is that of the
+// composed resource's Synced condition if it is not synced, and otherwise that
+// of its Ready condition.
+func NewComposedResourceStatus(cd resource.Composed, t v1.ComposedTemplate, i int) ComposedResourceStatus {
+	synced := cd.GetCondition(xpv1.TypeSynced)
+	s := ComposedResourceStatus{
+		TemplateName: templateName(t, i),
+		Kind:         cd.GetObjectKind().GroupVersionKind().Kind,
+		Name:         cd.GetName(),
+		Synced:       resource.IsConditionTrue(synced),
+		Message:      cd.GetCondition(xpv1.TypeReady).Message,
+	}
+	if synced.Status == corev1.ConditionFalse {
+		s.Message = synced.Message
+	}
+	return s
+}
+
+// An unstructuredComposite is a composite resource backed by unstructured
+// data, which may hold fields unknown to the resource.Composite interface.
+type unstructuredComposite interface {
+	GetUnstructured() *kunstructured.Unstructured
+}
+
+// GetComposedResourceStatuses returns the statuses of the composed resources
+// of the supplied composite resource.
+func GetComposedResourceStatuses(cr resource.Composite) []ComposedResourceStatus {
+	u, ok := cr.(unstructuredComposite)
+	if !ok {
+		return nil
+	}
+	out := []ComposedResourceStatus{}
+	if err := fieldpath.Pave(u.GetUnstructured().Object).GetValueInto("status.resources", &out); err != nil {
+		return nil
+	}
+	return out
+}
+
+// SetComposedResourceStatuses sets the statuses of the composed resources of
+// the supplied composite resource. It is a no-op for composite resources that
+// are not unstructured.
+func SetComposedResourceStatuses(cr resource.Composite, s []ComposedResourceStatus) {
+	u, ok := cr.(unstructuredComposite)
+	if !ok {
+		return
+	}
+	_ = fieldpath.Pave(u.GetUnstructured().Object).SetValue("status.resources", s)
+}
+
 // A RenderFn renders the supplied composed resource.
 type RenderFn func(cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error
 
@@ -348,7 +1292,7 @@ func (r *APIDryRunRenderer) Render(ctx context.Context, cp resource.Composite, c
 	cd.SetName(name)
 	cd.SetNamespace(namespace)
 
-	onlyPatches := []v1.PatchType{v1.PatchTypeFromCompositeFieldPath}
+	onlyPatches := []v1.PatchType{v1.PatchTypeFromCompositeFieldPath, v1.PatchTypeCombineFromComposite}
 	for i, p := range t.Patches {
 		if err := p.Apply(cp, cd, onlyPatches...); err != nil {
 			return errors.Wrapf(err, errFmtPatch, i)
@@ -356,9 +1300,16 @@ func (r *APIDryRunRenderer) Render(ctx context.Context, cp resource.Composite, c
 	}
 
 	// We do this last to ensure that a Composition cannot influence owner (and
-	// especially controller) references.
-	or := meta.AsController(meta.TypedReferenceTo(cp, cp.GetObjectKind().GroupVersionKind()))
-	cd.SetOwnerReferences([]metav1.OwnerReference{or})
+	// especially controller) references. Composed resources that should
+	// outlive their composite resource have no owner references, so that the
+	// garbage collector can never delete them - even when their composite
+	// resource is deleted with foreground propagation. We set an empty rather
+	// than nil array so that any existing owner references are removed.
+	refs := []metav1.OwnerReference{}
+	if GetDeletionPolicy(cp, t) != xpv1.DeletionOrphan {
+		refs = append(refs, meta.AsController(meta.TypedReferenceTo(cp, cp.GetObjectKind().GroupVersionKind())))
+	}
+	cd.SetOwnerReferences(refs)
 
 	// We don't want to dry-run create a resource that can't be named by the API
 	// server due to a missing generate name. We also don't want to create one
@@ -378,12 +1329,89 @@ func (r *APIDryRunRenderer) Render(ctx context.Context, cp resource.Composite, c
 	return errors.Wrap(r.client.Create(ctx, cd, client.DryRunAll), errName)
 }
 
+// RenderFromEnvironment renders the supplied composed resource by applying all
+// FromEnvironmentFieldPath patches of the supplied template, using the supplied
+// environment as the source of each patch.
+func RenderFromEnvironment(_ context.Context, env *v1alpha1.EnvironmentConfig, cd resource.Composed, t v1.ComposedTemplate) error {
+	if env == nil {
+		env = &v1alpha1.EnvironmentConfig{}
+	}
+	for i, p := range t.Patches {
+		if err := p.Apply(env, cd, v1.PatchTypeFromEnvironmentFieldPath); err != nil {
+			return errors.Wrapf(err, errFmtPatch, i)
+		}
+	}
+	return nil
+}
+
+// An unavailableError indicates that a composed resource could not be rendered
+// because a composed resource it patches from is not yet available.
+type unavailableError struct {
+	error
+}
+
+// IsUnavailable returns true if the supplied error indicates that a composed
+// resource could not be rendered because a composed resource it patches from
+// is not yet available.
+func IsUnavailable(err error) bool {
+	return errors.As(err, &unavailableError{})
+}
+
+// RenderFromComposed renders the supplied composed resource by applying all
+// FromComposedFieldPath patches of the supplied template. The supplied observed
+// composed resources, keyed by the name of their template, are used as the
+// source of each patch. An error for which IsUnavailable returns true is
+// returned if a patch's source resource has not been observed, or if it lacks
+// the field a patch with a Required policy patches from.
+func RenderFromComposed(_ context.Context, observed map[string]resource.Composed, cd resource.Composed, t v1.ComposedTemplate) error {
+	for i, p := range t.Patches {
+		if p.Type != v1.PatchTypeFromComposedFieldPath {
+			continue
+		}
+		if p.FromResourceName == nil {
+			return errors.Wrapf(errors.New(errComposedPatchName), errFmtPatch, i)
+		}
+		from, ok := observed[*p.FromResourceName]
+		if !ok {
+			return unavailableError{errors.Errorf(errFmtComposedMissing, *p.FromResourceName)}
+		}
+		err := p.Apply(from, cd, v1.PatchTypeFromComposedFieldPath)
+		if fieldpath.IsNotFound(err) {
+			return unavailableError{errors.Wrapf(err, errFmtComposedFieldPath, *p.FromResourceName)}
+		}
+		if err != nil {
+			return errors.Wrapf(err, errFmtPatch, i)
+		}
+	}
+	return nil
+}
+
 // RenderComposite renders the supplied composite resource using the supplied composed
-// resource and template.
-func RenderComposite(_ context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
-	onlyPatches := []v1.PatchType{v1.PatchTypeToCompositeFieldPath}
+// resource and template. The variables of a CombineToComposite patch may be
+// read from the supplied observed composed resources, keyed by the name of
+// their template. An error for which IsUnavailable returns true is returned if
+// a variable's resource has not been observed, or lacks the field a required
+// variable is read from.
+func RenderComposite(_ context.Context, cp resource.Composite, observed map[string]resource.Composed, cd resource.Composed, t v1.ComposedTemplate) error {
+	composed := make(map[string]runtime.Object, len(observed))
+	for name, o := range observed {
+		composed[name] = o
+	}
 	for i, p := range t.Patches {
-		if err := p.Apply(cp, cd, onlyPatches...); err != nil {
+		var err error
+		switch p.Type {
+		case v1.PatchTypeToCompositeFieldPath:
+			err = p.Apply(cp, cd, v1.PatchTypeToCompositeFieldPath)
+		case v1.PatchTypeCombineToComposite:
+			if name := unobservedVariableResource(p, observed); name != "" {
+				return unavailableError{errors.Wrapf(errors.Errorf(errFmtComposedMissing, name), errFmtPatch, i)}
+			}
+			err = p.ApplyCombineToComposite(cp, cd, composed)
+			if fieldpath.IsNotFound(err) {
+				return unavailableError{errors.Wrapf(err, errFmtPatch, i)}
+			}
+		}
+		if err != nil {
 			return errors.Wrapf(err, errFmtPatch, i)
 		}
 	}
@@ -391,6 +1419,24 @@ func RenderComposite(_ context.Context, cp resource.Composite, cd resource.Compo
 	return nil
 }
 
+// unobservedVariableResource returns the name of the first resource template
+// named by a variable of the supplied combine patch whose composed resource
+// has not been observed, if any.
+func unobservedVariableResource(p v1.Patch, observed map[string]resource.Composed) string {
+	if p.Combine == nil {
+		return ""
+	}
+	for _, v := range p.Combine.Variables {
+		if v.FromResourceName == nil {
+			continue
+		}
+		if _, ok := observed[*v.FromResourceName]; !ok {
+			return *v.FromResourceName
+		}
+	}
+	return ""
+}
+
 // An APIConnectionDetailsFetcher may use the API server to read connection
 // details from a Secret.
 type APIConnectionDetailsFetcher struct {
@@ -422,45 +1468,24 @@ func (cdf *APIConnectionDetailsFetcher) FetchConnectionDetails(ctx context.Conte
 	conn := managed.ConnectionDetails{}
 
 	for _, d := range t.ConnectionDetails {
-		switch tp := connectionDetailType(d); tp {
-		case v1.ConnectionDetailTypeFromValue:
-			// Name, Value must be set if value type
-			switch {
-			case d.Name == nil:
-				return nil, errors.Errorf(errFmtConnDetailKey, tp)
-			case d.Value == nil:
-				return nil, errors.Errorf(errFmtConnDetailVal, tp)
-			default:
-				conn[*d.Name] = []byte(*d.Value)
-			}
-		case v1.ConnectionDetailTypeFromConnectionSecretKey:
-			if d.FromConnectionSecretKey == nil {
-				return nil, errors.Errorf(errFmtConnDetailKey, tp)
-			}
-			if data[*d.FromConnectionSecretKey] == nil {
-				// We don't consider this an error because it's possible the
-				// key will still be written at some point in the future.
-				continue
-			}
-			key := *d.FromConnectionSecretKey
-			if d.Name != nil {
-				key = *d.Name
-			}
-			if key != "" {
-				conn[key] = data[*d.FromConnectionSecretKey]
-			}
-		case v1.ConnectionDetailTypeFromFieldPath:
-			switch {
-			case d.Name == nil:
-				return nil, errors.Errorf(errFmtConnDetailKey, tp)
-			case d.FromFieldPath == nil:
-				return nil, errors.Errorf(errFmtConnDetailPath, tp)
-			default:
-				_ = extractFieldPathValue(cd, d, conn)
+		key, val, err := connectionDetailValue(cd, data, d)
+		if err != nil {
+			return nil, err
+		}
+		if key == "" || val == nil {
+			// The value of this connection detail is not (yet) available.
+			continue
+		}
+		for i := range d.Transforms {
+			if val, err = d.Transforms[i].Transform(val); err != nil {
+				return nil, errors.Wrapf(err, errFmtConnDetailTransform, i, key)
 			}
-		case v1.ConnectionDetailTypeUnknown:
-			// We weren't able to determine the type of this connection detail.
 		}
+		b, err := connectionDetailBytes(val)
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtConnDetailBytes, key)
+		}
+		conn[key] = b
 	}
 
 	if len(conn) == 0 {
@@ -483,34 +1508,104 @@ func connectionDetailType(d v1.ConnectionDetail) v1.ConnectionDetailType {
 		return v1.ConnectionDetailTypeFromConnectionSecretKey
 	case d.FromFieldPath != nil:
 		return v1.ConnectionDetailTypeFromFieldPath
+	case d.Combine != nil:
+		return v1.ConnectionDetailTypeCombine
 	default:
 		return v1.ConnectionDetailTypeUnknown
 	}
 }
 
-func extractFieldPathValue(from runtime.Object, detail v1.ConnectionDetail, conn managed.ConnectionDetails) error {
-	fromMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(from)
-	if err != nil {
-		return err
-	}
-
-	str, err := fieldpath.Pave(fromMap).GetString(*detail.FromFieldPath)
-	if err == nil {
-		conn[*detail.Name] = []byte(str)
-		return nil
+// connectionDetailValue returns the key and value of the supplied connection
+// detail. The returned value is nil if it is not yet available.
+func connectionDetailValue(cd resource.Composed, data map[string][]byte, d v1.ConnectionDetail) (string, interface{}, error) { // nolint:gocyclo
+	switch tp := connectionDetailType(d); tp {
+	case v1.ConnectionDetailTypeFromValue:
+		// Name, Value must be set if value type
+		switch {
+		case d.Name == nil:
+			return "", nil, errors.Errorf(errFmtConnDetailKey, tp)
+		case d.Value == nil:
+			return "", nil, errors.Errorf(errFmtConnDetailVal, tp)
+		default:
+			return *d.Name, *d.Value, nil
+		}
+	case v1.ConnectionDetailTypeFromConnectionSecretKey:
+		if d.FromConnectionSecretKey == nil {
+			return "", nil, errors.Errorf(errFmtConnDetailKey, tp)
+		}
+		v, ok := data[*d.FromConnectionSecretKey]
+		if !ok || v == nil {
+			// We don't consider this an error because it's possible the
+			// key will still be written at some point in the future.
+			return "", nil, nil
+		}
+		key := *d.FromConnectionSecretKey
+		if d.Name != nil {
+			key = *d.Name
+		}
+		return key, string(v), nil
+	case v1.ConnectionDetailTypeFromFieldPath:
+		switch {
+		case d.Name == nil:
+			return "", nil, errors.Errorf(errFmtConnDetailKey, tp)
+		case d.FromFieldPath == nil:
+			return "", nil, errors.Errorf(errFmtConnDetailPath, tp)
+		}
+		// We don't consider a missing field an error because it's
+		// possible the field will still be set in the future.
+		v, _ := extractFieldPathValue(cd, *d.FromFieldPath)
+		return *d.Name, v, nil
+	case v1.ConnectionDetailTypeCombine:
+		switch {
+		case d.Name == nil:
+			return "", nil, errors.Errorf(errFmtConnDetailKey, tp)
+		case d.Combine == nil:
+			return "", nil, errors.Errorf(errFmtConnDetailCombine, tp)
+		}
+		vars := make([]interface{}, len(d.Combine.Variables))
+		for i, cv := range d.Combine.Variables {
+			var v interface{}
+			switch {
+			case cv.FromConnectionSecretKey != nil:
+				if b, ok := data[*cv.FromConnectionSecretKey]; ok && b != nil {
+					v = string(b)
+				}
+			case cv.FromFieldPath != nil:
+				v, _ = extractFieldPathValue(cd, *cv.FromFieldPath)
+			case cv.Value != nil:
+				v = *cv.Value
+			default:
+				return "", nil, errors.Errorf(errFmtConnDetailVar, i, *d.Name)
+			}
+			if v == nil {
+				// We only combine the variables once they're all available.
+				return "", nil, nil
+			}
+			vars[i] = v
+		}
+		v, err := d.Combine.Combine(vars)
+		return *d.Name, v, errors.Wrapf(err, errFmtConnDetailCombineFailed, *d.Name)
+	case v1.ConnectionDetailTypeUnknown:
+		// We weren't able to determine the type of this connection detail.
 	}
+	return "", nil, nil
+}
 
-	in, err := fieldpath.Pave(fromMap).GetValue(*detail.FromFieldPath)
-	if err != nil {
-		return err
+// connectionDetailBytes returns the supplied connection detail value as bytes.
+// Strings are used verbatim, while any other value is encoded as JSON.
+func connectionDetailBytes(v interface{}) ([]byte, error) {
+	if s, ok := v.(string); ok {
+		return []byte(s), nil
 	}
+	return json.Marshal(v)
+}
 
-	buffer, err := json.Marshal(in)
+func extractFieldPathValue(from runtime.Object, path string) (interface{}, error) {
+	fromMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(from)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	conn[*detail.Name] = buffer
-	return nil
+	return fieldpath.Pave(fromMap).GetValue(path)
 }
 
 // IsReady returns whether the composed resource is ready.
@@ -553,6 +1648,29 @@ func IsReady(_ context.Context, cd resource.Composed, t v1.ComposedTemplate) (bo
 				return false, err
 			}
 			ready = !fieldpath.IsNotFound(err) && val == check.MatchInteger
+		case v1.ReadinessCheckTypeMatchIntegerGreaterThanOrEqual:
+			val, err := paved.GetInteger(check.FieldPath)
+			if resource.Ignore(fieldpath.IsNotFound, err) != nil {
+				return false, err
+			}
+			ready = !fieldpath.IsNotFound(err) && val >= check.MatchInteger
+		case v1.ReadinessCheckTypeMatchIntegerLessThanOrEqual:
+			val, err := paved.GetInteger(check.FieldPath)
+			if resource.Ignore(fieldpath.IsNotFound, err) != nil {
+				return false, err
+			}
+			ready = !fieldpath.IsNotFound(err) && val <= check.MatchInteger
+		case v1.ReadinessCheckTypeMatchTrue, v1.ReadinessCheckTypeMatchFalse:
+			val, err := paved.GetBool(check.FieldPath)
+			if resource.Ignore(fieldpath.IsNotFound, err) != nil {
+				return false, err
+			}
+			ready = !fieldpath.IsNotFound(err) && val == (check.Type == v1.ReadinessCheckTypeMatchTrue)
+		case v1.ReadinessCheckTypeMatchCondition:
+			if check.MatchCondition == nil {
+				return false, errors.Errorf(errFmtMatchCondition, i)
+			}
+			ready = cd.GetCondition(check.MatchCondition.Type).Status == check.MatchCondition.Status
 		default:
 			return false, errors.New(fmt.Sprintf("readiness check at index %d: an unknown type is chosen", i))
 		}
@@ -19,10 +19,12 @@ package composite
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -33,12 +35,15 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
 	"github.com/crossplane/crossplane/internal/xcrd"
 )
 
@@ -166,363 +171,2146 @@ func TestRejectDuplicateNames(t *testing.T) {
 	}
 }
 
+func TestRejectInvalidTransforms(t *testing.T) {
+	two := int64(2)
+	zero := int64(0)
+	valid := v1.Transform{
+		Type: v1.TransformTypeMath,
+		Math: &v1.MathTransform{Type: v1.MathTransformTypeDivide, Divide: &two},
+	}
+	invalid := v1.Transform{
+		Type: v1.TransformTypeMath,
+		Math: &v1.MathTransform{Type: v1.MathTransformTypeDivide, Divide: &zero},
+	}
+
+	cases := map[string]struct {
+		comp *v1.Composition
+		want error
+	}{
+		"Valid": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					PatchSets: []v1.PatchSet{{
+						Name:    "cool",
+						Patches: []v1.Patch{{Transforms: []v1.Transform{valid}}},
+					}},
+					Resources: []v1.ComposedTemplate{{
+						Patches: []v1.Patch{{Transforms: []v1.Transform{valid}}},
+					}},
+				},
+			},
+			want: nil,
+		},
+		"InvalidPatchSetTransform": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					PatchSets: []v1.PatchSet{{
+						Name:    "cool",
+						Patches: []v1.Patch{{Transforms: []v1.Transform{valid, invalid}}},
+					}},
+				},
+			},
+			want: errors.Wrapf(invalid.Validate(), errFmtPatchSetTransform, 1, 0, "cool"),
+		},
+		"InvalidResourceTransform": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources: []v1.ComposedTemplate{
+						{},
+						{
+							Patches: []v1.Patch{
+								{},
+								{Transforms: []v1.Transform{invalid}},
+							},
+						},
+					},
+				},
+			},
+			want: errors.Wrapf(invalid.Validate(), errFmtResourceTransform, 0, 1, 1),
+		},
+		"InvalidConnectionDetailTransform": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources: []v1.ComposedTemplate{{
+						ConnectionDetails: []v1.ConnectionDetail{
+							{Transforms: []v1.Transform{valid}},
+							{Transforms: []v1.Transform{valid, invalid}},
+						},
+					}},
+				},
+			},
+			want: errors.Wrapf(invalid.Validate(), errFmtConnDetailTransformInvalid, 1, 1, 0),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := RejectInvalidTransforms(tc.comp)
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\nRejectInvalidTransforms(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRejectInvalidComposedPatches(t *testing.T) {
+	fromVPC := v1.Patch{
+		Type:             v1.PatchTypeFromComposedFieldPath,
+		FromResourceName: pointer.StringPtr("vpc"),
+		FromFieldPath:    pointer.StringPtr("status.atProvider.id"),
+		ToFieldPath:      pointer.StringPtr("spec.forProvider.vpcId"),
+	}
+	combineFrom := func(name string) v1.Patch {
+		return v1.Patch{
+			Type: v1.PatchTypeCombineToComposite,
+			Combine: &v1.Combine{
+				Variables: []v1.CombineVariable{{FromResourceName: pointer.StringPtr(name), FromFieldPath: "status.atProvider.id"}},
+				Strategy:  v1.CombineStrategyString,
+				String:    &v1.StringCombine{Format: "%s"},
+			},
+			ToFieldPath: pointer.StringPtr("status.id"),
+		}
+	}
+
+	cases := map[string]struct {
+		comp *v1.Composition
+		want error
+	}{
+		"Valid": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources: []v1.ComposedTemplate{
+						{Name: pointer.StringPtr("vpc")},
+						{Name: pointer.StringPtr("subnet"), Patches: []v1.Patch{fromVPC}},
+					},
+				},
+			},
+			want: nil,
+		},
+		"MissingResourceName": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources: []v1.ComposedTemplate{
+						{Name: pointer.StringPtr("subnet"), Patches: []v1.Patch{
+							{},
+							{Type: v1.PatchTypeFromComposedFieldPath},
+						}},
+					},
+				},
+			},
+			want: errors.Errorf(errFmtComposedPatchName, 1, 0),
+		},
+		"DeclaredAfter": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources: []v1.ComposedTemplate{
+						{Name: pointer.StringPtr("subnet"), Patches: []v1.Patch{fromVPC}},
+						{Name: pointer.StringPtr("vpc")},
+					},
+				},
+			},
+			want: errors.Errorf(errFmtComposedPatch, 0, 0, "vpc"),
+		},
+		"PatchesFromItself": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources: []v1.ComposedTemplate{
+						{Name: pointer.StringPtr("vpc"), Patches: []v1.Patch{fromVPC}},
+					},
+				},
+			},
+			want: errors.Errorf(errFmtComposedPatch, 0, 0, "vpc"),
+		},
+		"CombineDeclaredAfter": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources: []v1.ComposedTemplate{
+						{Name: pointer.StringPtr("vpc"), Patches: []v1.Patch{combineFrom("subnet")}},
+						{Name: pointer.StringPtr("subnet")},
+					},
+				},
+			},
+			want: nil,
+		},
+		"CombineUndeclared": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources: []v1.ComposedTemplate{
+						{Name: pointer.StringPtr("vpc"), Patches: []v1.Patch{combineFrom("subnet")}},
+					},
+				},
+			},
+			want: errors.Errorf(errFmtCombineResource, 0, 0, 0, "subnet"),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := RejectInvalidComposedPatches(tc.comp)
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\nRejectInvalidComposedPatches(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRejectAnonymousConditionalTemplates(t *testing.T) {
+	cond := &v1.ComposedTemplateCondition{Type: v1.ComposedTemplateConditionTypeFieldPathExists, FieldPath: "spec.ha"}
+
+	cases := map[string]struct {
+		comp *v1.Composition
+		want error
+	}{
+		"Named": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources: []v1.ComposedTemplate{
+						{Name: pointer.StringPtr("cool")},
+						{Name: pointer.StringPtr("replica"), Condition: cond},
+					},
+				},
+			},
+			want: nil,
+		},
+		"Anonymous": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources: []v1.ComposedTemplate{
+						{},
+						{Condition: cond},
+					},
+				},
+			},
+			want: errors.Errorf(errFmtConditionName, 1),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := RejectAnonymousConditionalTemplates(tc.comp)
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\nRejectAnonymousConditionalTemplates(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRejectInvalidForEach(t *testing.T) {
+	fe := &v1.ForEach{FieldPath: "spec.buckets"}
+	elementPatch := v1.Patch{Type: v1.PatchTypeFromElementFieldPath, FromFieldPath: pointer.StringPtr("element")}
+
+	cases := map[string]struct {
+		comp *v1.Composition
+		want error
+	}{
+		"Valid": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources: []v1.ComposedTemplate{
+						{Name: pointer.StringPtr("cool")},
+						{Name: pointer.StringPtr("bucket"), ForEach: fe, Patches: []v1.Patch{elementPatch}},
+					},
+				},
+			},
+			want: nil,
+		},
+		"Anonymous": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources: []v1.ComposedTemplate{{ForEach: fe}},
+				},
+			},
+			want: errors.Errorf(errFmtForEachName, 0),
+		},
+		"MissingKeyFieldPath": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources: []v1.ComposedTemplate{{
+						Name:    pointer.StringPtr("bucket"),
+						ForEach: &v1.ForEach{FieldPath: "spec.buckets", Key: v1.ForEachKeyTypeFieldPath},
+					}},
+				},
+			},
+			want: errors.Errorf(errFmtForEachKeyPath, 0, v1.ForEachKeyTypeFieldPath),
+		},
+		"ElementPatchWithoutForEach": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources: []v1.ComposedTemplate{{
+						Name:    pointer.StringPtr("bucket"),
+						Patches: []v1.Patch{{}, elementPatch},
+					}},
+				},
+			},
+			want: errors.Errorf(errFmtElementPatch, 1, 0),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := RejectInvalidForEach(tc.comp)
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\nRejectInvalidForEach(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRejectInvalidDependencies(t *testing.T) {
+	cases := map[string]struct {
+		comp *v1.Composition
+		want error
+	}{
+		"Valid": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources: []v1.ComposedTemplate{
+						{Name: pointer.StringPtr("vpc")},
+						{Name: pointer.StringPtr("subnet"), DependsOn: []string{"vpc"}},
+						{Name: pointer.StringPtr("cluster"), DependsOn: []string{"vpc", "subnet"}},
+					},
+				},
+			},
+			want: nil,
+		},
+		"Anonymous": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources: []v1.ComposedTemplate{{DependsOn: []string{"vpc"}}},
+				},
+			},
+			want: errors.Errorf(errFmtDependsOnName, 0),
+		},
+		"UnknownDependency": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources: []v1.ComposedTemplate{
+						{Name: pointer.StringPtr("subnet"), DependsOn: []string{"vpc"}},
+					},
+				},
+			},
+			want: errors.Errorf(errFmtDependsOnUnknown, 0, "vpc"),
+		},
+		"ForEachDependency": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources: []v1.ComposedTemplate{
+						{Name: pointer.StringPtr("vpc"), ForEach: &v1.ForEach{FieldPath: "spec.vpcs"}},
+						{Name: pointer.StringPtr("subnet"), DependsOn: []string{"vpc"}},
+					},
+				},
+			},
+			want: errors.Errorf(errFmtDependsOnForEach, 1, "vpc"),
+		},
+		"Cyclic": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources: []v1.ComposedTemplate{
+						{Name: pointer.StringPtr("vpc"), DependsOn: []string{"vpc"}},
+					},
+				},
+			},
+			want: errors.Wrap(errors.New("detected cycle on: vpc"), errDependsOn),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := RejectInvalidDependencies(tc.comp)
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\nRejectInvalidDependencies(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestApplyOrder(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		tas    []TemplateAssociation
+		want   []int
+	}{
+		"DeclarationOrder": {
+			reason: "Resources without dependencies should be applied in the order their templates are declared.",
+			tas: []TemplateAssociation{
+				{Template: v1.ComposedTemplate{}},
+				{Template: v1.ComposedTemplate{Name: pointer.StringPtr("a")}},
+				{Template: v1.ComposedTemplate{}},
+			},
+			want: []int{0, 1, 2},
+		},
+		"Dependencies": {
+			reason: "Resources should be applied after the resources they depend on.",
+			tas: []TemplateAssociation{
+				{Template: v1.ComposedTemplate{Name: pointer.StringPtr("cluster"), DependsOn: []string{"subnet"}}},
+				{Template: v1.ComposedTemplate{Name: pointer.StringPtr("subnet"), DependsOn: []string{"vpc"}}},
+				{Template: v1.ComposedTemplate{Name: pointer.StringPtr("vpc")}},
+				{Template: v1.ComposedTemplate{Name: pointer.StringPtr("bucket")}},
+			},
+			want: []int{2, 1, 0, 3},
+		},
+		"ExcludedDependency": {
+			reason: "Dependencies on templates that are not associated should be ignored.",
+			tas: []TemplateAssociation{
+				{Template: v1.ComposedTemplate{Name: pointer.StringPtr("subnet"), DependsOn: []string{"vpc"}}},
+			},
+			want: []int{0},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := applyOrder(tc.tas)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\napplyOrder(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestBlockedOn(t *testing.T) {
+	tmpl := v1.ComposedTemplate{DependsOn: []string{"vpc", "subnet", "excluded"}}
+
+	cases := map[string]struct {
+		reason   string
+		included map[string]bool
+		ready    map[string]bool
+		want     []string
+	}{
+		"NotBlocked": {
+			reason:   "A resource whose dependencies are all ready should not be blocked.",
+			included: map[string]bool{"vpc": true, "subnet": true},
+			ready:    map[string]bool{"vpc": true, "subnet": true},
+			want:     nil,
+		},
+		"Blocked": {
+			reason:   "A resource should be blocked on each included dependency that is not ready.",
+			included: map[string]bool{"vpc": true, "subnet": true},
+			ready:    map[string]bool{"vpc": true},
+			want:     []string{"subnet"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := blockedOn(tmpl, tc.included, tc.ready)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nblockedOn(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestExpandForEach(t *testing.T) {
+	now := metav1.NewTime(time.Unix(0, 0))
+	cr := &fake.Composite{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "cool",
+			Finalizers: []string{"a", "b", "a"},
+			OwnerReferences: []metav1.OwnerReference{
+				{Name: "x", UID: "uid-x"},
+				{Name: "y", UID: "uid-y"},
+			},
+		},
+		// Converting a fake composite to unstructured panics if this is nil.
+		ConnectionDetailsLastPublishedTimer: fake.ConnectionDetailsLastPublishedTimer{Time: &now},
+	}
+
+	base := runtime.RawExtension{Raw: []byte(`{"apiVersion":"v","kind":"K"}`)}
+	plain := v1.ComposedTemplate{Name: pointer.StringPtr("plain"), Base: base}
+	patches := []v1.Patch{
+		{
+			Type:          v1.PatchTypeFromElementFieldPath,
+			FromFieldPath: pointer.StringPtr("element.uid"),
+			ToFieldPath:   pointer.StringPtr("spec.uid"),
+		},
+		{
+			Type:          v1.PatchTypeFromElementFieldPath,
+			FromFieldPath: pointer.StringPtr("index"),
+			ToFieldPath:   pointer.StringPtr("spec.index"),
+		},
+		{
+			Type:          v1.PatchTypeFromCompositeFieldPath,
+			FromFieldPath: pointer.StringPtr("objectMeta.name"),
+			ToFieldPath:   pointer.StringPtr("spec.composite"),
+		},
+	}
+
+	type want struct {
+		ts  []v1.ComposedTemplate
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		ts     []v1.ComposedTemplate
+		want   want
+	}{
+		"NoForEach": {
+			reason: "Templates that do not specify forEach should be returned as is",
+			ts:     []v1.ComposedTemplate{plain},
+			want:   want{ts: []v1.ComposedTemplate{plain}},
+		},
+		"FieldPathNotFound": {
+			reason: "A template should expand to nothing if its forEach field path does not exist",
+			ts: []v1.ComposedTemplate{
+				plain,
+				{Name: pointer.StringPtr("bucket"), Base: base, ForEach: &v1.ForEach{FieldPath: "spec.buckets"}},
+			},
+			want: want{ts: []v1.ComposedTemplate{plain}},
+		},
+		"NotAnArray": {
+			reason: "An error should be returned if the forEach field path is not an array",
+			ts: []v1.ComposedTemplate{
+				{Name: pointer.StringPtr("bucket"), Base: base, ForEach: &v1.ForEach{FieldPath: "objectMeta.name"}},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Errorf(errFmtForEachNotArray, "objectMeta.name"), errFmtForEach, "bucket"),
+			},
+		},
+		"DuplicateKey": {
+			reason: "An error should be returned if two elements have the same key",
+			ts: []v1.ComposedTemplate{
+				{Name: pointer.StringPtr("bucket"), Base: base, ForEach: &v1.ForEach{FieldPath: "objectMeta.finalizers", Key: v1.ForEachKeyTypeValue}},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Errorf(errFmtForEachDuplicate, "a", 2), errFmtForEach, "bucket"),
+			},
+		},
+		"NonScalarKey": {
+			reason: "An error should be returned if an element's key is not a scalar value",
+			ts: []v1.ComposedTemplate{
+				{Name: pointer.StringPtr("bucket"), Base: base, ForEach: &v1.ForEach{FieldPath: "objectMeta.ownerReferences", Key: v1.ForEachKeyTypeValue}},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Errorf(errFmtForEachKeyScalar, 0), errFmtForEach, "bucket"),
+			},
+		},
+		"ExpandByIndex": {
+			reason: "A template should be expanded once per element, identified by index",
+			ts: []v1.ComposedTemplate{
+				{Name: pointer.StringPtr("bucket"), Base: base, Patches: patches, ForEach: &v1.ForEach{FieldPath: "objectMeta.ownerReferences"}},
+			},
+			want: want{ts: []v1.ComposedTemplate{
+				{
+					Name:    pointer.StringPtr("bucket[0]"),
+					Base:    runtime.RawExtension{Raw: []byte(`{"apiVersion":"v","kind":"K","spec":{"index":0,"uid":"uid-x"}}`)},
+					Patches: patches,
+				},
+				{
+					Name:    pointer.StringPtr("bucket[1]"),
+					Base:    runtime.RawExtension{Raw: []byte(`{"apiVersion":"v","kind":"K","spec":{"index":1,"uid":"uid-y"}}`)},
+					Patches: patches,
+				},
+			}},
+		},
+		"ExpandByFieldPath": {
+			reason: "A template should be expanded once per element, identified by the value of its key field path",
+			ts: []v1.ComposedTemplate{
+				{
+					Name:    pointer.StringPtr("bucket"),
+					Base:    base,
+					Patches: patches[:1],
+					ForEach: &v1.ForEach{FieldPath: "objectMeta.ownerReferences", Key: v1.ForEachKeyTypeFieldPath, KeyFieldPath: pointer.StringPtr("name")},
+				},
+			},
+			want: want{ts: []v1.ComposedTemplate{
+				{
+					Name:    pointer.StringPtr("bucket[x]"),
+					Base:    runtime.RawExtension{Raw: []byte(`{"apiVersion":"v","kind":"K","spec":{"uid":"uid-x"}}`)},
+					Patches: patches[:1],
+				},
+				{
+					Name:    pointer.StringPtr("bucket[y]"),
+					Base:    runtime.RawExtension{Raw: []byte(`{"apiVersion":"v","kind":"K","spec":{"uid":"uid-y"}}`)},
+					Patches: patches[:1],
+				},
+			}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := ExpandForEach(cr, tc.ts)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nExpandForEach(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.ts, got); diff != "" {
+				t.Errorf("\n%s\nExpandForEach(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestIsIncluded(t *testing.T) {
+	now := metav1.NewTime(time.Unix(0, 0))
+	cr := &fake.Composite{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "cool",
+			Generation: 2,
+			Labels:     map[string]string{"tier": "gold"},
+		},
+		// Converting a fake composite to unstructured panics if this is nil.
+		ConnectionDetailsLastPublishedTimer: fake.ConnectionDetailsLastPublishedTimer{Time: &now},
+	}
+
+	type want struct {
+		included bool
+		err      error
+	}
+	cases := map[string]struct {
+		reason string
+		c      *v1.ComposedTemplateCondition
+		want   want
+	}{
+		"NoCondition": {
+			reason: "A template without a condition should always be included",
+			want:   want{included: true},
+		},
+		"FieldPathExists": {
+			reason: "A FieldPathExists condition should be met if the field path exists",
+			c:      &v1.ComposedTemplateCondition{Type: v1.ComposedTemplateConditionTypeFieldPathExists, FieldPath: "objectMeta.labels[tier]"},
+			want:   want{included: true},
+		},
+		"FieldPathDoesNotExist": {
+			reason: "A condition should not be met if the field path does not exist",
+			c:      &v1.ComposedTemplateCondition{Type: v1.ComposedTemplateConditionTypeMatchTrue, FieldPath: "spec.highAvailability"},
+			want:   want{included: false},
+		},
+		"MatchString": {
+			reason: "A MatchString condition should be met if the field matches",
+			c:      &v1.ComposedTemplateCondition{Type: v1.ComposedTemplateConditionTypeMatchString, FieldPath: "objectMeta.labels[tier]", MatchString: "gold"},
+			want:   want{included: true},
+		},
+		"MatchStringMismatch": {
+			reason: "A MatchString condition should not be met if the field does not match",
+			c:      &v1.ComposedTemplateCondition{Type: v1.ComposedTemplateConditionTypeMatchString, FieldPath: "objectMeta.labels[tier]", MatchString: "silver"},
+			want:   want{included: false},
+		},
+		"MatchInteger": {
+			reason: "A MatchInteger condition should be met if the field matches",
+			c:      &v1.ComposedTemplateCondition{Type: v1.ComposedTemplateConditionTypeMatchInteger, FieldPath: "objectMeta.generation", MatchInteger: 2},
+			want:   want{included: true},
+		},
+		"MatchTrueWrongType": {
+			reason: "An error should be returned if a MatchTrue condition's field is not a boolean",
+			c:      &v1.ComposedTemplateCondition{Type: v1.ComposedTemplateConditionTypeMatchTrue, FieldPath: "objectMeta.name"},
+			want: want{
+				err: errors.New("objectMeta.name: not a bool"),
+			},
+		},
+		"UnknownType": {
+			reason: "An error should be returned if a condition's type is unknown",
+			c:      &v1.ComposedTemplateCondition{Type: "Unknown"},
+			want: want{
+				err: errors.Errorf(errFmtConditionType, "Unknown"),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := IsIncluded(cr, v1.ComposedTemplate{Condition: tc.c})
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nIsIncluded(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.included, got); diff != "" {
+				t.Errorf("\n%s\nIsIncluded(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
 func TestRender(t *testing.T) {
 	ctrl := true
+	orphan := xpv1.DeletionOrphan
 	tmpl, _ := json.Marshal(&fake.Managed{})
 
-	type args struct {
-		ctx context.Context
-		cp  resource.Composite
-		cd  resource.Composed
-		t   v1.ComposedTemplate
+	type args struct {
+		ctx context.Context
+		cp  resource.Composite
+		cd  resource.Composed
+		t   v1.ComposedTemplate
+	}
+	type want struct {
+		cd  resource.Composed
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		client client.Client
+		args
+		want
+	}{
+		"InvalidTemplate": {
+			reason: "Invalid template should not be accepted",
+			args: args{
+				cd: &fake.Composed{},
+				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte("olala")}},
+			},
+			want: want{
+				cd:  &fake.Composed{},
+				err: errors.Wrap(errors.New("invalid character 'o' looking for beginning of value"), errUnmarshal),
+			},
+		},
+		"NoLabel": {
+			reason: "The name prefix label has to be set",
+			args: args{
+				cp: &fake.Composite{},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}},
+			},
+			want: want{
+				cd:  &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				err: errors.New(errNamePrefix),
+			},
+		},
+		"DryRunError": {
+			reason: "Errors dry-run creating the rendered resource to name it should be returned",
+			client: &test.MockClient{MockCreate: test.NewMockCreateFn(errBoom)},
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					xcrd.LabelKeyNamePrefixForComposed: "ola",
+					xcrd.LabelKeyClaimName:             "rola",
+					xcrd.LabelKeyClaimNamespace:        "rolans",
+				}}},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{}},
+				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					GenerateName: "ola-",
+					Labels: map[string]string{
+						xcrd.LabelKeyNamePrefixForComposed: "ola",
+						xcrd.LabelKeyClaimName:             "rola",
+						xcrd.LabelKeyClaimNamespace:        "rolans",
+					},
+					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl}},
+				}},
+				err: errors.Wrap(errBoom, errName),
+			},
+		},
+		"Success": {
+			reason: "Configuration should result in the right object with correct generateName",
+			client: &test.MockClient{MockCreate: test.NewMockCreateFn(nil)},
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					xcrd.LabelKeyNamePrefixForComposed: "ola",
+					xcrd.LabelKeyClaimName:             "rola",
+					xcrd.LabelKeyClaimNamespace:        "rolans",
+				}}},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:         "cd",
+					GenerateName: "ola-",
+					Labels: map[string]string{
+						xcrd.LabelKeyNamePrefixForComposed: "ola",
+						xcrd.LabelKeyClaimName:             "rola",
+						xcrd.LabelKeyClaimNamespace:        "rolans",
+					},
+					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl}},
+				}},
+			},
+		},
+		"OrphanPolicy": {
+			reason: "Resources that should be orphaned should not have any owner references, including existing ones",
+			client: &test.MockClient{MockCreate: test.NewMockCreateFn(nil)},
+			args: args{
+				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+					xcrd.LabelKeyNamePrefixForComposed: "ola",
+					xcrd.LabelKeyClaimName:             "rola",
+					xcrd.LabelKeyClaimNamespace:        "rolans",
+				}}},
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:            "cd",
+					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl}},
+				}},
+				t: v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}, DeletionPolicy: &orphan},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:         "cd",
+					GenerateName: "ola-",
+					Labels: map[string]string{
+						xcrd.LabelKeyNamePrefixForComposed: "ola",
+						xcrd.LabelKeyClaimName:             "rola",
+						xcrd.LabelKeyClaimNamespace:        "rolans",
+					},
+					OwnerReferences: []metav1.OwnerReference{},
+				}},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := NewAPIDryRunRenderer(tc.client)
+			err := r.Render(tc.args.ctx, tc.args.cp, tc.args.cd, tc.args.t)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.cd, tc.args.cd); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRenderFromEnvironment(t *testing.T) {
+	errNotFound := func(path string) error {
+		_, err := fieldpath.Pave(map[string]interface{}{}).GetValue(path)
+		return err
+	}
+	required := v1.FromFieldPathPolicyRequired
+
+	env := &v1alpha1.EnvironmentConfig{
+		Data: map[string]extv1.JSON{"region": {Raw: []byte(`"us-east-1"`)}},
+	}
+
+	type args struct {
+		env *v1alpha1.EnvironmentConfig
+		cd  resource.Composed
+		t   v1.ComposedTemplate
+	}
+	type want struct {
+		cd  resource.Composed
+		err error
+	}
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"NilEnvironmentOptional": {
+			reason: "Optional patches from a nil environment should be a no-op",
+			args: args{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				t: v1.ComposedTemplate{Patches: []v1.Patch{{
+					Type:          v1.PatchTypeFromEnvironmentFieldPath,
+					FromFieldPath: pointer.StringPtr("data.region"),
+					ToFieldPath:   pointer.StringPtr("objectMeta.labels.region"),
+				}}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+		},
+		"NilEnvironmentRequired": {
+			reason: "Required patches from a nil environment should return an error",
+			args: args{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				t: v1.ComposedTemplate{Patches: []v1.Patch{{
+					Type:          v1.PatchTypeFromEnvironmentFieldPath,
+					FromFieldPath: pointer.StringPtr("data.region"),
+					ToFieldPath:   pointer.StringPtr("objectMeta.labels.region"),
+					Policy:        &v1.PatchPolicy{FromFieldPath: &required},
+				}}},
+			},
+			want: want{
+				cd:  &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				err: errors.Wrapf(errNotFound("data.region"), errFmtPatch, 0),
+			},
+		},
+		"Success": {
+			reason: "Only FromEnvironmentFieldPath patches should be applied, using the environment as their source",
+			args: args{
+				env: env,
+				cd:  &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				t: v1.ComposedTemplate{Patches: []v1.Patch{
+					{
+						Type:          v1.PatchTypeFromCompositeFieldPath,
+						FromFieldPath: pointer.StringPtr("objectMeta.labels"),
+					},
+					{
+						Type:          v1.PatchTypeFromEnvironmentFieldPath,
+						FromFieldPath: pointer.StringPtr("data.region"),
+						ToFieldPath:   pointer.StringPtr("objectMeta.labels.region"),
+					},
+				}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:   "cd",
+					Labels: map[string]string{"region": "us-east-1"},
+				}},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := RenderFromEnvironment(context.Background(), tc.args.env, tc.args.cd, tc.args.t)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRenderFromEnvironment(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.cd, tc.args.cd); diff != "" {
+				t.Errorf("\n%s\nRenderFromEnvironment(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestNewComposedResourceStatus(t *testing.T) {
+	type args struct {
+		cd resource.Composed
+		t  v1.ComposedTemplate
+		i  int
+	}
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   ComposedResourceStatus
+	}{
+		"Anonymous": {
+			reason: "An anonymous template should be identified by its index",
+			args: args{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cool-abcde"}},
+				i:  2,
+			},
+			want: ComposedResourceStatus{TemplateName: "2", Name: "cool-abcde"},
+		},
+		"NotSynced": {
+			reason: "The message of an unsynced resource's Synced condition should be reported",
+			args: args{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cool-abcde"},
+					ConditionedStatus: xpv1.ConditionedStatus{Conditions: []xpv1.Condition{
+						xpv1.Creating().WithMessage("creating"),
+						xpv1.ReconcileError(errors.New("boom")),
+					}},
+				},
+				t: v1.ComposedTemplate{Name: pointer.StringPtr("cool")},
+			},
+			want: ComposedResourceStatus{TemplateName: "cool", Name: "cool-abcde", Message: "boom"},
+		},
+		"Synced": {
+			reason: "The message of a synced resource's Ready condition should be reported",
+			args: args{
+				cd: &fake.Composed{
+					ObjectMeta: metav1.ObjectMeta{Name: "cool-abcde"},
+					ConditionedStatus: xpv1.ConditionedStatus{Conditions: []xpv1.Condition{
+						xpv1.Creating().WithMessage("creating"),
+						xpv1.ReconcileSuccess(),
+					}},
+				},
+				t: v1.ComposedTemplate{Name: pointer.StringPtr("cool")},
+			},
+			want: ComposedResourceStatus{TemplateName: "cool", Name: "cool-abcde", Synced: true, Message: "creating"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := NewComposedResourceStatus(tc.args.cd, tc.args.t, tc.args.i)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nNewComposedResourceStatus(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRenderFromComposed(t *testing.T) {
+	errNotFound := func(path string) error {
+		_, err := fieldpath.Pave(map[string]interface{}{"objectMeta": map[string]interface{}{}}).GetValue(path)
+		return err
+	}
+	required := v1.FromFieldPathPolicyRequired
+	fromVPC := func(p *v1.PatchPolicy) v1.Patch {
+		return v1.Patch{
+			Type:             v1.PatchTypeFromComposedFieldPath,
+			FromResourceName: pointer.StringPtr("vpc"),
+			FromFieldPath:    pointer.StringPtr("objectMeta.annotations[id]"),
+			ToFieldPath:      pointer.StringPtr("objectMeta.labels[vpc]"),
+			Policy:           p,
+		}
+	}
+	vpc := &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+		Name:        "vpc",
+		Annotations: map[string]string{"id": "vpc-123"},
+	}}
+
+	type args struct {
+		observed map[string]resource.Composed
+		cd       resource.Composed
+		t        v1.ComposedTemplate
+	}
+	type want struct {
+		cd          resource.Composed
+		err         error
+		unavailable bool
+	}
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"MissingResourceName": {
+			reason: "A FromComposedFieldPath patch without a fromResourceName should return an error",
+			args: args{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				t:  v1.ComposedTemplate{Patches: []v1.Patch{{Type: v1.PatchTypeFromComposedFieldPath}}},
+			},
+			want: want{
+				cd:  &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				err: errors.Wrapf(errors.New(errComposedPatchName), errFmtPatch, 0),
+			},
+		},
+		"ResourceNotObserved": {
+			reason: "A patch from a composed resource that has not been observed should be reported as unavailable",
+			args: args{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				t:  v1.ComposedTemplate{Patches: []v1.Patch{fromVPC(nil)}},
+			},
+			want: want{
+				cd:          &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				err:         unavailableError{errors.Errorf(errFmtComposedMissing, "vpc")},
+				unavailable: true,
+			},
+		},
+		"OptionalFieldNotFound": {
+			reason: "An optional patch from a field that does not exist should be a no-op",
+			args: args{
+				observed: map[string]resource.Composed{"vpc": &fake.Composed{}},
+				cd:       &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				t:        v1.ComposedTemplate{Patches: []v1.Patch{fromVPC(nil)}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+			},
+		},
+		"RequiredFieldNotFound": {
+			reason: "A required patch from a field that does not exist should be reported as unavailable",
+			args: args{
+				observed: map[string]resource.Composed{"vpc": &fake.Composed{}},
+				cd:       &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				t:        v1.ComposedTemplate{Patches: []v1.Patch{fromVPC(&v1.PatchPolicy{FromFieldPath: &required})}},
+			},
+			want: want{
+				cd:          &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				err:         unavailableError{errors.Wrapf(errNotFound("objectMeta.annotations[id]"), errFmtComposedFieldPath, "vpc")},
+				unavailable: true,
+			},
+		},
+		"Success": {
+			reason: "Only FromComposedFieldPath patches should be applied, using the observed composed resource as their source",
+			args: args{
+				observed: map[string]resource.Composed{"vpc": vpc},
+				cd:       &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
+				t: v1.ComposedTemplate{Patches: []v1.Patch{
+					{
+						Type:          v1.PatchTypeFromCompositeFieldPath,
+						FromFieldPath: pointer.StringPtr("objectMeta.labels"),
+					},
+					fromVPC(nil),
+				}},
+			},
+			want: want{
+				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+					Name:   "cd",
+					Labels: map[string]string{"vpc": "vpc-123"},
+				}},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := RenderFromComposed(context.Background(), tc.args.observed, tc.args.cd, tc.args.t)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nRenderFromComposed(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.unavailable, IsUnavailable(err)); diff != "" {
+				t.Errorf("\n%s\nIsUnavailable(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.cd, tc.args.cd); diff != "" {
+				t.Errorf("\n%s\nRenderFromComposed(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRenderComposite(t *testing.T) {
+	now := metav1.NewTime(time.Unix(0, 0))
+	lpt := fake.ConnectionDetailsLastPublishedTimer{Time: &now}
+	endpoint := v1.Patch{
+		Type: v1.PatchTypeCombineToComposite,
+		Combine: &v1.Combine{
+			Variables: []v1.CombineVariable{
+				{FromResourceName: pointer.StringPtr("server"), FromFieldPath: "objectMeta.annotations[host]"},
+				{FromFieldPath: "objectMeta.annotations[port]"},
+			},
+			Strategy: v1.CombineStrategyString,
+			String:   &v1.StringCombine{Format: "%s:%s"},
+		},
+		ToFieldPath: pointer.StringPtr("objectMeta.labels[endpoint]"),
+	}
+	cd := &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+		Name:        "cd",
+		Annotations: map[string]string{"port": "5432"},
+	}}
+	server := &fake.Composed{ObjectMeta: metav1.ObjectMeta{
+		Name:        "server",
+		Annotations: map[string]string{"host": "example.org"},
+	}}
+
+	type args struct {
+		observed map[string]resource.Composed
+		t        v1.ComposedTemplate
+	}
+	type want struct {
+		cp          resource.Composite
+		err         error
+		unavailable bool
+	}
+	cases := map[string]struct {
+		reason string
+		args
+		want
+	}{
+		"ResourceNotObserved": {
+			reason: "A combine patch with a variable whose composed resource has not been observed should be reported as unavailable",
+			args: args{
+				t: v1.ComposedTemplate{Patches: []v1.Patch{endpoint}},
+			},
+			want: want{
+				cp:          &fake.Composite{ObjectMeta: metav1.ObjectMeta{Name: "cp"}, ConnectionDetailsLastPublishedTimer: lpt},
+				err:         unavailableError{errors.Wrapf(errors.Errorf(errFmtComposedMissing, "server"), errFmtPatch, 0)},
+				unavailable: true,
+			},
+		},
+		"FieldNotFound": {
+			reason: "A combine patch with a required variable whose field does not exist should be reported as unavailable",
+			args: args{
+				observed: map[string]resource.Composed{"server": &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "server"}}},
+				t:        v1.ComposedTemplate{Patches: []v1.Patch{endpoint}},
+			},
+			want: want{
+				cp:          &fake.Composite{ObjectMeta: metav1.ObjectMeta{Name: "cp"}, ConnectionDetailsLastPublishedTimer: lpt},
+				unavailable: true,
+			},
+		},
+		"Success": {
+			reason: "Combine patches should read variables from the observed composed resources they name",
+			args: args{
+				observed: map[string]resource.Composed{"server": server},
+				t: v1.ComposedTemplate{Patches: []v1.Patch{
+					{
+						Type:          v1.PatchTypeFromCompositeFieldPath,
+						FromFieldPath: pointer.StringPtr("objectMeta.labels"),
+					},
+					endpoint,
+				}},
+			},
+			want: want{
+				cp: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cp",
+						Labels: map[string]string{"endpoint": "example.org:5432"},
+					},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+			},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			cp := &fake.Composite{ObjectMeta: metav1.ObjectMeta{Name: "cp"}, ConnectionDetailsLastPublishedTimer: lpt}
+			err := RenderComposite(context.Background(), cp, tc.args.observed, cd, tc.args.t)
+			if tc.want.err != nil {
+				if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+					t.Errorf("\n%s\nRenderComposite(...): -want, +got:\n%s", tc.reason, diff)
+				}
+			}
+			if diff := cmp.Diff(tc.want.unavailable, IsUnavailable(err)); diff != "" {
+				t.Errorf("\n%s\nIsUnavailable(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.cp, cp); diff != "" {
+				t.Errorf("\n%s\nRenderComposite(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestAssociateByOrder(t *testing.T) {
+	t0 := v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte("zero")}}
+	t1 := v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte("one")}}
+	t2 := v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte("two")}}
+
+	r0 := corev1.ObjectReference{Name: "zero"}
+	r1 := corev1.ObjectReference{Name: "one"}
+	r2 := corev1.ObjectReference{Name: "two"}
+
+	cases := map[string]struct {
+		reason string
+		t      []v1.ComposedTemplate
+		r      []corev1.ObjectReference
+		want   []TemplateAssociation
+	}{
+		"NoReferences": {
+			reason: "When there are no references we should return templates associated with empty references.",
+			t:      []v1.ComposedTemplate{t0, t1, t2},
+			want: []TemplateAssociation{
+				{Template: t0},
+				{Template: t1},
+				{Template: t2},
+			},
+		},
+		"SomeReferences": {
+			reason: "We should return all templates when there are fewer references than templates.",
+			t:      []v1.ComposedTemplate{t0, t1, t2},
+			r:      []corev1.ObjectReference{r0, r1},
+			want: []TemplateAssociation{
+				{Template: t0, Reference: r0},
+				{Template: t1, Reference: r1},
+				{Template: t2},
+			},
+		},
+		"ExtraReferences": {
+			reason: "When there are more references than templates they should be truncated.",
+			t:      []v1.ComposedTemplate{t0, t1},
+			r:      []corev1.ObjectReference{r0, r1, r2},
+			want: []TemplateAssociation{
+				{Template: t0, Reference: r0},
+				{Template: t1, Reference: r1},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := AssociateByOrder(tc.t, tc.r)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nAssociateByOrder(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestGarbageCollectingAssociator(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	n0 := "zero"
+	t0 := v1.ComposedTemplate{Name: &n0}
+
+	n1 := "one"
+	t1 := v1.ComposedTemplate{Name: &n1, Condition: &v1.ComposedTemplateCondition{
+		Type:        v1.ComposedTemplateConditionTypeMatchString,
+		FieldPath:   "objectMeta.labels[ha]",
+		MatchString: "true",
+	}}
+
+	r0 := corev1.ObjectReference{Name: n0}
+	r1 := corev1.ObjectReference{Name: n1}
+
+	// Converting a fake composite to unstructured panics if this is nil.
+	now := metav1.NewTime(time.Unix(0, 0))
+	lpt := fake.ConnectionDetailsLastPublishedTimer{Time: &now}
+
+	type args struct {
+		ctx  context.Context
+		cr   resource.Composite
+		comp *v1.Composition
+	}
+
+	type want struct {
+		tas []TemplateAssociation
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		c      client.Client
+		args   args
+		want   want
+	}{
+		"AnonymousTemplates": {
+			reason: "We should fall back to associating templates with references by order if any template is not named.",
+			args: args{
+				cr: &fake.Composite{},
+				comp: &v1.Composition{
+					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{t0, {Name: nil}}},
+				},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0}, {Template: v1.ComposedTemplate{Name: nil}}},
+			},
+		},
+		"ResourceNotFoundError": {
+			reason: "Non-existent resources should be ignored.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "")),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				comp: &v1.Composition{
+					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{t0}},
+				},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0}},
+			},
+		},
+		"GetResourceError": {
+			reason: "Errors getting a referenced resource should be returned.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(errBoom),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				comp: &v1.Composition{
+					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{t0}},
+				},
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errGetComposed),
+			},
+		},
+		"AnonymousResource": {
+			reason: "We should fall back to associating templates with references by order if any resource is not annotated with its template name.",
+			c: &test.MockClient{
+				// Return an empty (and thus unannotated) composed resource.
+				MockGet: test.NewMockGetFn(nil),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				comp: &v1.Composition{
+					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{t0}},
+				},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0, Reference: r0}},
+			},
+		},
+		"AssociatedResource": {
+			reason: "We should associate referenced resources by their template name annotation.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					SetCompositionResourceName(obj.(metav1.Object), n0)
+					return nil
+				}),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				comp: &v1.Composition{
+					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{t0}},
+				},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0, Reference: r0}},
+			},
+		},
+		"UncontrolledResource": {
+			reason: "We should not garbage collect a resource that we don't control.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					// The template used to create this resource is no longer known to us.
+					SetCompositionResourceName(obj, "unknown")
+
+					// This resource is not controlled by us.
+					ctrl := true
+					obj.SetOwnerReferences([]metav1.OwnerReference{{
+						Controller: &ctrl,
+						UID:        types.UID("who-dat"),
+					}})
+					return nil
+				}),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ObjectMeta:                  metav1.ObjectMeta{UID: types.UID("very-unique")},
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				comp: &v1.Composition{
+					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{t0}},
+				},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0}},
+			},
+		},
+		"GarbageCollectionError": {
+			reason: "We should return errors encountered while garbage collecting a composed resource.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					// The template used to create this resource is no longer known to us.
+					SetCompositionResourceName(obj, "unknown")
+					return nil
+				}),
+				MockDelete: test.NewMockDeleteFn(errBoom),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				comp: &v1.Composition{
+					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{t0}},
+				},
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errGCComposed),
+			},
+		},
+		"GarbageCollectedResource": {
+			reason: "We should not return a resource that we successfully garbage collect.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					// The template used to create this resource is no longer known to us.
+					SetCompositionResourceName(obj, "unknown")
+					return nil
+				}),
+				MockDelete: test.NewMockDeleteFn(nil),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				},
+				comp: &v1.Composition{
+					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{t0}},
+				},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0}},
+			},
+		},
+		"ConditionMet": {
+			reason: "We should associate a template whose condition is met.",
+			c: &test.MockClient{
+				MockGet: func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+					SetCompositionResourceName(obj, key.Name)
+					return nil
+				},
+			},
+			args: args{
+				cr: &fake.Composite{
+					ObjectMeta:                          metav1.ObjectMeta{Labels: map[string]string{"ha": "true"}},
+					ComposedResourcesReferencer:         fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0, r1}},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				comp: &v1.Composition{
+					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{t0, t1}},
+				},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0, Reference: r0}, {Template: t1, Reference: r1}},
+			},
+		},
+		"ConditionNotMet": {
+			reason: "We should exclude, and garbage collect any resource composed from, a template whose condition is not met.",
+			c: &test.MockClient{
+				MockGet: func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+					SetCompositionResourceName(obj, key.Name)
+					return nil
+				},
+				MockDelete: func(_ context.Context, obj client.Object, _ ...client.DeleteOption) error {
+					if obj.GetName() != n1 {
+						t.Errorf("Delete(...): unexpected deletion of %q", obj.GetName())
+					}
+					return nil
+				},
+			},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer:         fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0, r1}},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				comp: &v1.Composition{
+					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{t0, t1}},
+				},
+			},
+			want: want{
+				tas: []TemplateAssociation{{Template: t0, Reference: r0}},
+			},
+		},
+		"ConditionGarbageCollectionError": {
+			reason: "We should return errors encountered while garbage collecting a resource whose condition is not met.",
+			c: &test.MockClient{
+				MockGet: func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+					SetCompositionResourceName(obj, key.Name)
+					return nil
+				},
+				MockDelete: test.NewMockDeleteFn(errBoom),
+			},
+			args: args{
+				cr: &fake.Composite{
+					ComposedResourcesReferencer:         fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r1}},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				comp: &v1.Composition{
+					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{t1}},
+				},
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errGCComposed),
+			},
+		},
+		"ForEachRemovedElement": {
+			reason: "We should garbage collect a resource composed from an element that was removed from a forEach array.",
+			c: &test.MockClient{
+				MockGet: func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+					SetCompositionResourceName(obj, key.Name)
+					return nil
+				},
+				MockDelete: func(_ context.Context, obj client.Object, _ ...client.DeleteOption) error {
+					if obj.GetName() != "bucket[b]" {
+						t.Errorf("Delete(...): unexpected deletion of %q", obj.GetName())
+					}
+					return nil
+				},
+			},
+			args: args{
+				cr: &fake.Composite{
+					ObjectMeta: metav1.ObjectMeta{Finalizers: []string{"a"}},
+					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{
+						{Name: "bucket[a]"},
+						{Name: "bucket[b]"},
+					}},
+					ConnectionDetailsLastPublishedTimer: lpt,
+				},
+				comp: &v1.Composition{
+					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{
+						{
+							Name:    pointer.StringPtr("bucket"),
+							Base:    runtime.RawExtension{Raw: []byte(`{}`)},
+							ForEach: &v1.ForEach{FieldPath: "objectMeta.finalizers", Key: v1.ForEachKeyTypeValue},
+						},
+					}},
+				},
+			},
+			want: want{
+				tas: []TemplateAssociation{{
+					Template:  v1.ComposedTemplate{Name: pointer.StringPtr("bucket[a]"), Base: runtime.RawExtension{Raw: []byte(`{}`)}},
+					Reference: corev1.ObjectReference{Name: "bucket[a]"},
+				}},
+			},
+		},
+		"ConditionError": {
+			reason: "We should return errors encountered while evaluating a template's condition.",
+			args: args{
+				cr: &fake.Composite{ConnectionDetailsLastPublishedTimer: lpt},
+				comp: &v1.Composition{
+					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{
+						{Name: &n1, Condition: &v1.ComposedTemplateCondition{Type: "Unknown"}},
+					}},
+				},
+			},
+			want: want{
+				err: errors.Wrapf(errors.Errorf(errFmtConditionType, "Unknown"), errFmtCondition, n1),
+			},
+		},
 	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a := NewGarbageCollectingAssociator(tc.c)
+			got, err := a.AssociateTemplates(tc.args.ctx, tc.args.cr, tc.args.comp)
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nAssociateTemplates(...): -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.tas, got); diff != "" {
+				t.Errorf("\n%s\nAssociateTemplates(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestAPIOrphaner(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	orphan := xpv1.DeletionOrphan
+	del := xpv1.DeletionDelete
+
+	n0, n1 := "zero", "one"
+	r0 := corev1.ObjectReference{Kind: "Database", Name: n0}
+	r1 := corev1.ObjectReference{Kind: "Database", Name: n1}
+
+	xr := func(policy xpv1.DeletionPolicy) *composite.Unstructured {
+		cr := composite.New()
+		cr.SetName("cool-xr")
+		cr.SetUID("cool-uid")
+		cr.SetCompositionReference(&corev1.ObjectReference{Name: "cool-comp"})
+		cr.SetResourceReferences([]corev1.ObjectReference{r0, r1})
+		if policy != "" {
+			_ = fieldpath.Pave(cr.Object).SetValue("spec.deletionPolicy", policy)
+		}
+		return cr
+	}
+
+	// withComposed returns a MockGetFn that returns a composed resource
+	// controlled by the owner with the supplied UID, and annotated with the
+	// name of the template it was composed from.
+	withComposed := func(comp v1.CompositionSpec, owner types.UID) test.MockGetFn {
+		return test.NewMockGetFn(nil, func(obj client.Object) error {
+			switch o := obj.(type) {
+			case *v1.Composition:
+				o.Spec = comp
+			case *composed.Unstructured:
+				SetCompositionResourceName(o, o.GetName())
+				o.SetOwnerReferences([]metav1.OwnerReference{
+					{UID: "other-uid"},
+					{UID: owner, Controller: pointer.BoolPtr(true)},
+				})
+			}
+			return nil
+		})
+	}
+
 	type want struct {
-		cd  resource.Composed
-		err error
+		orphaned []corev1.ObjectReference
+		err      error
 	}
+
 	cases := map[string]struct {
 		reason string
-		client client.Client
-		args
-		want
+		c      client.Client
+		cr     resource.Composite
+		want   want
 	}{
-		"InvalidTemplate": {
-			reason: "Invalid template should not be accepted",
-			args: args{
-				cd: &fake.Composed{},
-				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte("olala")}},
+		"GetCompositionError": {
+			reason: "Errors getting the composite resource's Composition should be returned.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(errBoom),
+			},
+			cr: xr(""),
+			want: want{
+				err: errors.Wrap(errBoom, errGetComp),
+			},
+		},
+		"GetComposedError": {
+			reason: "Errors getting a composed resource should be returned.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					if _, ok := obj.(*composed.Unstructured); ok {
+						return errBoom
+					}
+					return nil
+				}),
+			},
+			cr: xr(""),
+			want: want{
+				err: errors.Wrap(errBoom, errGetComposed),
+			},
+		},
+		"CompositionNotFound": {
+			reason: "The composite resource's deletion policy should apply if its Composition no longer exists.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					if _, ok := obj.(*v1.Composition); ok {
+						return kerrors.NewNotFound(schema.GroupResource{}, "")
+					}
+					obj.SetOwnerReferences([]metav1.OwnerReference{{UID: "cool-uid", Controller: pointer.BoolPtr(true)}})
+					return nil
+				}),
+				MockUpdate: test.NewMockUpdateFn(nil),
+			},
+			cr: xr(orphan),
+			want: want{
+				orphaned: []corev1.ObjectReference{r0, r1},
+			},
+		},
+		"NotControlled": {
+			reason: "Composed resources that are not controlled by the composite resource should not be orphaned.",
+			c: &test.MockClient{
+				MockGet: withComposed(v1.CompositionSpec{}, "other-uid"),
+			},
+			cr: xr(orphan),
+			want: want{
+				orphaned: []corev1.ObjectReference{},
+			},
+		},
+		"NotControlledOrphanPolicy": {
+			reason: "Composed resources that should be orphaned are composed without a controller reference, and should be annotated as orphaned.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					switch o := obj.(type) {
+					case *v1.Composition:
+						o.Spec = v1.CompositionSpec{Resources: []v1.ComposedTemplate{{Name: &n0}, {Name: &n1, DeletionPolicy: &orphan}}}
+					case *composed.Unstructured:
+						SetCompositionResourceName(o, o.GetName())
+					}
+					return nil
+				}),
+				MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
+					if diff := cmp.Diff("cool-xr", obj.GetAnnotations()[AnnotationKeyOrphanedFrom]); diff != "" {
+						t.Errorf("Update(...): -want orphaned from annotation, +got:\n%s", diff)
+					}
+					return nil
+				}),
+			},
+			cr: xr(""),
+			want: want{
+				orphaned: []corev1.ObjectReference{r1},
+			},
+		},
+		"DeletePolicy": {
+			reason: "Composed resources should not be orphaned by default.",
+			c: &test.MockClient{
+				MockGet: withComposed(v1.CompositionSpec{Resources: []v1.ComposedTemplate{{Name: &n0}, {Name: &n1}}}, "cool-uid"),
+			},
+			cr: xr(""),
+			want: want{
+				orphaned: []corev1.ObjectReference{},
+			},
+		},
+		"TemplateOrphanPolicy": {
+			reason: "Composed resources whose template's deletion policy is Orphan should be orphaned.",
+			c: &test.MockClient{
+				MockGet: withComposed(v1.CompositionSpec{Resources: []v1.ComposedTemplate{{Name: &n0}, {Name: &n1, DeletionPolicy: &orphan}}}, "cool-uid"),
+				MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
+					want := []metav1.OwnerReference{{UID: "other-uid"}}
+					if diff := cmp.Diff(want, obj.GetOwnerReferences()); diff != "" {
+						t.Errorf("Update(...): -want owner references, +got:\n%s", diff)
+					}
+					if diff := cmp.Diff("cool-xr", obj.GetAnnotations()[AnnotationKeyOrphanedFrom]); diff != "" {
+						t.Errorf("Update(...): -want orphaned from annotation, +got:\n%s", diff)
+					}
+					return nil
+				}),
+			},
+			cr: xr(""),
+			want: want{
+				orphaned: []corev1.ObjectReference{r1},
+			},
+		},
+		"CompositeOrphanPolicy": {
+			reason: "The deletion policy of a template should take precedence over that of its composite resource.",
+			c: &test.MockClient{
+				MockGet:    withComposed(v1.CompositionSpec{Resources: []v1.ComposedTemplate{{Name: &n0, DeletionPolicy: &del}, {Name: &n1}}}, "cool-uid"),
+				MockUpdate: test.NewMockUpdateFn(nil),
 			},
+			cr: xr(orphan),
 			want: want{
-				cd:  &fake.Composed{},
-				err: errors.Wrap(errors.New("invalid character 'o' looking for beginning of value"), errUnmarshal),
+				orphaned: []corev1.ObjectReference{r1},
 			},
 		},
-		"NoLabel": {
-			reason: "The name prefix label has to be set",
-			args: args{
-				cp: &fake.Composite{},
-				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
-				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}},
+		"UpdateComposedError": {
+			reason: "Errors updating a composed resource should be returned.",
+			c: &test.MockClient{
+				MockGet:    withComposed(v1.CompositionSpec{}, "cool-uid"),
+				MockUpdate: test.NewMockUpdateFn(errBoom),
 			},
+			cr: xr(orphan),
 			want: want{
-				cd:  &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
-				err: errors.New(errNamePrefix),
+				err: errors.Wrap(errBoom, errOrphan),
 			},
 		},
-		"DryRunError": {
-			reason: "Errors dry-run creating the rendered resource to name it should be returned",
-			client: &test.MockClient{MockCreate: test.NewMockCreateFn(errBoom)},
-			args: args{
-				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
-					xcrd.LabelKeyNamePrefixForComposed: "ola",
-					xcrd.LabelKeyClaimName:             "rola",
-					xcrd.LabelKeyClaimNamespace:        "rolans",
-				}}},
-				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{}},
-				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}},
+		"GetRevisionError": {
+			reason: "Errors getting the composite resource's CompositionRevision should be returned.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					if _, ok := obj.(*v1alpha1.CompositionRevision); ok {
+						return errBoom
+					}
+					return nil
+				}),
 			},
+			cr: func() resource.Composite {
+				cr := xr("")
+				SetCompositionRevisionReference(cr, &corev1.LocalObjectReference{Name: "cool-comp-1"})
+				return cr
+			}(),
 			want: want{
-				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
-					GenerateName: "ola-",
-					Labels: map[string]string{
-						xcrd.LabelKeyNamePrefixForComposed: "ola",
-						xcrd.LabelKeyClaimName:             "rola",
-						xcrd.LabelKeyClaimNamespace:        "rolans",
-					},
-					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl}},
-				}},
-				err: errors.Wrap(errBoom, errName),
+				err: errors.Wrap(errBoom, errGetRev),
 			},
 		},
-		"Success": {
-			reason: "Configuration should result in the right object with correct generateName",
-			client: &test.MockClient{MockCreate: test.NewMockCreateFn(nil)},
-			args: args{
-				cp: &fake.Composite{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
-					xcrd.LabelKeyNamePrefixForComposed: "ola",
-					xcrd.LabelKeyClaimName:             "rola",
-					xcrd.LabelKeyClaimNamespace:        "rolans",
-				}}},
-				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{Name: "cd"}},
-				t:  v1.ComposedTemplate{Base: runtime.RawExtension{Raw: tmpl}},
+		"RevisionOrphanPolicy": {
+			reason: "The deletion policies of the CompositionRevision the composite resource references should take precedence over those of its Composition.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					switch o := obj.(type) {
+					case *v1.Composition:
+						o.Spec = v1.CompositionSpec{Resources: []v1.ComposedTemplate{{Name: &n0}, {Name: &n1}}}
+					case *v1alpha1.CompositionRevision:
+						o.SetLabels(map[string]string{v1alpha1.LabelCompositionName: "cool-comp"})
+						o.Spec.CompositionSpec = v1.CompositionSpec{Resources: []v1.ComposedTemplate{{Name: &n0, DeletionPolicy: &orphan}, {Name: &n1}}}
+					case *composed.Unstructured:
+						SetCompositionResourceName(o, o.GetName())
+						o.SetOwnerReferences([]metav1.OwnerReference{{UID: "cool-uid", Controller: pointer.BoolPtr(true)}})
+					}
+					return nil
+				}),
+				MockUpdate: test.NewMockUpdateFn(nil),
 			},
+			cr: func() resource.Composite {
+				cr := xr("")
+				SetCompositionRevisionReference(cr, &corev1.LocalObjectReference{Name: "cool-comp-1"})
+				return cr
+			}(),
 			want: want{
-				cd: &fake.Composed{ObjectMeta: metav1.ObjectMeta{
-					Name:         "cd",
-					GenerateName: "ola-",
-					Labels: map[string]string{
-						xcrd.LabelKeyNamePrefixForComposed: "ola",
-						xcrd.LabelKeyClaimName:             "rola",
-						xcrd.LabelKeyClaimNamespace:        "rolans",
-					},
-					OwnerReferences: []metav1.OwnerReference{{Controller: &ctrl}},
-				}},
+				orphaned: []corev1.ObjectReference{r0},
 			},
 		},
 	}
+
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			r := NewAPIDryRunRenderer(tc.client)
-			err := r.Render(tc.args.ctx, tc.args.cp, tc.args.cd, tc.args.t)
+			o := NewAPIOrphaner(tc.c)
+			got, err := o.Orphan(context.Background(), tc.cr)
 			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
-				t.Errorf("\n%s\nRender(...): -want, +got:\n%s", tc.reason, diff)
+				t.Errorf("\n%s\nOrphan(...): -want error, +got error:\n%s", tc.reason, diff)
 			}
-			if diff := cmp.Diff(tc.want.cd, tc.args.cd); diff != "" {
-				t.Errorf("\n%s\nRender(...): -want, +got:\n%s", tc.reason, diff)
+			if diff := cmp.Diff(tc.want.orphaned, got); diff != "" {
+				t.Errorf("\n%s\nOrphan(...): -want, +got:\n%s", tc.reason, diff)
 			}
 		})
 	}
 }
 
-func TestAssociateByOrder(t *testing.T) {
-	t0 := v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte("zero")}}
-	t1 := v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte("one")}}
-	t2 := v1.ComposedTemplate{Base: runtime.RawExtension{Raw: []byte("two")}}
+func TestTemplateIndexOf(t *testing.T) {
+	named, db := "named", "database"
+	nt := v1.ComposedTemplate{Name: &named}
+	ft := v1.ComposedTemplate{Name: &db, ForEach: &v1.ForEach{FieldPath: "spec.databases"}}
 
-	r0 := corev1.ObjectReference{Name: "zero"}
-	r1 := corev1.ObjectReference{Name: "one"}
-	r2 := corev1.ObjectReference{Name: "two"}
+	withName := func(name string) resource.Composed {
+		cd := composed.New()
+		SetCompositionResourceName(cd, name)
+		return cd
+	}
 
 	cases := map[string]struct {
 		reason string
-		t      []v1.ComposedTemplate
-		r      []corev1.ObjectReference
-		want   []TemplateAssociation
+		ts     []v1.ComposedTemplate
+		cd     resource.Composed
+		i      int
+		want   int
 	}{
-		"NoReferences": {
-			reason: "When there are no references we should return templates associated with empty references.",
-			t:      []v1.ComposedTemplate{t0, t1, t2},
-			want: []TemplateAssociation{
-				{Template: t0},
-				{Template: t1},
-				{Template: t2},
+		"Named": {
+			reason: "Resources should be associated with the template they are annotated with.",
+			ts:     []v1.ComposedTemplate{ft, nt},
+			cd:     withName(named),
+			want:   1,
+		},
+		"ForEach": {
+			reason: "Resources composed from a forEach template should be associated with the template that was expanded.",
+			ts:     []v1.ComposedTemplate{nt, ft},
+			cd:     withName("database[orders]"),
+			want:   1,
+		},
+		"Anonymous": {
+			reason: "Resources composed from anonymous templates should be associated by index.",
+			ts:     []v1.ComposedTemplate{{}, {}},
+			cd:     withName(""),
+			i:      1,
+			want:   1,
+		},
+		"NoTemplate": {
+			reason: "Resources that correspond to no template should not be associated.",
+			ts:     []v1.ComposedTemplate{nt},
+			cd:     withName("gone"),
+			want:   -1,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := templateIndexOf(tc.ts, tc.cd, tc.i)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\ntemplateIndexOf(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestRejectInvalidDeletionOrder(t *testing.T) {
+	cases := map[string]struct {
+		comp *v1.Composition
+		want error
+	}{
+		"Valid": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources:     []v1.ComposedTemplate{{Name: pointer.StringPtr("vpc")}, {Name: pointer.StringPtr("subnet")}},
+					DeletionOrder: []string{"subnet", "vpc"},
+				},
 			},
+			want: nil,
 		},
-		"SomeReferences": {
-			reason: "We should return all templates when there are fewer references than templates.",
-			t:      []v1.ComposedTemplate{t0, t1, t2},
-			r:      []corev1.ObjectReference{r0, r1},
-			want: []TemplateAssociation{
-				{Template: t0, Reference: r0},
-				{Template: t1, Reference: r1},
-				{Template: t2},
+		"UnknownResource": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources:     []v1.ComposedTemplate{{Name: pointer.StringPtr("vpc")}},
+					DeletionOrder: []string{"vpc", "subnet"},
+				},
 			},
+			want: errors.Errorf(errFmtDeletionOrder, 1, "subnet"),
 		},
-		"ExtraReferences": {
-			reason: "When there are more references than templates they should be truncated.",
-			t:      []v1.ComposedTemplate{t0, t1},
-			r:      []corev1.ObjectReference{r0, r1, r2},
-			want: []TemplateAssociation{
-				{Template: t0, Reference: r0},
-				{Template: t1, Reference: r1},
+		"DuplicateResource": {
+			comp: &v1.Composition{
+				Spec: v1.CompositionSpec{
+					Resources:     []v1.ComposedTemplate{{Name: pointer.StringPtr("vpc")}},
+					DeletionOrder: []string{"vpc", "vpc"},
+				},
 			},
+			want: errors.Errorf(errFmtDeletionOrderDup, 1, "vpc"),
 		},
 	}
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			got := AssociateByOrder(tc.t, tc.r)
+			got := RejectInvalidDeletionOrder(tc.comp)
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\nRejectInvalidDeletionOrder(...): -want, +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDeletionOrder(t *testing.T) {
+	cases := map[string]struct {
+		reason   string
+		ts       []v1.ComposedTemplate
+		explicit []string
+		want     []int
+	}{
+		"ReverseDeclarationOrder": {
+			reason: "Resources without dependencies should be deleted in reverse declaration order.",
+			ts:     []v1.ComposedTemplate{{}, {}, {}},
+			want:   []int{2, 1, 0},
+		},
+		"Dependencies": {
+			reason: "Resources should be deleted before the resources they depend on.",
+			ts: []v1.ComposedTemplate{
+				{Name: pointer.StringPtr("subnet"), DependsOn: []string{"vpc"}},
+				{Name: pointer.StringPtr("vpc")},
+				{Name: pointer.StringPtr("bucket")},
+			},
+			want: []int{2, 0, 1},
+		},
+		"Explicit": {
+			reason: "Resources named by the explicit deletion order should be deleted first, in that order.",
+			ts: []v1.ComposedTemplate{
+				{Name: pointer.StringPtr("vpc")},
+				{Name: pointer.StringPtr("subnet")},
+				{Name: pointer.StringPtr("bucket")},
+			},
+			explicit: []string{"vpc", "bucket"},
+			want:     []int{0, 2, 1},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := deletionOrder(tc.ts, tc.explicit)
 			if diff := cmp.Diff(tc.want, got); diff != "" {
-				t.Errorf("\n%s\nAssociateByOrder(...): -want, +got:\n%s", tc.reason, diff)
+				t.Errorf("\n%s\ndeletionOrder(...): -want, +got:\n%s", tc.reason, diff)
 			}
 		})
 	}
 }
 
-func TestGarbageCollectingAssociator(t *testing.T) {
+func TestAPIComposedDeleter(t *testing.T) {
 	errBoom := errors.New("boom")
+	now := metav1.Now()
 
-	n0 := "zero"
-	t0 := v1.ComposedTemplate{Name: &n0}
+	vpc, subnet := "vpc", "subnet"
+	rv := corev1.ObjectReference{Kind: "VPC", Name: vpc}
+	rs := corev1.ObjectReference{Kind: "Subnet", Name: subnet}
+	ro := corev1.ObjectReference{Kind: "Bucket", Name: "other"}
 
-	r0 := corev1.ObjectReference{Name: n0}
+	xr := func() *composite.Unstructured {
+		cr := composite.New()
+		cr.SetUID("cool-uid")
+		cr.SetCompositionReference(&corev1.ObjectReference{Name: "cool-comp"})
+		cr.SetResourceReferences([]corev1.ObjectReference{rv, rs, ro})
+		return cr
+	}
+	comp := v1.CompositionSpec{Resources: []v1.ComposedTemplate{{Name: &vpc}, {Name: &subnet}}}
 
-	type args struct {
-		ctx  context.Context
-		cr   resource.Composite
-		comp *v1.Composition
+	// withComposed returns a MockGetFn that returns extant composed resources
+	// annotated with the name of the template they were composed from. The
+	// 'other' resource is not controlled by the composite resource.
+	withComposed := func(exists map[string]bool, deleting bool) test.MockGetFn {
+		return test.NewMockGetFn(nil, func(obj client.Object) error {
+			switch o := obj.(type) {
+			case *v1.Composition:
+				o.Spec = comp
+			case *composed.Unstructured:
+				if !exists[o.GetName()] {
+					return kerrors.NewNotFound(schema.GroupResource{}, o.GetName())
+				}
+				SetCompositionResourceName(o, o.GetName())
+				uid := types.UID("cool-uid")
+				if o.GetName() == "other" {
+					uid = "other-uid"
+				}
+				o.SetOwnerReferences([]metav1.OwnerReference{{UID: uid, Controller: pointer.BoolPtr(true)}})
+				if deleting {
+					o.SetDeletionTimestamp(&now)
+				}
+			}
+			return nil
+		})
 	}
 
 	type want struct {
-		tas []TemplateAssociation
-		err error
+		remaining []corev1.ObjectReference
+		err       error
 	}
 
 	cases := map[string]struct {
 		reason string
 		c      client.Client
-		args   args
+		cr     resource.Composite
 		want   want
 	}{
-		"AnonymousTemplates": {
-			reason: "We should fall back to associating templates with references by order if any template is not named.",
-			args: args{
-				cr: &fake.Composite{},
-				comp: &v1.Composition{
-					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{t0, {Name: nil}}},
-				},
+		"GetCompositionError": {
+			reason: "Errors getting the composite resource's Composition should be returned.",
+			c: &test.MockClient{
+				MockGet: test.NewMockGetFn(errBoom),
 			},
 			want: want{
-				tas: []TemplateAssociation{{Template: t0}, {Template: v1.ComposedTemplate{Name: nil}}},
+				err: errors.Wrap(errBoom, errGetComp),
 			},
 		},
-		"ResourceNotFoundError": {
-			reason: "Non-existent resources should be ignored.",
+		"RevisionDeletionOrder": {
+			reason: "The deletion order of the CompositionRevision the composite resource references should take precedence over that of its Composition.",
 			c: &test.MockClient{
-				MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "")),
-			},
-			args: args{
-				cr: &fake.Composite{
-					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				MockGet: func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+					if rev, ok := obj.(*v1alpha1.CompositionRevision); ok {
+						rev.SetLabels(map[string]string{v1alpha1.LabelCompositionName: "cool-comp"})
+						rev.Spec.CompositionSpec = v1.CompositionSpec{
+							Resources:     comp.Resources,
+							DeletionOrder: []string{vpc},
+						}
+						return nil
+					}
+					return withComposed(map[string]bool{vpc: true, subnet: true}, false)(ctx, key, obj)
 				},
-				comp: &v1.Composition{
-					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{t0}},
+				MockDelete: func(_ context.Context, obj client.Object, _ ...client.DeleteOption) error {
+					if obj.GetName() != vpc {
+						t.Errorf("Delete(...): unexpected deletion of %q", obj.GetName())
+					}
+					return nil
 				},
 			},
+			cr: func() resource.Composite {
+				cr := xr()
+				SetCompositionRevisionReference(cr, &corev1.LocalObjectReference{Name: "cool-comp-1"})
+				return cr
+			}(),
 			want: want{
-				tas: []TemplateAssociation{{Template: t0}},
+				remaining: []corev1.ObjectReference{rv, rs},
 			},
 		},
-		"GetResourceError": {
-			reason: "Errors getting a referenced resource should be returned.",
+		"RevisionNotFound": {
+			reason: "The Composition should be used if the CompositionRevision the composite resource references no longer exists.",
 			c: &test.MockClient{
-				MockGet: test.NewMockGetFn(errBoom),
-			},
-			args: args{
-				cr: &fake.Composite{
-					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
+				MockGet: func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+					if _, ok := obj.(*v1alpha1.CompositionRevision); ok {
+						return kerrors.NewNotFound(schema.GroupResource{}, key.Name)
+					}
+					return withComposed(map[string]bool{vpc: true, subnet: true}, false)(ctx, key, obj)
 				},
-				comp: &v1.Composition{
-					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{t0}},
+				MockDelete: func(_ context.Context, obj client.Object, _ ...client.DeleteOption) error {
+					if obj.GetName() != subnet {
+						t.Errorf("Delete(...): unexpected deletion of %q", obj.GetName())
+					}
+					return nil
 				},
 			},
+			cr: func() resource.Composite {
+				cr := xr()
+				SetCompositionRevisionReference(cr, &corev1.LocalObjectReference{Name: "cool-comp-1"})
+				return cr
+			}(),
 			want: want{
-				err: errors.Wrap(errBoom, errGetComposed),
+				remaining: []corev1.ObjectReference{rs, rv},
 			},
 		},
-		"AnonymousResource": {
-			reason: "We should fall back to associating templates with references by order if any resource is not annotated with its template name.",
+		"GetComposedError": {
+			reason: "Errors getting a composed resource should be returned.",
 			c: &test.MockClient{
-				// Return an empty (and thus unannotated) composed resource.
-				MockGet: test.NewMockGetFn(nil),
-			},
-			args: args{
-				cr: &fake.Composite{
-					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
-				},
-				comp: &v1.Composition{
-					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{t0}},
-				},
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					if _, ok := obj.(*composed.Unstructured); ok {
+						return errBoom
+					}
+					return nil
+				}),
 			},
 			want: want{
-				tas: []TemplateAssociation{{Template: t0, Reference: r0}},
+				err: errors.Wrap(errBoom, errGetComposed),
 			},
 		},
-		"AssociatedResource": {
-			reason: "We should associate referenced resources by their template name annotation.",
+		"DeleteFirst": {
+			reason: "Only the first extant composed resource in deletion order should be deleted.",
 			c: &test.MockClient{
-				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-					SetCompositionResourceName(obj.(metav1.Object), n0)
+				MockGet: withComposed(map[string]bool{vpc: true, subnet: true, "other": true}, false),
+				MockDelete: func(_ context.Context, obj client.Object, _ ...client.DeleteOption) error {
+					if obj.GetName() != subnet {
+						t.Errorf("Delete(...): unexpected deletion of %q", obj.GetName())
+					}
 					return nil
-				}),
-			},
-			args: args{
-				cr: &fake.Composite{
-					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
-				},
-				comp: &v1.Composition{
-					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{t0}},
 				},
 			},
 			want: want{
-				tas: []TemplateAssociation{{Template: t0, Reference: r0}},
+				remaining: []corev1.ObjectReference{rs, rv},
 			},
 		},
-		"UncontrolledResource": {
-			reason: "We should not garbage collect a resource that we don't control.",
+		"WaitForDeletion": {
+			reason: "We should not delete another composed resource while the first is being deleted.",
 			c: &test.MockClient{
-				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-					// The template used to create this resource is no longer known to us.
-					SetCompositionResourceName(obj, "unknown")
-
-					// This resource is not controlled by us.
-					ctrl := true
-					obj.SetOwnerReferences([]metav1.OwnerReference{{
-						Controller: &ctrl,
-						UID:        types.UID("who-dat"),
-					}})
-					return nil
-				}),
-			},
-			args: args{
-				cr: &fake.Composite{
-					ObjectMeta:                  metav1.ObjectMeta{UID: types.UID("very-unique")},
-					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
-				},
-				comp: &v1.Composition{
-					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{t0}},
-				},
+				MockGet: withComposed(map[string]bool{vpc: true, subnet: true}, true),
 			},
 			want: want{
-				tas: []TemplateAssociation{{Template: t0}},
+				remaining: []corev1.ObjectReference{rs, rv},
 			},
 		},
-		"GarbageCollectionError": {
-			reason: "We should return errors encountered while garbage collecting a composed resource.",
+		"DeleteNext": {
+			reason: "We should delete the next composed resource once the first no longer exists.",
 			c: &test.MockClient{
-				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-					// The template used to create this resource is no longer known to us.
-					SetCompositionResourceName(obj, "unknown")
+				MockGet: withComposed(map[string]bool{vpc: true}, false),
+				MockDelete: func(_ context.Context, obj client.Object, _ ...client.DeleteOption) error {
+					if obj.GetName() != vpc {
+						t.Errorf("Delete(...): unexpected deletion of %q", obj.GetName())
+					}
 					return nil
-				}),
-				MockDelete: test.NewMockDeleteFn(errBoom),
-			},
-			args: args{
-				cr: &fake.Composite{
-					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
-				},
-				comp: &v1.Composition{
-					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{t0}},
 				},
 			},
 			want: want{
-				err: errors.Wrap(errBoom, errGCComposed),
+				remaining: []corev1.ObjectReference{rv},
 			},
 		},
-		"GarbageCollectedResource": {
-			reason: "We should not return a resource that we successfully garbage collect.",
+		"AllDeleted": {
+			reason: "No composed resources should remain once all are deleted.",
 			c: &test.MockClient{
-				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
-					// The template used to create this resource is no longer known to us.
-					SetCompositionResourceName(obj, "unknown")
-					return nil
-				}),
-				MockDelete: test.NewMockDeleteFn(nil),
+				MockGet: withComposed(map[string]bool{}, false),
 			},
-			args: args{
-				cr: &fake.Composite{
-					ComposedResourcesReferencer: fake.ComposedResourcesReferencer{Refs: []corev1.ObjectReference{r0}},
-				},
-				comp: &v1.Composition{
-					Spec: v1.CompositionSpec{Resources: []v1.ComposedTemplate{t0}},
-				},
+			want: want{
+				remaining: []corev1.ObjectReference{},
+			},
+		},
+		"DeleteError": {
+			reason: "Errors deleting a composed resource should be returned.",
+			c: &test.MockClient{
+				MockGet:    withComposed(map[string]bool{vpc: true}, false),
+				MockDelete: test.NewMockDeleteFn(errBoom),
 			},
 			want: want{
-				tas: []TemplateAssociation{{Template: t0}},
+				err: errors.Wrap(errBoom, errDeleteCD),
 			},
 		},
 	}
 
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
-			a := NewGarbageCollectingAssociator(tc.c)
-			got, err := a.AssociateTemplates(tc.args.ctx, tc.args.cr, tc.args.comp)
-
+			cr := tc.cr
+			if cr == nil {
+				cr = xr()
+			}
+			d := NewAPIComposedDeleter(tc.c)
+			got, err := d.DeleteComposed(context.Background(), cr)
 			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
-				t.Errorf("\n%s\nAssociateTemplates(...): -want, +got:\n%s", tc.reason, diff)
+				t.Errorf("\n%s\nDeleteComposed(...): -want error, +got error:\n%s", tc.reason, diff)
 			}
-			if diff := cmp.Diff(tc.want.tas, got); diff != "" {
-				t.Errorf("\n%s\nAssociateTemplates(...): -want, +got:\n%s", tc.reason, diff)
+			if diff := cmp.Diff(tc.want.remaining, got); diff != "" {
+				t.Errorf("\n%s\nDeleteComposed(...): -want, +got:\n%s", tc.reason, diff)
 			}
 		})
 	}
@@ -532,6 +2320,7 @@ func TestFetch(t *testing.T) {
 	fromKey := v1.ConnectionDetailTypeFromConnectionSecretKey
 	fromVal := v1.ConnectionDetailTypeFromValue
 	fromField := v1.ConnectionDetailTypeFromFieldPath
+	combine := v1.ConnectionDetailTypeCombine
 
 	sref := &xpv1.SecretReference{Name: "foo", Namespace: "bar"}
 	s := &corev1.Secret{
@@ -540,6 +2329,17 @@ func TestFetch(t *testing.T) {
 			"bar": []byte("b"),
 		},
 	}
+	getSecret := test.NewMockGetFn(nil, func(obj client.Object) error {
+		s.DeepCopyInto(obj.(*corev1.Secret))
+		return nil
+	})
+
+	toUpper := v1.StringConversionTypeToUpper
+	multiply := v1.Transform{
+		Type: v1.TransformTypeMath,
+		Math: &v1.MathTransform{Type: v1.MathTransformTypeMultiply, Multiply: pointer.Int64Ptr(2)},
+	}
+	_, errMultiply := multiply.Transform("a")
 
 	type args struct {
 		kube client.Client
@@ -843,6 +2643,132 @@ func TestFetch(t *testing.T) {
 				},
 			},
 		},
+		"SuccessTransforms": {
+			reason: "Should transform connection details before publishing them",
+			args: args{
+				kube: &test.MockClient{MockGet: getSecret},
+				cd: &fake.Composed{
+					ConnectionSecretWriterTo: fake.ConnectionSecretWriterTo{Ref: sref},
+					ObjectMeta: metav1.ObjectMeta{
+						Generation: 4,
+					},
+				},
+				t: v1.ComposedTemplate{ConnectionDetails: []v1.ConnectionDetail{
+					{
+						FromConnectionSecretKey: pointer.StringPtr("foo"),
+						Type:                    &fromKey,
+						Transforms: []v1.Transform{{
+							Type:   v1.TransformTypeString,
+							String: &v1.StringTransform{Type: v1.StringTransformTypeConvert, Convert: &toUpper},
+						}},
+					},
+					{
+						Name:          pointer.StringPtr("generation"),
+						FromFieldPath: pointer.StringPtr("objectMeta.generation"),
+						Type:          &fromField,
+						Transforms:    []v1.Transform{multiply},
+					},
+				}},
+			},
+			want: want{
+				conn: managed.ConnectionDetails{
+					"foo":        []byte("A"),
+					"generation": []byte("8"),
+				},
+			},
+		},
+		"TransformError": {
+			reason: "Should return an error if a connection detail cannot be transformed",
+			args: args{
+				kube: &test.MockClient{MockGet: getSecret},
+				cd: &fake.Composed{
+					ConnectionSecretWriterTo: fake.ConnectionSecretWriterTo{Ref: sref},
+				},
+				t: v1.ComposedTemplate{ConnectionDetails: []v1.ConnectionDetail{
+					{
+						FromConnectionSecretKey: pointer.StringPtr("foo"),
+						Type:                    &fromKey,
+						Transforms:              []v1.Transform{multiply},
+					},
+				}},
+			},
+			want: want{
+				err: errors.Wrapf(errMultiply, errFmtConnDetailTransform, 0, "foo"),
+			},
+		},
+		"CombineNotSet": {
+			reason: "Should error if Combine type combine is not set",
+			args: args{
+				kube: &test.MockClient{MockGet: getSecret},
+				cd: &fake.Composed{
+					ConnectionSecretWriterTo: fake.ConnectionSecretWriterTo{Ref: sref},
+				},
+				t: v1.ComposedTemplate{ConnectionDetails: []v1.ConnectionDetail{
+					{
+						Name: pointer.StringPtr("url"),
+						Type: &combine,
+					},
+				}},
+			},
+			want: want{
+				err: errors.Errorf(errFmtConnDetailCombine, v1.ConnectionDetailTypeCombine),
+			},
+		},
+		"CombineVariableNotAvailable": {
+			reason: "Should not publish a combined connection detail until all of its variables are available",
+			args: args{
+				kube: &test.MockClient{MockGet: getSecret},
+				cd: &fake.Composed{
+					ConnectionSecretWriterTo: fake.ConnectionSecretWriterTo{Ref: sref},
+				},
+				t: v1.ComposedTemplate{ConnectionDetails: []v1.ConnectionDetail{
+					{
+						Name: pointer.StringPtr("url"),
+						Type: &combine,
+						Combine: &v1.ConnectionDetailCombine{
+							Variables: []v1.ConnectionDetailVariable{
+								{FromConnectionSecretKey: pointer.StringPtr("foo")},
+								{FromConnectionSecretKey: pointer.StringPtr("none")},
+							},
+							Strategy: v1.CombineStrategyString,
+							String:   &v1.StringCombine{Format: "%s:%s"},
+						},
+					},
+				}},
+			},
+		},
+		"SuccessCombine": {
+			reason: "Should combine connection secret keys, field paths, and values into one connection detail",
+			args: args{
+				kube: &test.MockClient{MockGet: getSecret},
+				cd: &fake.Composed{
+					ConnectionSecretWriterTo: fake.ConnectionSecretWriterTo{Ref: sref},
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test",
+					},
+				},
+				t: v1.ComposedTemplate{ConnectionDetails: []v1.ConnectionDetail{
+					{
+						Name: pointer.StringPtr("url"),
+						Combine: &v1.ConnectionDetailCombine{
+							Variables: []v1.ConnectionDetailVariable{
+								{FromConnectionSecretKey: pointer.StringPtr("foo")},
+								{FromConnectionSecretKey: pointer.StringPtr("bar")},
+								{FromFieldPath: pointer.StringPtr("objectMeta.name")},
+								{Value: pointer.StringPtr("db")},
+							},
+							Strategy: v1.CombineStrategyString,
+							String:   &v1.StringCombine{Format: "postgres://%s:%s@%s/%s"},
+						},
+					},
+				}},
+			},
+			want: want{
+				conn: managed.ConnectionDetails{
+					"url": []byte("postgres://a:b@test/db"),
+				},
+			},
+		},
 	}
 	for name, tc := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -901,6 +2827,13 @@ func TestConnectionDetailType(t *testing.T) {
 			},
 			want: v1.ConnectionDetailTypeFromFieldPath,
 		},
+		"CombineInferred": {
+			d: v1.ConnectionDetail{
+				Name:    &name,
+				Combine: &v1.ConnectionDetailCombine{},
+			},
+			want: v1.ConnectionDetailTypeCombine,
+		},
 	}
 
 	for name, tc := range cases {
@@ -1052,6 +2985,184 @@ func TestIsReady(t *testing.T) {
 				ready: true,
 			},
 		},
+		"MatchIntegerGreaterThanOrEqualFalse": {
+			reason: "If the value of the field is less than the bound, it should return false",
+			args: args{
+				cd: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]interface{}{
+						"spec": map[string]interface{}{
+							"someNum": int64(4),
+						},
+					}
+				}),
+				t: v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{Type: "MatchIntegerGreaterThanOrEqual", FieldPath: "spec.someNum", MatchInteger: 5}}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"MatchIntegerGreaterThanOrEqualTrue": {
+			reason: "If the value of the field is equal to the bound, it should return true",
+			args: args{
+				cd: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]interface{}{
+						"spec": map[string]interface{}{
+							"someNum": int64(5),
+						},
+					}
+				}),
+				t: v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{Type: "MatchIntegerGreaterThanOrEqual", FieldPath: "spec.someNum", MatchInteger: 5}}},
+			},
+			want: want{
+				ready: true,
+			},
+		},
+		"MatchIntegerGreaterThanOrEqualMissing": {
+			reason: "If the field does not exist, it should return false",
+			args: args{
+				cd: composed.New(),
+				t:  v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{Type: "MatchIntegerGreaterThanOrEqual", FieldPath: "status.availableReplicas", MatchInteger: 1}}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"MatchIntegerLessThanOrEqualFalse": {
+			reason: "If the value of the field is greater than the bound, it should return false",
+			args: args{
+				cd: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]interface{}{
+						"spec": map[string]interface{}{
+							"someNum": int64(6),
+						},
+					}
+				}),
+				t: v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{Type: "MatchIntegerLessThanOrEqual", FieldPath: "spec.someNum", MatchInteger: 5}}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"MatchIntegerLessThanOrEqualTrue": {
+			reason: "If the value of the field is less than the bound, it should return true",
+			args: args{
+				cd: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]interface{}{
+						"spec": map[string]interface{}{
+							"someNum": int64(4),
+						},
+					}
+				}),
+				t: v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{Type: "MatchIntegerLessThanOrEqual", FieldPath: "spec.someNum", MatchInteger: 5}}},
+			},
+			want: want{
+				ready: true,
+			},
+		},
+		"MatchTrueErr": {
+			reason: "If the value cannot be fetched due to fieldPath being misconfigured, error should be returned",
+			args: args{
+				cd: composed.New(),
+				t:  v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{Type: "MatchTrue", FieldPath: "metadata..uid"}}},
+			},
+			want: want{
+				err: errors.Wrapf(errors.New("unexpected '.' at position 9"), "cannot parse path %q", "metadata..uid"),
+			},
+		},
+		"MatchTrueMissing": {
+			reason: "If the field does not exist, MatchTrue should return false",
+			args: args{
+				cd: composed.New(),
+				t:  v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{Type: "MatchTrue", FieldPath: "status.atProvider.running"}}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"MatchTrueTrue": {
+			reason: "If the value of the field is true, MatchTrue should return true",
+			args: args{
+				cd: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]interface{}{
+						"spec": map[string]interface{}{
+							"running": true,
+						},
+					}
+				}),
+				t: v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{Type: "MatchTrue", FieldPath: "spec.running"}}},
+			},
+			want: want{
+				ready: true,
+			},
+		},
+		"MatchFalseFalse": {
+			reason: "If the value of the field is true, MatchFalse should return false",
+			args: args{
+				cd: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]interface{}{
+						"spec": map[string]interface{}{
+							"running": true,
+						},
+					}
+				}),
+				t: v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{Type: "MatchFalse", FieldPath: "spec.running"}}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"MatchFalseTrue": {
+			reason: "If the value of the field is false, MatchFalse should return true",
+			args: args{
+				cd: composed.New(func(r *composed.Unstructured) {
+					r.Object = map[string]interface{}{
+						"spec": map[string]interface{}{
+							"running": false,
+						},
+					}
+				}),
+				t: v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{Type: "MatchFalse", FieldPath: "spec.running"}}},
+			},
+			want: want{
+				ready: true,
+			},
+		},
+		"MatchConditionMissing": {
+			reason: "If the MatchCondition type is chosen without a matchCondition, it should return an error",
+			args: args{
+				cd: composed.New(),
+				t:  v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{Type: "MatchCondition"}}},
+			},
+			want: want{
+				err: errors.Errorf(errFmtMatchCondition, 0),
+			},
+		},
+		"MatchConditionFalse": {
+			reason: "If the status of the condition does not match, it should return false",
+			args: args{
+				cd: composed.New(composed.WithConditions(xpv1.Condition{Type: "Progressing", Status: corev1.ConditionFalse})),
+				t: v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{
+					Type:           "MatchCondition",
+					MatchCondition: &v1.MatchConditionReadinessCheck{Type: "Progressing", Status: corev1.ConditionTrue},
+				}}},
+			},
+			want: want{
+				ready: false,
+			},
+		},
+		"MatchConditionTrue": {
+			reason: "If the status of the condition does match, it should return true",
+			args: args{
+				cd: composed.New(composed.WithConditions(xpv1.Condition{Type: "Progressing", Status: corev1.ConditionTrue})),
+				t: v1.ComposedTemplate{ReadinessChecks: []v1.ReadinessCheck{{
+					Type:           "MatchCondition",
+					MatchCondition: &v1.MatchConditionReadinessCheck{Type: "Progressing", Status: corev1.ConditionTrue},
+				}}},
+			},
+			want: want{
+				ready: true,
+			},
+		},
 		"UnknownType": {
 			reason: "If unknown type is chosen, it should return an error",
 			args: args{
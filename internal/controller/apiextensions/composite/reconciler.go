@@ -18,12 +18,16 @@ package composite
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -39,9 +43,13 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
 
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
+	"github.com/crossplane/crossplane/internal/connection"
 )
 
 const (
+	finalizer = "composite.apiextensions.crossplane.io"
+
 	shortWait = 30 * time.Second
 	longWait  = 1 * time.Minute
 	timeout   = 2 * time.Minute
@@ -54,6 +62,7 @@ const (
 	errUpdateStatus = "cannot update composite resource status"
 	errSelectComp   = "cannot select Composition"
 	errGetComp      = "cannot get Composition"
+	errGetRev       = "cannot get CompositionRevision"
 	errConfigure    = "cannot configure composite resource"
 	errPublish      = "cannot publish connection details"
 	errRenderCD     = "cannot render composed resource"
@@ -61,8 +70,27 @@ const (
 	errValidate     = "refusing to use invalid Composition"
 	errInline       = "cannot inline Composition patch sets"
 	errAssociate    = "cannot associate composed resources with Composition resource templates"
-
-	errFmtRender = "cannot render composed resource from resource template at index %d"
+	errEnvironment  = "cannot fetch environment"
+	errOrphanCD     = "cannot orphan composed resources"
+	errDeleteCDs    = "cannot delete composed resources"
+	errUnpublish    = "cannot unpublish connection details"
+	errAddFinalizer = "cannot add composite resource finalizer"
+	errRemFinalizer = "cannot remove composite resource finalizer"
+
+	errComposedUnavailable  = "composed resource patches from a composed resource that is not yet available"
+	errComposedBlocked      = "composed resource depends on composed resources that are not yet ready"
+	errCompositeUnavailable = "composite resource patches from a composed resource that is not yet available"
+
+	errFmtRevision    = "CompositionRevision %q is not a revision of Composition %q"
+	errFmtRender      = "cannot render composed resource from resource template at index %d"
+	errFmtUnavailable = "not yet applying composed resource from resource template at index %d"
+	errFmtUnpatched   = "not yet patching composite resource from resource template at index %d"
+
+	msgFmtUnready = "Unready resources: %s"
+	msgFmtBlocked = "Waiting for dependencies to become ready: %s"
+
+	msgFmtOrphaned = "Orphaned composed resource %s %q"
+	msgFmtDeleting = "Waiting for composed resource %s %q to be deleted; %d composed resources remain"
 )
 
 // Event reasons.
@@ -70,8 +98,19 @@ const (
 	reasonResolve event.Reason = "SelectComposition"
 	reasonCompose event.Reason = "ComposeResources"
 	reasonPublish event.Reason = "PublishConnectionSecret"
+	reasonRotate  event.Reason = "RotateConnectionSecret"
+	reasonDelete  event.Reason = "DeleteComposedResources"
 )
 
+// templateName returns the name of the supplied template, or its index if it
+// is anonymous.
+func templateName(t v1.ComposedTemplate, i int) string {
+	if t.Name != nil {
+		return *t.Name
+	}
+	return strconv.Itoa(i)
+}
+
 // ControllerName returns the recommended name for controllers that use this
 // package to reconcile a particular kind of composite resource.
 func ControllerName(name string) string {
@@ -87,10 +126,11 @@ type ConnectionSecretFilterer interface {
 // supplied resource. Publishers must handle the case in which the supplied
 // ConnectionDetails are empty.
 type ConnectionPublisher interface {
-	// PublishConnection details for the supplied resource. Publishing must be
-	// additive; i.e. if details (a, b, c) are published, subsequently
-	// publishing details (b, c, d) should update (b, c) but not remove a.
-	// Returns 'published' if the publish was not a no-op.
+	// PublishConnection details for the supplied resource. Publishing must
+	// replace any previously published details; i.e. if details (a, b, c) are
+	// published, subsequently publishing details (b, c, d) should update
+	// (b, c), add d, and remove a. Returns 'published' if the publish was not
+	// a no-op.
 	PublishConnection(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) (published bool, err error)
 }
 
@@ -103,6 +143,22 @@ func (fn ConnectionPublisherFn) PublishConnection(ctx context.Context, o resourc
 	return fn(ctx, o, c)
 }
 
+// A ConnectionUnpublisher unpublishes the connection details of the supplied
+// resource, for example because it is being deleted.
+type ConnectionUnpublisher interface {
+	// UnpublishConnection details for the supplied resource.
+	UnpublishConnection(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) error
+}
+
+// A ConnectionUnpublisherFn unpublishes the connection details of the
+// supplied resource.
+type ConnectionUnpublisherFn func(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) error
+
+// UnpublishConnection details for the supplied resource.
+func (fn ConnectionUnpublisherFn) UnpublishConnection(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) error {
+	return fn(ctx, o, c)
+}
+
 // A CompositionSelector selects a composition reference.
 type CompositionSelector interface {
 	SelectComposition(ctx context.Context, cr resource.Composite) error
@@ -143,6 +199,70 @@ func (fn RendererFn) Render(ctx context.Context, cp resource.Composite, cd resou
 	return fn(ctx, cp, cd, t)
 }
 
+// An EnvironmentFetcher fetches the environment from which the composed
+// resources of a composite resource may be patched.
+type EnvironmentFetcher interface {
+	FetchEnvironment(ctx context.Context, cr resource.Composite, comp *v1.Composition) (*v1alpha1.EnvironmentConfig, error)
+}
+
+// An EnvironmentFetcherFn fetches the environment from which the composed
+// resources of a composite resource may be patched.
+type EnvironmentFetcherFn func(ctx context.Context, cr resource.Composite, comp *v1.Composition) (*v1alpha1.EnvironmentConfig, error)
+
+// FetchEnvironment of the supplied composite resource.
+func (fn EnvironmentFetcherFn) FetchEnvironment(ctx context.Context, cr resource.Composite, comp *v1.Composition) (*v1alpha1.EnvironmentConfig, error) {
+	return fn(ctx, cr, comp)
+}
+
+// An EnvironmentRenderer is used to render a composed resource using an
+// environment.
+type EnvironmentRenderer interface {
+	RenderFromEnvironment(ctx context.Context, env *v1alpha1.EnvironmentConfig, cd resource.Composed, t v1.ComposedTemplate) error
+}
+
+// An EnvironmentRendererFn may be used to render a composed resource using an
+// environment.
+type EnvironmentRendererFn func(ctx context.Context, env *v1alpha1.EnvironmentConfig, cd resource.Composed, t v1.ComposedTemplate) error
+
+// RenderFromEnvironment renders the supplied composed resource using the
+// supplied environment and template as inputs.
+func (fn EnvironmentRendererFn) RenderFromEnvironment(ctx context.Context, env *v1alpha1.EnvironmentConfig, cd resource.Composed, t v1.ComposedTemplate) error {
+	return fn(ctx, env, cd, t)
+}
+
+// A ComposedRenderer is used to render a composed resource using the observed
+// state of other composed resources of the same composite resource.
+type ComposedRenderer interface {
+	RenderFromComposed(ctx context.Context, observed map[string]resource.Composed, cd resource.Composed, t v1.ComposedTemplate) error
+}
+
+// A ComposedRendererFn may be used to render a composed resource using the
+// observed state of other composed resources of the same composite resource.
+type ComposedRendererFn func(ctx context.Context, observed map[string]resource.Composed, cd resource.Composed, t v1.ComposedTemplate) error
+
+// RenderFromComposed renders the supplied composed resource using the supplied
+// observed composed resources and template as inputs.
+func (fn ComposedRendererFn) RenderFromComposed(ctx context.Context, observed map[string]resource.Composed, cd resource.Composed, t v1.ComposedTemplate) error {
+	return fn(ctx, observed, cd, t)
+}
+
+// A CompositeRenderer is used to render a composite resource using one of its
+// composed resources, and the observed state of its other composed resources.
+type CompositeRenderer interface {
+	RenderComposite(ctx context.Context, cp resource.Composite, observed map[string]resource.Composed, cd resource.Composed, t v1.ComposedTemplate) error
+}
+
+// A CompositeRendererFn may be used to render a composite resource using one
+// of its composed resources, and the observed state of its other composed
+// resources.
+type CompositeRendererFn func(ctx context.Context, cp resource.Composite, observed map[string]resource.Composed, cd resource.Composed, t v1.ComposedTemplate) error
+
+// RenderComposite renders the supplied composite resource using the supplied
+// composed resource, observed composed resources, and template as inputs.
+func (fn CompositeRendererFn) RenderComposite(ctx context.Context, cp resource.Composite, observed map[string]resource.Composed, cd resource.Composed, t v1.ComposedTemplate) error {
+	return fn(ctx, cp, observed, cd, t)
+}
+
 // ConnectionDetailsFetcher fetches the connection details of the Composed resource.
 type ConnectionDetailsFetcher interface {
 	FetchConnectionDetails(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error)
@@ -170,6 +290,40 @@ func (fn ReadinessCheckerFn) IsReady(ctx context.Context, cd resource.Composed,
 	return fn(ctx, cd, t)
 }
 
+// An Orphaner orphans the composed resources of a composite resource that is
+// being deleted, according to their deletion policies.
+type Orphaner interface {
+	Orphan(ctx context.Context, cr resource.Composite) ([]corev1.ObjectReference, error)
+}
+
+// An OrphanerFn orphans the composed resources of a composite resource that is
+// being deleted, according to their deletion policies.
+type OrphanerFn func(ctx context.Context, cr resource.Composite) ([]corev1.ObjectReference, error)
+
+// Orphan the composed resources of the supplied composite resource, returning
+// references to those that were orphaned.
+func (fn OrphanerFn) Orphan(ctx context.Context, cr resource.Composite) ([]corev1.ObjectReference, error) {
+	return fn(ctx, cr)
+}
+
+// A ComposedDeleter deletes the composed resources of a composite resource that
+// is being deleted.
+type ComposedDeleter interface {
+	// DeleteComposed resources of the supplied composite resource, returning
+	// references to those that still exist.
+	DeleteComposed(ctx context.Context, cr resource.Composite) ([]corev1.ObjectReference, error)
+}
+
+// A ComposedDeleterFn deletes the composed resources of a composite resource
+// that is being deleted.
+type ComposedDeleterFn func(ctx context.Context, cr resource.Composite) ([]corev1.ObjectReference, error)
+
+// DeleteComposed resources of the supplied composite resource, returning
+// references to those that still exist.
+func (fn ComposedDeleterFn) DeleteComposed(ctx context.Context, cr resource.Composite) ([]corev1.ObjectReference, error) {
+	return fn(ctx, cr)
+}
+
 // ReconcilerOption is used to configure the Reconciler.
 type ReconcilerOption func(*Reconciler)
 
@@ -211,6 +365,30 @@ func WithCompositionTemplateAssociator(a CompositionTemplateAssociator) Reconcil
 	}
 }
 
+// WithEnvironmentFetcher specifies how the Reconciler should fetch the
+// environment of composite resources.
+func WithEnvironmentFetcher(f EnvironmentFetcher) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composition.EnvironmentFetcher = f
+	}
+}
+
+// WithEnvironmentRenderer specifies how the Reconciler should render composed
+// resources using their composite resource's environment.
+func WithEnvironmentRenderer(rd EnvironmentRenderer) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composed.EnvironmentRenderer = rd
+	}
+}
+
+// WithComposedRenderer specifies how the Reconciler should render composed
+// resources using other composed resources of the same composite resource.
+func WithComposedRenderer(rd ComposedRenderer) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composed.ComposedRenderer = rd
+	}
+}
+
 // WithRenderer specifies how the Reconciler should render composed resources.
 func WithRenderer(rd Renderer) ReconcilerOption {
 	return func(r *Reconciler) {
@@ -258,27 +436,66 @@ func WithConnectionPublisher(p ConnectionPublisher) ReconcilerOption {
 	}
 }
 
+// WithConnectionUnpublisher specifies how the Reconciler should unpublish
+// connection secrets when composite resources are deleted.
+func WithConnectionUnpublisher(u ConnectionUnpublisher) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composite.ConnectionUnpublisher = u
+	}
+}
+
+// WithCompositeFinalizer specifies how the Reconciler should add and remove
+// finalizers to and from composite resources.
+func WithCompositeFinalizer(f resource.Finalizer) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composite.Finalizer = f
+	}
+}
+
+// WithOrphaner specifies how the Reconciler should orphan the composed
+// resources of composite resources that are being deleted.
+func WithOrphaner(o Orphaner) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composite.Orphaner = o
+	}
+}
+
+// WithComposedDeleter specifies how the Reconciler should delete the composed
+// resources of composite resources that are being deleted.
+func WithComposedDeleter(d ComposedDeleter) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.composite.ComposedDeleter = d
+	}
+}
+
 // WithCompositeRenderer specifies how the Reconciler should render composite resources.
-func WithCompositeRenderer(rd Renderer) ReconcilerOption {
+func WithCompositeRenderer(rd CompositeRenderer) ReconcilerOption {
 	return func(r *Reconciler) {
-		r.composite.Renderer = rd
+		r.composite.CompositeRenderer = rd
 	}
 }
 
 type composition struct {
 	CompositionValidator
 	CompositionTemplateAssociator
+	EnvironmentFetcher
 }
 
 type compositeResource struct {
+	resource.Finalizer
 	CompositionSelector
 	Configurator
 	ConnectionPublisher
-	Renderer
+	ConnectionUnpublisher
+	CompositeRenderer
+	Orphaner
+	ComposedDeleter
 }
 
 type composedResource struct {
 	Renderer
+	EnvironmentRenderer
+	ComposedRenderer
 	ConnectionDetailsFetcher
 	ReadinessChecker
 }
@@ -289,6 +506,7 @@ func NewReconciler(mgr manager.Manager, of resource.CompositeKind, opts ...Recon
 		return composite.New(composite.WithGroupVersionKind(schema.GroupVersionKind(of)))
 	}
 	kube := unstructured.NewClient(mgr.GetClient())
+	pub := NewAPIFilteredSecretPublisher(kube, []string{})
 
 	r := &Reconciler{
 		client: resource.ClientApplicator{
@@ -298,22 +516,26 @@ func NewReconciler(mgr manager.Manager, of resource.CompositeKind, opts ...Recon
 		newComposite: nc,
 
 		composition: composition{
-			CompositionValidator: ValidationChain{
-				CompositionValidatorFn(RejectMixedTemplates),
-				CompositionValidatorFn(RejectDuplicateNames),
-			},
+			CompositionValidator:          DefaultValidationChain(),
 			CompositionTemplateAssociator: NewGarbageCollectingAssociator(kube),
+			EnvironmentFetcher:            NewAPIEnvironmentFetcher(kube),
 		},
 
 		composite: compositeResource{
-			CompositionSelector: NewAPILabelSelectorResolver(kube),
-			Configurator:        NewConfiguratorChain(NewAPINamingConfigurator(kube), NewAPIConfigurator(kube)),
-			ConnectionPublisher: NewAPIFilteredSecretPublisher(kube, []string{}),
-			Renderer:            RendererFn(RenderComposite),
+			Finalizer:             resource.NewAPIFinalizer(kube, finalizer),
+			CompositionSelector:   NewAPILabelSelectorResolver(kube),
+			Configurator:          NewConfiguratorChain(NewAPINamingConfigurator(kube), NewAPIConfigurator(kube)),
+			ConnectionPublisher:   pub,
+			ConnectionUnpublisher: pub,
+			CompositeRenderer:     CompositeRendererFn(RenderComposite),
+			Orphaner:              NewAPIOrphaner(kube),
+			ComposedDeleter:       NewAPIComposedDeleter(kube),
 		},
 
 		composed: composedResource{
 			Renderer:                 NewAPIDryRunRenderer(kube),
+			EnvironmentRenderer:      EnvironmentRendererFn(RenderFromEnvironment),
+			ComposedRenderer:         ComposedRendererFn(RenderFromComposed),
 			ReadinessChecker:         ReadinessCheckerFn(IsReady),
 			ConnectionDetailsFetcher: NewAPIConnectionDetailsFetcher(kube),
 		},
@@ -365,6 +587,58 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		"name", cr.GetName(),
 	)
 
+	// Our finalizer gives us a chance to orphan the composed resources that
+	// should outlive their composite resource, then to delete the rest in
+	// order, waiting for each to be deleted before deleting the next. Composed
+	// resources are instead garbage collected via their controller reference
+	// to their composite resource if it is deleted with foreground
+	// propagation, in which case they are deleted before it is finalized.
+	// Composed resources that should be orphaned have no owner references, so
+	// they are never garbage collected.
+	if meta.WasDeleted(cr) {
+		log = log.WithValues("deletion-timestamp", cr.GetDeletionTimestamp())
+
+		orphaned, err := r.composite.Orphan(ctx, cr)
+		if err != nil {
+			log.Debug(errOrphanCD, "error", err)
+			r.record.Event(cr, event.Warning(reasonDelete, errors.Wrap(err, errOrphanCD)))
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
+		for _, ref := range orphaned {
+			log.Debug("Orphaned composed resource", "kind", ref.Kind, "composed-name", ref.Name)
+			r.record.Event(cr, event.Normal(reasonDelete, fmt.Sprintf(msgFmtOrphaned, ref.Kind, ref.Name)))
+		}
+
+		remaining, err := r.composite.DeleteComposed(ctx, cr)
+		if err != nil {
+			log.Debug(errDeleteCDs, "error", err)
+			r.record.Event(cr, event.Warning(reasonDelete, errors.Wrap(err, errDeleteCDs)))
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
+		if len(remaining) > 0 {
+			log.Debug("Waiting for composed resource to be deleted", "kind", remaining[0].Kind, "composed-name", remaining[0].Name, "remaining", len(remaining))
+			cr.SetConditions(xpv1.Deleting().WithMessage(fmt.Sprintf(msgFmtDeleting, remaining[0].Kind, remaining[0].Name, len(remaining))))
+			return reconcile.Result{RequeueAfter: shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateStatus)
+		}
+
+		if err := r.composite.UnpublishConnection(ctx, cr, nil); err != nil {
+			log.Debug(errUnpublish, "error", err)
+			r.record.Event(cr, event.Warning(reasonDelete, errors.Wrap(err, errUnpublish)))
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
+
+		if err := r.composite.RemoveFinalizer(ctx, cr); err != nil {
+			log.Debug(errRemFinalizer, "error", err)
+			r.record.Event(cr, event.Warning(reasonDelete, errors.Wrap(err, errRemFinalizer)))
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
+
+		// Our composite resource should no longer exist once we've removed
+		// our finalizer, so there is no point updating its status.
+		log.Debug("Successfully finalized composite resource")
+		return reconcile.Result{Requeue: false}, nil
+	}
+
 	if err := r.composite.SelectComposition(ctx, cr); err != nil {
 		log.Debug(errSelectComp, "error", err)
 		r.record.Event(cr, event.Warning(reasonResolve, err))
@@ -381,6 +655,25 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		return reconcile.Result{RequeueAfter: shortWait}, nil
 	}
 
+	// Composite resources are composed from the revision of their Composition
+	// that they reference, if any.
+	if ref := GetCompositionRevisionReference(cr); ref != nil {
+		rev := &v1alpha1.CompositionRevision{}
+		if err := r.client.Get(ctx, types.NamespacedName{Name: ref.Name}, rev); err != nil {
+			log.Debug(errGetRev, "error", err)
+			r.record.Event(cr, event.Warning(reasonCompose, errors.Wrap(err, errGetRev)))
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
+		if rev.GetLabels()[v1alpha1.LabelCompositionName] != comp.GetName() {
+			err := errors.Errorf(errFmtRevision, rev.GetName(), comp.GetName())
+			log.Debug(errGetRev, "error", err)
+			r.record.Event(cr, event.Warning(reasonCompose, err))
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
+		comp.Spec = rev.Spec.CompositionSpec
+		log = log.WithValues("composition-revision", rev.Spec.Revision)
+	}
+
 	if err := r.composite.Configure(ctx, cr, comp); err != nil {
 		log.Debug(errConfigure, "error", err)
 		r.record.Event(cr, event.Warning(reasonCompose, err))
@@ -393,8 +686,9 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		"composition-name", comp.GetName(),
 	)
 
-	// TODO(negz): Composition validation should be handled by a validation
-	// webhook, not by this controller.
+	// Compositions are also validated at admission time when Crossplane's
+	// webhook is enabled, but the webhook is optional and may have admitted
+	// a Composition before it was enabled.
 	if err := r.composition.Validate(comp); err != nil {
 		log.Debug(errValidate, "error", err)
 		r.record.Event(cr, event.Warning(reasonCompose, err))
@@ -415,6 +709,13 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		return reconcile.Result{RequeueAfter: shortWait}, nil
 	}
 
+	env, err := r.composition.FetchEnvironment(ctx, cr, comp)
+	if err != nil {
+		log.Debug(errEnvironment, "error", err)
+		r.record.Event(cr, event.Warning(reasonCompose, errors.Wrap(err, errEnvironment)))
+		return reconcile.Result{RequeueAfter: shortWait}, nil
+	}
+
 	// We want to ensure we can render all of our composed resources before we
 	// apply any of them. We prefer to avoid creating or updating any composed
 	// resources if we know we won't be able to create or update all of them.
@@ -427,11 +728,24 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 			r.record.Event(cr, event.Warning(reasonCompose, errors.Wrapf(err, errFmtRender, i)))
 			return reconcile.Result{RequeueAfter: shortWait}, nil
 		}
+		if err := r.composed.RenderFromEnvironment(ctx, env, cd, ta.Template); err != nil {
+			log.Debug(errRenderCD, "error", err, "index", i)
+			r.record.Event(cr, event.Warning(reasonCompose, errors.Wrapf(err, errFmtRender, i)))
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
 
 		cds[i] = cd
 		refs[i] = *meta.ReferenceTo(cd, cd.GetObjectKind().GroupVersionKind())
 	}
 
+	// We add our finalizer before we create any composed resources, so that we
+	// can orphan or delete them in order when we're deleted.
+	if err := r.composite.AddFinalizer(ctx, cr); err != nil {
+		log.Debug(errAddFinalizer, "error", err)
+		r.record.Event(cr, event.Warning(reasonCompose, errors.Wrap(err, errAddFinalizer)))
+		return reconcile.Result{RequeueAfter: shortWait}, nil
+	}
+
 	// We persist references to our composed resources before we create them.
 	// This way we can render composed resources with non-deterministic names,
 	// and also potentially recover from any errors we encounter while applying
@@ -446,21 +760,91 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	// We apply all of our composed resources before we observe them and update
 	// the composite resource accordingly in the loop below. This ensures that
 	// issues observing and processing one composed resource won't block the
-	// application of another.
-	for _, cd := range cds {
+	// application of another. Composed resources are applied after the
+	// resources they depend on, and otherwise in the order their templates are
+	// declared. They may be patched from the observed state of the resources
+	// applied before them. A resource that patches from a resource that is not
+	// yet available, or that depends on a resource that is not yet ready, is
+	// not applied until a later reconcile.
+	observed := map[string]resource.Composed{}
+	ready := map[string]bool{}
+	included := map[string]bool{}
+	for _, ta := range tas {
+		if ta.Template.Name != nil {
+			included[*ta.Template.Name] = true
+		}
+	}
+	unavailable := make([]error, len(cds))
+	blocked := make([][]string, len(cds))
+	rdy := make([]bool, len(cds))
+	readiness := make([]error, len(cds))
+	for _, i := range applyOrder(tas) {
+		cd := cds[i]
+		tpl := tas[i].Template
+		if b := blockedOn(tpl, included, ready); len(b) > 0 {
+			log.Debug(errComposedBlocked, "index", i, "blocked-on", b)
+			blocked[i] = b
+			continue
+		}
+		err := r.composed.RenderFromComposed(ctx, observed, cd, tpl)
+		if IsUnavailable(err) {
+			log.Debug(errComposedUnavailable, "error", err, "index", i)
+			r.record.Event(cr, event.Normal(reasonCompose, errors.Wrapf(err, errFmtUnavailable, i).Error()))
+			unavailable[i] = err
+			continue
+		}
+		if err != nil {
+			log.Debug(errRenderCD, "error", err, "index", i)
+			r.record.Event(cr, event.Warning(reasonCompose, errors.Wrapf(err, errFmtRender, i)))
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
 		if err := r.client.Apply(ctx, cd, resource.MustBeControllableBy(cr.GetUID())); err != nil {
 			log.Debug(errApply, "error", err)
 			r.record.Event(cr, event.Warning(reasonCompose, err))
 			return reconcile.Result{RequeueAfter: shortWait}, nil
 		}
+
+		// Resources that depend on this one may only be applied once it is
+		// ready. Any error checking readiness is returned while observing.
+		rdy[i], readiness[i] = r.composed.IsReady(ctx, cd, tpl)
+		if tpl.Name != nil {
+			observed[*tpl.Name] = cd
+			ready[*tpl.Name] = rdy[i] && readiness[i] == nil
+		}
 	}
 
 	conn := managed.ConnectionDetails{}
-	ready := 0
-	for i, tpl := range comp.Spec.Resources {
+	statuses := make([]ComposedResourceStatus, len(tas))
+	unready := make([]string, 0)
+	for i, ta := range tas {
 		cd := cds[i]
+		tpl := ta.Template
+		statuses[i] = NewComposedResourceStatus(cd, tpl, i)
+
+		// Resources that were not applied have no state to observe.
+		if len(blocked[i]) > 0 {
+			statuses[i].BlockedOn = blocked[i]
+			statuses[i].Message = fmt.Sprintf(msgFmtBlocked, strings.Join(blocked[i], ", "))
+			unready = append(unready, statuses[i].TemplateName)
+			continue
+		}
+		if unavailable[i] != nil {
+			statuses[i].Message = unavailable[i].Error()
+			unready = append(unready, statuses[i].TemplateName)
+			continue
+		}
 
-		if err := r.composite.Render(ctx, cr, cd, tpl); err != nil {
+		// A composed resource whose fields the composite resource patches
+		// from may not be observed yet. We still publish the connection
+		// details of this resource, but consider it unready until it is.
+		err := r.composite.RenderComposite(ctx, cr, observed, cd, tpl)
+		unpatched := IsUnavailable(err)
+		if unpatched {
+			log.Debug(errCompositeUnavailable, "error", err, "index", i)
+			r.record.Event(cr, event.Normal(reasonCompose, errors.Wrapf(err, errFmtUnpatched, i).Error()))
+			statuses[i].Message = err.Error()
+		}
+		if err != nil && !unpatched {
 			log.Debug(errRenderCR, "error", err)
 			r.record.Event(cr, event.Warning(reasonCompose, err))
 			return reconcile.Result{RequeueAfter: shortWait}, nil
@@ -477,17 +861,18 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 			conn[key] = val
 		}
 
-		rdy, err := r.composed.IsReady(ctx, cd, tpl)
-		if err != nil {
+		if err := readiness[i]; err != nil {
 			log.Debug(errReadiness, "error", err)
 			r.record.Event(cr, event.Warning(reasonCompose, err))
 			return reconcile.Result{RequeueAfter: shortWait}, nil
 		}
 
-		if rdy {
-			ready++
+		statuses[i].Ready = rdy[i]
+		if !rdy[i] || unpatched {
+			unready = append(unready, statuses[i].TemplateName)
 		}
 	}
+	SetComposedResourceStatuses(cr, statuses)
 
 	// We pass a deepcopy because the update method doesn't update status,
 	// but calling update resets any pending status changes.
@@ -511,6 +896,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 
 	r.record.Event(cr, event.Normal(reasonCompose, "Successfully composed resources"))
 
+	rotated := connection.GetLastRotatedTime(cr)
 	published, err := r.composite.PublishConnection(ctx, cr, conn)
 	if err != nil {
 		log.Debug(errPublish, "error", err)
@@ -522,13 +908,17 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		log.Debug("Successfully published connection details")
 		r.record.Event(cr, event.Normal(reasonPublish, "Successfully published connection details"))
 	}
+	if t := connection.GetLastRotatedTime(cr); t != nil && !t.Equal(rotated) {
+		// Consumers may watch for this event in order to restart when the
+		// connection details they use change.
+		log.Debug("Connection details were rotated")
+		r.record.Event(cr, event.Normal(reasonRotate, "Connection details were rotated"))
+	}
 
-	// TODO(muvaf):
-	// * Report which resources are not ready.
-	// * If a resource becomes Unavailable at some point, should we still report
-	//   it as Creating?
-	if ready != len(refs) {
-		cr.SetConditions(xpv1.Creating())
+	// TODO(muvaf): If a resource becomes Unavailable at some point, should we
+	// still report it as Creating?
+	if len(unready) > 0 {
+		cr.SetConditions(xpv1.Creating().WithMessage(fmt.Sprintf(msgFmtUnready, strings.Join(unready, ", "))))
 		return reconcile.Result{RequeueAfter: shortWait}, errors.Wrap(r.client.Status().Update(ctx, cr), errUpdateStatus)
 	}
 
@@ -18,28 +18,37 @@ package composite
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
 	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
 	"github.com/crossplane/crossplane-runtime/pkg/resource"
 	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
 	"github.com/crossplane/crossplane-runtime/pkg/test"
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
 )
 
 func TestReconcile(t *testing.T) {
 	errBoom := errors.New("boom")
 	cd := managed.ConnectionDetails{"a": []byte("b")}
+	now := metav1.Now()
+	deleted := test.NewMockGetFn(nil, func(obj client.Object) error {
+		obj.SetDeletionTimestamp(&now)
+		return nil
+	})
 
 	type args struct {
 		mgr  manager.Manager
@@ -89,6 +98,150 @@ func TestReconcile(t *testing.T) {
 				err: errors.Wrap(errBoom, errGet),
 			},
 		},
+		"OrphanComposedError": {
+			reason: "We should requeue after a short wait if we encounter an error while orphaning composed resources.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{MockGet: deleted},
+					}),
+					WithOrphaner(OrphanerFn(func(_ context.Context, _ resource.Composite) ([]corev1.ObjectReference, error) {
+						return nil, errBoom
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"DeleteComposedError": {
+			reason: "We should requeue after a short wait if we encounter an error while deleting composed resources.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{MockGet: deleted},
+					}),
+					WithOrphaner(OrphanerFn(func(_ context.Context, _ resource.Composite) ([]corev1.ObjectReference, error) {
+						return nil, nil
+					})),
+					WithComposedDeleter(ComposedDeleterFn(func(_ context.Context, _ resource.Composite) ([]corev1.ObjectReference, error) {
+						return nil, errBoom
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"ComposedResourcesDeleting": {
+			reason: "We should report which composed resource we're waiting for, and requeue after a short wait, while composed resources remain.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: deleted,
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								cr := obj.(resource.Composite)
+								want := xpv1.Deleting().WithMessage(fmt.Sprintf(msgFmtDeleting, "Subnet", "cool-subnet", 2))
+								if diff := cmp.Diff(want, cr.GetCondition(xpv1.TypeReady), test.EquateConditions()); diff != "" {
+									t.Errorf("Status().Update(...): -want, +got:\n%s", diff)
+								}
+								return nil
+							}),
+						},
+					}),
+					WithOrphaner(OrphanerFn(func(_ context.Context, _ resource.Composite) ([]corev1.ObjectReference, error) {
+						return nil, nil
+					})),
+					WithComposedDeleter(ComposedDeleterFn(func(_ context.Context, _ resource.Composite) ([]corev1.ObjectReference, error) {
+						return []corev1.ObjectReference{{Kind: "Subnet", Name: "cool-subnet"}, {Kind: "VPC", Name: "cool-vpc"}}, nil
+					})),
+					WithCompositeFinalizer(resource.FinalizerFns{RemoveFinalizerFn: func(_ context.Context, _ resource.Object) error {
+						t.Errorf("RemoveFinalizer(...): unexpected call while composed resources remain")
+						return nil
+					}}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"UnpublishConnectionError": {
+			reason: "We should requeue after a short wait if we encounter an error while unpublishing connection details.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{MockGet: deleted},
+					}),
+					WithOrphaner(OrphanerFn(func(_ context.Context, _ resource.Composite) ([]corev1.ObjectReference, error) {
+						return nil, nil
+					})),
+					WithComposedDeleter(ComposedDeleterFn(func(_ context.Context, _ resource.Composite) ([]corev1.ObjectReference, error) {
+						return nil, nil
+					})),
+					WithConnectionUnpublisher(ConnectionUnpublisherFn(func(_ context.Context, _ resource.ConnectionSecretOwner, _ managed.ConnectionDetails) error {
+						return errBoom
+					})),
+					WithCompositeFinalizer(resource.FinalizerFns{RemoveFinalizerFn: func(_ context.Context, _ resource.Object) error {
+						t.Errorf("RemoveFinalizer(...): unexpected call before connection details are unpublished")
+						return nil
+					}}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"RemoveFinalizerError": {
+			reason: "We should requeue after a short wait if we encounter an error while removing our finalizer.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{MockGet: deleted},
+					}),
+					WithOrphaner(OrphanerFn(func(_ context.Context, _ resource.Composite) ([]corev1.ObjectReference, error) {
+						return nil, nil
+					})),
+					WithComposedDeleter(ComposedDeleterFn(func(_ context.Context, _ resource.Composite) ([]corev1.ObjectReference, error) {
+						return nil, nil
+					})),
+					WithCompositeFinalizer(resource.FinalizerFns{RemoveFinalizerFn: func(_ context.Context, _ resource.Object) error {
+						return errBoom
+					}}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"CompositeResourceFinalized": {
+			reason: "We should not requeue once we've orphaned composed resources and removed our finalizer.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{MockGet: deleted},
+					}),
+					WithOrphaner(OrphanerFn(func(_ context.Context, _ resource.Composite) ([]corev1.ObjectReference, error) {
+						return []corev1.ObjectReference{{Kind: "Database", Name: "cool-db"}}, nil
+					})),
+					WithComposedDeleter(ComposedDeleterFn(func(_ context.Context, _ resource.Composite) ([]corev1.ObjectReference, error) {
+						return nil, nil
+					})),
+					WithCompositeFinalizer(resource.FinalizerFns{RemoveFinalizerFn: func(_ context.Context, _ resource.Object) error {
+						return nil
+					}}),
+				},
+			},
+			want: want{
+				r: reconcile.Result{Requeue: false},
+			},
+		},
 		"SelectCompositionError": {
 			reason: "We should requeue after a short wait if we encounter an error while selecting a composition.",
 			args: args{
@@ -133,6 +286,107 @@ func TestReconcile(t *testing.T) {
 				r: reconcile.Result{RequeueAfter: shortWait},
 			},
 		},
+		"GetCompositionRevisionError": {
+			reason: "We should requeue after a short wait if we encounter an error while getting a composition revision.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if _, ok := obj.(*v1alpha1.CompositionRevision); ok {
+									return errBoom
+								}
+								return nil
+							}),
+						},
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						SetCompositionRevisionReference(cr, &corev1.LocalObjectReference{Name: "cool-comp-1"})
+						return nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"CompositionRevisionMismatch": {
+			reason: "We should requeue after a short wait if the referenced revision is not a revision of the referenced composition.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								switch o := obj.(type) {
+								case *v1.Composition:
+									o.SetName("cool-comp")
+								case *v1alpha1.CompositionRevision:
+									o.SetLabels(map[string]string{v1alpha1.LabelCompositionName: "other-comp"})
+								}
+								return nil
+							}),
+						},
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{Name: "cool-comp"})
+						SetCompositionRevisionReference(cr, &corev1.LocalObjectReference{Name: "other-comp-1"})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						t.Errorf("Configure(...): should not be called for a mismatched revision")
+						return nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"ComposeFromRevision": {
+			reason: "We should compose resources using the spec of the referenced composition revision.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								switch o := obj.(type) {
+								case *v1.Composition:
+									o.SetName("cool-comp")
+									o.Spec.Resources = []v1.ComposedTemplate{{Name: pointer.StringPtr("current")}}
+								case *v1alpha1.CompositionRevision:
+									o.SetLabels(map[string]string{v1alpha1.LabelCompositionName: "cool-comp"})
+									o.Spec.Resources = []v1.ComposedTemplate{{Name: pointer.StringPtr("revised")}}
+								}
+								return nil
+							}),
+						},
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{Name: "cool-comp"})
+						SetCompositionRevisionReference(cr, &corev1.LocalObjectReference{Name: "cool-comp-1"})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithCompositionValidator(CompositionValidatorFn(func(comp *v1.Composition) error { return nil })),
+					WithCompositionTemplateAssociator(CompositionTemplateAssociatorFn(func(_ context.Context, _ resource.Composite, comp *v1.Composition) ([]TemplateAssociation, error) {
+						want := []v1.ComposedTemplate{{Name: pointer.StringPtr("revised"), Patches: []v1.Patch{}}}
+						if diff := cmp.Diff(want, comp.Spec.Resources); diff != "" {
+							t.Errorf("AssociateTemplates(...): -want, +got:\n%s", diff)
+						}
+						return nil, errBoom
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
 		"ConfigureCompositeError": {
 			reason: "We should requeue after a short wait if we encounter an error while configuring the composite resource.",
 			args: args{
@@ -247,6 +501,66 @@ func TestReconcile(t *testing.T) {
 				r: reconcile.Result{RequeueAfter: shortWait},
 			},
 		},
+		"FetchEnvironmentError": {
+			reason: "We should requeue after a short wait if we encounter an error while fetching the environment.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil),
+						},
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithEnvironmentFetcher(EnvironmentFetcherFn(func(ctx context.Context, cr resource.Composite, comp *v1.Composition) (*v1alpha1.EnvironmentConfig, error) {
+						return nil, errBoom
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"RenderFromEnvironmentError": {
+			reason: "We should requeue after a short wait if we encounter an error while rendering a composed resource from the environment.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+								}
+								return nil
+							}),
+						},
+					}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+						return nil
+					})),
+					WithEnvironmentRenderer(EnvironmentRendererFn(func(ctx context.Context, env *v1alpha1.EnvironmentConfig, cd resource.Composed, t v1.ComposedTemplate) error {
+						return errBoom
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
 		"RenderComposedError": {
 			reason: "We should requeue after a short wait if we encounter an error while rendering a composed resource.",
 			args: args{
@@ -278,6 +592,40 @@ func TestReconcile(t *testing.T) {
 				r: reconcile.Result{RequeueAfter: shortWait},
 			},
 		},
+		"AddFinalizerError": {
+			reason: "We should requeue after a short wait if we encounter an error while adding our finalizer.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+								}
+								return nil
+							}),
+						},
+					}),
+					WithCompositeFinalizer(resource.FinalizerFns{AddFinalizerFn: func(_ context.Context, _ resource.Object) error {
+						return errBoom
+					}}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+						return nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
 		"UpdateCompositeError": {
 			reason: "We should requeue after a short wait if we encounter an error while updating our composite resource with references.",
 			args: args{
@@ -294,6 +642,9 @@ func TestReconcile(t *testing.T) {
 							MockUpdate: test.NewMockUpdateFn(errBoom),
 						},
 					}),
+					WithCompositeFinalizer(resource.FinalizerFns{AddFinalizerFn: func(_ context.Context, _ resource.Object) error {
+						return nil
+					}}),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -329,6 +680,177 @@ func TestReconcile(t *testing.T) {
 							return errBoom
 						}),
 					}),
+					WithCompositeFinalizer(resource.FinalizerFns{AddFinalizerFn: func(_ context.Context, _ resource.Object) error {
+						return nil
+					}}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+						return nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"RenderFromComposedError": {
+			reason: "We should requeue after a short wait if we encounter an error while rendering a composed resource from another composed resource.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{{}}
+								}
+								return nil
+							}),
+							MockUpdate: test.NewMockUpdateFn(nil),
+						},
+					}),
+					WithCompositeFinalizer(resource.FinalizerFns{AddFinalizerFn: func(_ context.Context, _ resource.Object) error {
+						return nil
+					}}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+						return nil
+					})),
+					WithComposedRenderer(ComposedRendererFn(func(ctx context.Context, observed map[string]resource.Composed, cd resource.Composed, t v1.ComposedTemplate) error {
+						return errBoom
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"ComposedResourceUnavailable": {
+			reason: "We should not apply, and should report that we're waiting for, a composed resource that patches from a composed resource that is not yet available.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{
+										{Name: pointer.StringPtr("vpc")},
+										{Name: pointer.StringPtr("subnet")},
+									}
+								}
+								return nil
+							}),
+							MockUpdate: test.NewMockUpdateFn(nil),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								cr := obj.(resource.Composite)
+								want := xpv1.Creating().WithMessage(fmt.Sprintf(msgFmtUnready, "subnet"))
+								if diff := cmp.Diff(want, cr.GetCondition(xpv1.TypeReady), test.EquateConditions()); diff != "" {
+									t.Errorf("Status().Update(...): -want, +got:\n%s", diff)
+								}
+								wantStatuses := []ComposedResourceStatus{
+									{TemplateName: "vpc", Ready: true},
+									{TemplateName: "subnet", Message: errBoom.Error()},
+								}
+								if diff := cmp.Diff(wantStatuses, GetComposedResourceStatuses(cr)); diff != "" {
+									t.Errorf("Status().Update(...): -want resource statuses, +got:\n%s", diff)
+								}
+								return nil
+							}),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							if GetCompositionResourceName(r) == "subnet" {
+								t.Errorf("Apply(...): unexpected apply of unavailable composed resource")
+							}
+							return nil
+						}),
+					}),
+					WithCompositeFinalizer(resource.FinalizerFns{AddFinalizerFn: func(_ context.Context, _ resource.Object) error {
+						return nil
+					}}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithCompositionTemplateAssociator(CompositionTemplateAssociatorFn(func(ctx context.Context, cr resource.Composite, comp *v1.Composition) ([]TemplateAssociation, error) {
+						return AssociateByOrder(comp.Spec.Resources, nil), nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+						SetCompositionResourceName(cd, *t.Name)
+						return nil
+					})),
+					WithComposedRenderer(ComposedRendererFn(func(ctx context.Context, observed map[string]resource.Composed, cd resource.Composed, t v1.ComposedTemplate) error {
+						if *t.Name == "subnet" {
+							return unavailableError{errBoom}
+						}
+						return nil
+					})),
+					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
+						return true, nil
+					})),
+					WithConnectionPublisher(ConnectionPublisherFn(func(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) (published bool, err error) {
+						return false, nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"CompositeRenderUnavailable": {
+			reason: "We should publish connection details, and report the composed resource as unready, if the composite resource patches from a composed resource that is not yet available.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{{Name: pointer.StringPtr("db")}}
+								}
+								return nil
+							}),
+							MockUpdate: test.NewMockUpdateFn(nil),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								cr := obj.(resource.Composite)
+								want := xpv1.Creating().WithMessage(fmt.Sprintf(msgFmtUnready, "db"))
+								if diff := cmp.Diff(want, cr.GetCondition(xpv1.TypeReady), test.EquateConditions()); diff != "" {
+									t.Errorf("Status().Update(...): -want, +got:\n%s", diff)
+								}
+								wantStatuses := []ComposedResourceStatus{
+									{TemplateName: "db", Ready: true, Message: errBoom.Error()},
+								}
+								if diff := cmp.Diff(wantStatuses, GetComposedResourceStatuses(cr)); diff != "" {
+									t.Errorf("Status().Update(...): -want resource statuses, +got:\n%s", diff)
+								}
+								return nil
+							}),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							return nil
+						}),
+					}),
+					WithCompositeFinalizer(resource.FinalizerFns{AddFinalizerFn: func(_ context.Context, _ resource.Object) error {
+						return nil
+					}}),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -336,9 +858,102 @@ func TestReconcile(t *testing.T) {
 					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
 						return nil
 					})),
+					WithCompositionTemplateAssociator(CompositionTemplateAssociatorFn(func(ctx context.Context, cr resource.Composite, comp *v1.Composition) ([]TemplateAssociation, error) {
+						return AssociateByOrder(comp.Spec.Resources, nil), nil
+					})),
 					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
 						return nil
 					})),
+					WithCompositeRenderer(CompositeRendererFn(func(ctx context.Context, cp resource.Composite, _ map[string]resource.Composed, cd resource.Composed, t v1.ComposedTemplate) error {
+						return unavailableError{errBoom}
+					})),
+					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
+						return managed.ConnectionDetails{"password": []byte("hunter2")}, nil
+					})),
+					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
+						return true, nil
+					})),
+					WithConnectionPublisher(ConnectionPublisherFn(func(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) (published bool, err error) {
+						if diff := cmp.Diff(managed.ConnectionDetails{"password": []byte("hunter2")}, c); diff != "" {
+							t.Errorf("PublishConnection(...): -want, +got:\n%s", diff)
+						}
+						return false, nil
+					})),
+				},
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"ComposedResourceBlocked": {
+			reason: "We should not apply, and should report that we're waiting for, a composed resource that depends on a composed resource that is not yet ready.",
+			args: args{
+				mgr: &fake.Manager{},
+				opts: []ReconcilerOption{
+					WithClientApplicator(resource.ClientApplicator{
+						Client: &test.MockClient{
+							MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+								if comp, ok := obj.(*v1.Composition); ok {
+									comp.Spec.Resources = []v1.ComposedTemplate{
+										{Name: pointer.StringPtr("subnet"), DependsOn: []string{"vpc"}},
+										{Name: pointer.StringPtr("vpc")},
+									}
+								}
+								return nil
+							}),
+							MockUpdate: test.NewMockUpdateFn(nil),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								cr := obj.(resource.Composite)
+								want := xpv1.Creating().WithMessage(fmt.Sprintf(msgFmtUnready, "subnet, vpc"))
+								if diff := cmp.Diff(want, cr.GetCondition(xpv1.TypeReady), test.EquateConditions()); diff != "" {
+									t.Errorf("Status().Update(...): -want, +got:\n%s", diff)
+								}
+								wantStatuses := []ComposedResourceStatus{
+									{TemplateName: "subnet", Message: fmt.Sprintf(msgFmtBlocked, "vpc"), BlockedOn: []string{"vpc"}},
+									{TemplateName: "vpc", Ready: false},
+								}
+								if diff := cmp.Diff(wantStatuses, GetComposedResourceStatuses(cr)); diff != "" {
+									t.Errorf("Status().Update(...): -want resource statuses, +got:\n%s", diff)
+								}
+								return nil
+							}),
+						},
+						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
+							if GetCompositionResourceName(r) == "subnet" {
+								t.Errorf("Apply(...): unexpected apply of blocked composed resource")
+							}
+							return nil
+						}),
+					}),
+					WithCompositeFinalizer(resource.FinalizerFns{AddFinalizerFn: func(_ context.Context, _ resource.Object) error {
+						return nil
+					}}),
+					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+						cr.SetCompositionReference(&corev1.ObjectReference{})
+						return nil
+					})),
+					WithConfigurator(ConfiguratorFn(func(ctx context.Context, cr resource.Composite, cp *v1.Composition) error {
+						return nil
+					})),
+					WithCompositionTemplateAssociator(CompositionTemplateAssociatorFn(func(ctx context.Context, cr resource.Composite, comp *v1.Composition) ([]TemplateAssociation, error) {
+						return AssociateByOrder(comp.Spec.Resources, nil), nil
+					})),
+					WithRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+						SetCompositionResourceName(cd, *t.Name)
+						return nil
+					})),
+					WithComposedRenderer(ComposedRendererFn(func(ctx context.Context, observed map[string]resource.Composed, cd resource.Composed, t v1.ComposedTemplate) error {
+						return nil
+					})),
+					WithConnectionDetailsFetcher(ConnectionDetailsFetcherFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (managed.ConnectionDetails, error) {
+						return nil, nil
+					})),
+					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
+						return false, nil
+					})),
+					WithConnectionPublisher(ConnectionPublisherFn(func(ctx context.Context, o resource.ConnectionSecretOwner, c managed.ConnectionDetails) (published bool, err error) {
+						return false, nil
+					})),
 				},
 			},
 			want: want{
@@ -364,6 +979,9 @@ func TestReconcile(t *testing.T) {
 							return nil
 						}),
 					}),
+					WithCompositeFinalizer(resource.FinalizerFns{AddFinalizerFn: func(_ context.Context, _ resource.Object) error {
+						return nil
+					}}),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -403,6 +1021,9 @@ func TestReconcile(t *testing.T) {
 							return nil
 						}),
 					}),
+					WithCompositeFinalizer(resource.FinalizerFns{AddFinalizerFn: func(_ context.Context, _ resource.Object) error {
+						return nil
+					}}),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -445,6 +1066,9 @@ func TestReconcile(t *testing.T) {
 							return nil
 						}),
 					}),
+					WithCompositeFinalizer(resource.FinalizerFns{AddFinalizerFn: func(_ context.Context, _ resource.Object) error {
+						return nil
+					}}),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -461,7 +1085,7 @@ func TestReconcile(t *testing.T) {
 					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
 						return false, nil
 					})),
-					WithCompositeRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+					WithCompositeRenderer(CompositeRendererFn(func(ctx context.Context, cp resource.Composite, _ map[string]resource.Composed, cd resource.Composed, t v1.ComposedTemplate) error {
 						return errBoom
 					})),
 				},
@@ -496,6 +1120,9 @@ func TestReconcile(t *testing.T) {
 							return nil
 						}),
 					}),
+					WithCompositeFinalizer(resource.FinalizerFns{AddFinalizerFn: func(_ context.Context, _ resource.Object) error {
+						return nil
+					}}),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -512,7 +1139,7 @@ func TestReconcile(t *testing.T) {
 					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
 						return true, nil
 					})),
-					WithCompositeRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+					WithCompositeRenderer(CompositeRendererFn(func(ctx context.Context, cp resource.Composite, _ map[string]resource.Composed, cd resource.Composed, t v1.ComposedTemplate) error {
 						// use arbitrary annotation to track api-server requests
 						// made after composite render
 						cp.SetAnnotations(map[string]string{"composite-rendered": "true"})
@@ -551,6 +1178,9 @@ func TestReconcile(t *testing.T) {
 							return nil
 						}),
 					}),
+					WithCompositeFinalizer(resource.FinalizerFns{AddFinalizerFn: func(_ context.Context, _ resource.Object) error {
+						return nil
+					}}),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -567,7 +1197,7 @@ func TestReconcile(t *testing.T) {
 					WithReadinessChecker(ReadinessCheckerFn(func(ctx context.Context, cd resource.Composed, t v1.ComposedTemplate) (ready bool, err error) {
 						return true, nil
 					})),
-					WithCompositeRenderer(RendererFn(func(ctx context.Context, cp resource.Composite, cd resource.Composed, t v1.ComposedTemplate) error {
+					WithCompositeRenderer(CompositeRendererFn(func(ctx context.Context, cp resource.Composite, _ map[string]resource.Composed, cd resource.Composed, t v1.ComposedTemplate) error {
 						// use arbitrary annotation to track api-server requests
 						// made after composite render
 						cp.SetAnnotations(map[string]string{"composite-rendered": "true"})
@@ -594,6 +1224,9 @@ func TestReconcile(t *testing.T) {
 							return nil
 						}),
 					}),
+					WithCompositeFinalizer(resource.FinalizerFns{AddFinalizerFn: func(_ context.Context, _ resource.Object) error {
+						return nil
+					}}),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -623,13 +1256,23 @@ func TestReconcile(t *testing.T) {
 								}
 								return nil
 							}),
-							MockUpdate:       test.NewMockUpdateFn(nil),
-							MockStatusUpdate: test.NewMockStatusUpdateFn(nil),
+							MockUpdate: test.NewMockUpdateFn(nil),
+							MockStatusUpdate: test.NewMockStatusUpdateFn(nil, func(obj client.Object) error {
+								cr := obj.(resource.Composite)
+								want := xpv1.Creating().WithMessage(fmt.Sprintf(msgFmtUnready, "0"))
+								if diff := cmp.Diff(want, cr.GetCondition(xpv1.TypeReady), test.EquateConditions()); diff != "" {
+									t.Errorf("Status().Update(...): -want, +got:\n%s", diff)
+								}
+								return nil
+							}),
 						},
 						Applicator: resource.ApplyFn(func(c context.Context, r client.Object, ao ...resource.ApplyOption) error {
 							return nil
 						}),
 					}),
+					WithCompositeFinalizer(resource.FinalizerFns{AddFinalizerFn: func(_ context.Context, _ resource.Object) error {
+						return nil
+					}}),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -676,6 +1319,9 @@ func TestReconcile(t *testing.T) {
 							return nil
 						}),
 					}),
+					WithCompositeFinalizer(resource.FinalizerFns{AddFinalizerFn: func(_ context.Context, _ resource.Object) error {
+						return nil
+					}}),
 					WithCompositionSelector(CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
 						cr.SetCompositionReference(&corev1.ObjectReference{})
 						return nil
@@ -0,0 +1,293 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	kunstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/xcrd"
+)
+
+const (
+	errInlinePatchSets = "cannot inline patch sets"
+
+	errFmtDeriveSchema      = "cannot derive composite resource schema from CompositeResourceDefinition %q"
+	errFmtNoCompositeSchema = "no schema found for composite resource %s %s"
+	errFmtTemplateSchema    = "resource at index %d does not match its schema"
+	errFmtPatchSchema       = "patch at index %d is invalid"
+	errFmtFromFieldPath     = "invalid fromFieldPath %q"
+	errFmtToFieldPath       = "invalid toFieldPath %q"
+	errFmtVariableFieldPath = "invalid fromFieldPath %q of combine variable at index %d"
+	errFmtForEachFieldPath  = "invalid forEach fieldPath %q"
+	errFmtTransformInput    = "%s transform at index %d requires %s input, not %s"
+	errFmtPatchOutput       = "cannot patch %s value into %s field %q"
+)
+
+// A SchemaValidator validates Compositions against the OpenAPI schemas of the
+// composite resources they compose, and of the resources they compose. It
+// rejects patches from or to fields that do not exist, and patches whose
+// transforms produce a value of the wrong type. Patches to or from resources
+// whose schemas are unknown are validated on a best effort basis.
+type SchemaValidator struct {
+	schemas map[schema.GroupVersionKind]*extv1.JSONSchemaProps
+}
+
+// NewSchemaValidator returns a SchemaValidator that validates Compositions
+// against the schemas of the supplied CompositeResourceDefinitions and
+// CustomResourceDefinitions. It may be used both in-cluster, with definitions
+// read from the API server, and offline, with definitions read from files.
+func NewSchemaValidator(xrds []v1.CompositeResourceDefinition, crds []extv1.CustomResourceDefinition) (*SchemaValidator, error) {
+	v := &SchemaValidator{schemas: map[schema.GroupVersionKind]*extv1.JSONSchemaProps{}}
+	for i := range crds {
+		v.add(&crds[i])
+	}
+	for i := range xrds {
+		crd, err := xcrd.ForCompositeResource(&xrds[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, errFmtDeriveSchema, xrds[i].GetName())
+		}
+		v.add(crd)
+	}
+	return v, nil
+}
+
+func (v *SchemaValidator) add(crd *extv1.CustomResourceDefinition) {
+	for _, vr := range crd.Spec.Versions {
+		if vr.Schema == nil || vr.Schema.OpenAPIV3Schema == nil {
+			continue
+		}
+		gvk := schema.GroupVersionKind{Group: crd.Spec.Group, Version: vr.Name, Kind: crd.Spec.Names.Kind}
+		v.schemas[gvk] = vr.Schema.OpenAPIV3Schema
+	}
+}
+
+// Validate the supplied Composition against the schemas known to this
+// SchemaValidator.
+func (v *SchemaValidator) Validate(comp *v1.Composition) error {
+	ref := comp.Spec.CompositeTypeRef
+	xr, ok := v.schemas[schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind)]
+	if !ok {
+		return errors.Errorf(errFmtNoCompositeSchema, ref.APIVersion, ref.Kind)
+	}
+
+	// Inlining mutates the spec, so we validate a copy.
+	cs := comp.Spec.DeepCopy()
+	if err := cs.InlinePatchSets(); err != nil {
+		return errors.Wrap(err, errInlinePatchSets)
+	}
+
+	for i, t := range cs.Resources {
+		if err := v.validateTemplate(xr, t); err != nil {
+			return errors.Wrapf(err, errFmtTemplateSchema, i)
+		}
+	}
+	return nil
+}
+
+func (v *SchemaValidator) validateTemplate(xr *extv1.JSONSchemaProps, t v1.ComposedTemplate) error {
+	base := &kunstructured.Unstructured{}
+	if err := json.Unmarshal(t.Base.Raw, &base.Object); err != nil {
+		return errors.Wrap(err, errUnmarshal)
+	}
+
+	// The schema of a composed resource is nil, and thus unknown, if we don't
+	// know about its kind.
+	cd := v.schemas[base.GroupVersionKind()]
+
+	// The input of a FromElementFieldPath patch is an object containing an
+	// element of the forEach array, its index, and its key.
+	var el *extv1.JSONSchemaProps
+	if t.ForEach != nil {
+		arr, err := xcrd.FieldSchema(xr, t.ForEach.FieldPath)
+		if err != nil {
+			return errors.Wrapf(err, errFmtForEachFieldPath, t.ForEach.FieldPath)
+		}
+		el = elementSchema(arr)
+	}
+
+	for i, p := range t.Patches {
+		var from, to *extv1.JSONSchemaProps
+		switch p.Type {
+		case v1.PatchTypeFromCompositeFieldPath, v1.PatchTypeCombineFromComposite, "":
+			from, to = xr, cd
+		case v1.PatchTypeToCompositeFieldPath, v1.PatchTypeCombineToComposite:
+			from, to = cd, xr
+		case v1.PatchTypeFromElementFieldPath:
+			from, to = el, cd
+		case v1.PatchTypeFromEnvironmentFieldPath, v1.PatchTypeFromComposedFieldPath, v1.PatchTypePatchSet:
+			// We don't know the schema of the environment or of other
+			// composed resources, and patch sets were inlined.
+			from, to = nil, cd
+		}
+		if err := validatePatch(p, from, to); err != nil {
+			return errors.Wrapf(err, errFmtPatchSchema, i)
+		}
+	}
+	return nil
+}
+
+// elementSchema returns the schema of the input of a FromElementFieldPath patch,
+// given the schema of the array its template iterates over.
+func elementSchema(arr *extv1.JSONSchemaProps) *extv1.JSONSchemaProps {
+	if arr == nil || arr.Items == nil || arr.Items.Schema == nil {
+		return nil
+	}
+	return &extv1.JSONSchemaProps{
+		Type: xcrd.TypeObject,
+		Properties: map[string]extv1.JSONSchemaProps{
+			"element": *arr.Items.Schema,
+			"index":   {Type: xcrd.TypeInteger},
+			"key":     {Type: xcrd.TypeString},
+		},
+	}
+}
+
+func validatePatch(p v1.Patch, from, to *extv1.JSONSchemaProps) error { //nolint:gocyclo
+	// This function is a little over our complexity goal due to the need to
+	// handle both single field and combine patches.
+
+	in := ""
+	toPath := p.ToFieldPath
+
+	switch p.Type {
+	case v1.PatchTypeCombineFromComposite, v1.PatchTypeCombineToComposite:
+		if p.Combine == nil {
+			return nil
+		}
+		for i, vr := range p.Combine.Variables {
+			if vr.FromResourceName != nil {
+				// We don't know the schema of other composed resources.
+				continue
+			}
+			if _, err := xcrd.FieldSchema(from, vr.FromFieldPath); err != nil {
+				return errors.Wrapf(err, errFmtVariableFieldPath, vr.FromFieldPath, i)
+			}
+		}
+		if p.Combine.Strategy == v1.CombineStrategyString {
+			in = xcrd.TypeString
+		}
+	default:
+		if p.FromFieldPath == nil {
+			return nil
+		}
+		s, err := xcrd.FieldSchema(from, *p.FromFieldPath)
+		if err != nil {
+			return errors.Wrapf(err, errFmtFromFieldPath, *p.FromFieldPath)
+		}
+		in = schemaType(s)
+		if toPath == nil {
+			toPath = p.FromFieldPath
+		}
+	}
+
+	if toPath == nil {
+		return nil
+	}
+	s, err := xcrd.FieldSchema(to, *toPath)
+	if err != nil {
+		return errors.Wrapf(err, errFmtToFieldPath, *toPath)
+	}
+
+	out := in
+	for i, t := range p.Transforms {
+		if out, err = transformOutputType(t, i, out); err != nil {
+			return err
+		}
+	}
+
+	if !assignable(out, schemaType(s)) {
+		return errors.Errorf(errFmtPatchOutput, out, schemaType(s), *toPath)
+	}
+	return nil
+}
+
+// transformOutputType returns the type of the output of the supplied transform
+// given the type of its input. An empty type is unknown.
+func transformOutputType(t v1.Transform, i int, in string) (string, error) { //nolint:gocyclo
+	// This function is a little over our complexity goal due to the number of
+	// transform types.
+
+	switch t.Type {
+	case v1.TransformTypeMath:
+		if !assignable(in, xcrd.TypeNumber) {
+			return "", errors.Errorf(errFmtTransformInput, t.Type, i, "numeric", in)
+		}
+		// A rounded result is always an integer.
+		if t.Math != nil && t.Math.Round != nil {
+			return xcrd.TypeInteger, nil
+		}
+		return in, nil
+	case v1.TransformTypeMap:
+		if !assignable(in, xcrd.TypeString) {
+			return "", errors.Errorf(errFmtTransformInput, t.Type, i, xcrd.TypeString, in)
+		}
+		// Map values may be any JSON.
+		return "", nil
+	case v1.TransformTypeString:
+		if t.String != nil && t.String.GetType() != v1.StringTransformTypeFormat && !assignable(in, xcrd.TypeString) {
+			return "", errors.Errorf(errFmtTransformInput, t.Type, i, xcrd.TypeString, in)
+		}
+		return xcrd.TypeString, nil
+	case v1.TransformTypeConvert:
+		if in == xcrd.TypeObject || in == xcrd.TypeArray {
+			return "", errors.Errorf(errFmtTransformInput, t.Type, i, "scalar", in)
+		}
+		if t.Convert == nil {
+			return "", nil
+		}
+		switch t.Convert.ToType {
+		case v1.ConvertTransformTypeString:
+			return xcrd.TypeString, nil
+		case v1.ConvertTransformTypeBool:
+			return xcrd.TypeBoolean, nil
+		case v1.ConvertTransformTypeInt, v1.ConvertTransformTypeInt64:
+			return xcrd.TypeInteger, nil
+		case v1.ConvertTransformTypeFloat64:
+			return xcrd.TypeNumber, nil
+		}
+	}
+
+	// Match results may be any JSON.
+	return "", nil
+}
+
+func schemaType(s *extv1.JSONSchemaProps) string {
+	if s == nil {
+		return ""
+	}
+	return s.Type
+}
+
+// assignable returns true if a value of the supplied type may be assigned to
+// a field of the supplied type. Unknown types are always assignable.
+func assignable(value, field string) bool {
+	switch {
+	case value == "" || field == "":
+		return true
+	case value == field:
+		return true
+	case value == xcrd.TypeInteger && field == xcrd.TypeNumber:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,292 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composite
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func TestSchemaValidator(t *testing.T) {
+	xrd := v1.CompositeResourceDefinition{
+		Spec: v1.CompositeResourceDefinitionSpec{
+			Group: "example.org",
+			Names: extv1.CustomResourceDefinitionNames{Kind: "XCool", ListKind: "XCoolList", Plural: "xcools", Singular: "xcool"},
+			Versions: []v1.CompositeResourceDefinitionVersion{{
+				Name:          "v1",
+				Served:        true,
+				Referenceable: true,
+				Schema: &v1.CompositeResourceValidation{OpenAPIV3Schema: runtime.RawExtension{Raw: []byte(`{
+					"type": "object",
+					"properties": {
+						"spec": {
+							"type": "object",
+							"properties": {
+								"size": {"type": "integer"},
+								"ratio": {"type": "number"},
+								"region": {"type": "string"},
+								"buckets": {
+									"type": "array",
+									"items": {
+										"type": "object",
+										"properties": {"name": {"type": "string"}}
+									}
+								}
+							}
+						},
+						"status": {
+							"type": "object",
+							"properties": {"address": {"type": "string"}}
+						}
+					}
+				}`)}},
+			}},
+		},
+	}
+	crd := extv1.CustomResourceDefinition{
+		Spec: extv1.CustomResourceDefinitionSpec{
+			Group: "example.org",
+			Names: extv1.CustomResourceDefinitionNames{Kind: "Composed"},
+			Versions: []extv1.CustomResourceDefinitionVersion{{
+				Name: "v1",
+				Schema: &extv1.CustomResourceValidation{OpenAPIV3Schema: &extv1.JSONSchemaProps{
+					Type: "object",
+					Properties: map[string]extv1.JSONSchemaProps{
+						"spec": {
+							Type: "object",
+							Properties: map[string]extv1.JSONSchemaProps{
+								"size":     {Type: "string"},
+								"capacity": {Type: "number"},
+								"replicas": {Type: "integer"},
+								"region":   {Type: "string"},
+								"name":     {Type: "string"},
+							},
+						},
+						"status": {
+							Type: "object",
+							Properties: map[string]extv1.JSONSchemaProps{
+								"endpoint": {Type: "string"},
+							},
+						},
+					},
+				}},
+			}},
+		},
+	}
+
+	composed := runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Composed"}`)}
+	comp := func(ts ...v1.ComposedTemplate) *v1.Composition {
+		return &v1.Composition{Spec: v1.CompositionSpec{
+			CompositeTypeRef: v1.TypeReference{APIVersion: "example.org/v1", Kind: "XCool"},
+			Resources:        ts,
+		}}
+	}
+	patch := func(t v1.PatchType, from, to string, tr ...v1.Transform) v1.Patch {
+		return v1.Patch{Type: t, FromFieldPath: pointer.StringPtr(from), ToFieldPath: pointer.StringPtr(to), Transforms: tr}
+	}
+
+	round := v1.MathRoundingModeRound
+
+	cases := map[string]struct {
+		reason string
+		comp   *v1.Composition
+		want   error
+	}{
+		"NoCompositeSchema": {
+			reason: "We should return an error if we don't know the schema of the composite resource.",
+			comp: &v1.Composition{Spec: v1.CompositionSpec{
+				CompositeTypeRef: v1.TypeReference{APIVersion: "example.org/v2", Kind: "XCool"},
+			}},
+			want: errors.Errorf(errFmtNoCompositeSchema, "example.org/v2", "XCool"),
+		},
+		"Valid": {
+			reason: "We should not return an error if all patches match their schemas.",
+			comp: comp(v1.ComposedTemplate{
+				Base: composed,
+				Patches: []v1.Patch{
+					patch(v1.PatchTypeFromCompositeFieldPath, "spec.region", "spec.region"),
+					patch(v1.PatchTypeFromCompositeFieldPath, "spec.size", "spec.capacity"),
+					patch(v1.PatchTypeFromCompositeFieldPath, "metadata.labels[cool]", "metadata.labels[cool]"),
+					patch(v1.PatchTypeToCompositeFieldPath, "status.endpoint", "status.address"),
+				},
+			}),
+		},
+		"UnknownComposedSchema": {
+			reason: "We should not validate fields of composed resources whose schemas we don't know.",
+			comp: comp(v1.ComposedTemplate{
+				Base:    runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Unknown"}`)},
+				Patches: []v1.Patch{patch(v1.PatchTypeFromCompositeFieldPath, "spec.size", "spec.whatever")},
+			}),
+		},
+		"InvalidFromFieldPath": {
+			reason: "We should return an error if a fromFieldPath does not exist in the composite resource's schema.",
+			comp: comp(v1.ComposedTemplate{
+				Base:    composed,
+				Patches: []v1.Patch{patch(v1.PatchTypeFromCompositeFieldPath, "spec.nope", "spec.region")},
+			}),
+			want: errors.Wrapf(errors.Wrapf(errors.Wrapf(errors.Errorf("%s: no such field", "spec.nope"), errFmtFromFieldPath, "spec.nope"), errFmtPatchSchema, 0), errFmtTemplateSchema, 0),
+		},
+		"InvalidToFieldPath": {
+			reason: "We should return an error if a toFieldPath does not exist in the composed resource's schema.",
+			comp: comp(v1.ComposedTemplate{
+				Base:    composed,
+				Patches: []v1.Patch{patch(v1.PatchTypeFromCompositeFieldPath, "spec.region", "spec.nope")},
+			}),
+			want: errors.Wrapf(errors.Wrapf(errors.Wrapf(errors.Errorf("%s: no such field", "spec.nope"), errFmtToFieldPath, "spec.nope"), errFmtPatchSchema, 0), errFmtTemplateSchema, 0),
+		},
+		"DefaultToFieldPath": {
+			reason: "A patch's toFieldPath should default to its fromFieldPath.",
+			comp: comp(v1.ComposedTemplate{
+				Base: composed,
+				Patches: []v1.Patch{{
+					Type:          v1.PatchTypeFromCompositeFieldPath,
+					FromFieldPath: pointer.StringPtr("spec.buckets"),
+				}},
+			}),
+			want: errors.Wrapf(errors.Wrapf(errors.Wrapf(errors.Errorf("%s: no such field", "spec.buckets"), errFmtToFieldPath, "spec.buckets"), errFmtPatchSchema, 0), errFmtTemplateSchema, 0),
+		},
+		"TypeMismatch": {
+			reason: "We should return an error if a patch would write a value of the wrong type.",
+			comp: comp(v1.ComposedTemplate{
+				Base:    composed,
+				Patches: []v1.Patch{patch(v1.PatchTypeFromCompositeFieldPath, "spec.size", "spec.size")},
+			}),
+			want: errors.Wrapf(errors.Wrapf(errors.Errorf(errFmtPatchOutput, "integer", "string", "spec.size"), errFmtPatchSchema, 0), errFmtTemplateSchema, 0),
+		},
+		"TransformFixesType": {
+			reason: "We should consider the output type of a patch's transforms.",
+			comp: comp(v1.ComposedTemplate{
+				Base: composed,
+				Patches: []v1.Patch{
+					patch(v1.PatchTypeFromCompositeFieldPath, "spec.size", "spec.size",
+						v1.Transform{Type: v1.TransformTypeMath, Math: &v1.MathTransform{Multiply: pointer.Int64Ptr(2)}},
+						v1.Transform{Type: v1.TransformTypeConvert, Convert: &v1.ConvertTransform{ToType: v1.ConvertTransformTypeString}},
+					),
+					patch(v1.PatchTypeFromCompositeFieldPath, "spec.size", "spec.name",
+						v1.Transform{Type: v1.TransformTypeString, String: &v1.StringTransform{Format: "%d-cool"}},
+					),
+				},
+			}),
+		},
+		"RoundedMathTransform": {
+			reason: "A math transform that rounds its result should output an integer.",
+			comp: comp(v1.ComposedTemplate{
+				Base: composed,
+				Patches: []v1.Patch{patch(v1.PatchTypeFromCompositeFieldPath, "spec.ratio", "spec.replicas",
+					v1.Transform{Type: v1.TransformTypeMath, Math: &v1.MathTransform{Multiply: pointer.Int64Ptr(2), Round: &round}},
+				)},
+			}),
+		},
+		"UnroundedMathTransform": {
+			reason: "A math transform that does not round its result should output the type of its input.",
+			comp: comp(v1.ComposedTemplate{
+				Base: composed,
+				Patches: []v1.Patch{patch(v1.PatchTypeFromCompositeFieldPath, "spec.ratio", "spec.replicas",
+					v1.Transform{Type: v1.TransformTypeMath, Math: &v1.MathTransform{Multiply: pointer.Int64Ptr(2)}},
+				)},
+			}),
+			want: errors.Wrapf(errors.Wrapf(errors.Errorf(errFmtPatchOutput, "number", "integer", "spec.replicas"), errFmtPatchSchema, 0), errFmtTemplateSchema, 0),
+		},
+		"TransformInputMismatch": {
+			reason: "We should return an error if a transform does not accept the type of its input.",
+			comp: comp(v1.ComposedTemplate{
+				Base: composed,
+				Patches: []v1.Patch{patch(v1.PatchTypeFromCompositeFieldPath, "spec.region", "spec.capacity",
+					v1.Transform{Type: v1.TransformTypeMath, Math: &v1.MathTransform{Multiply: pointer.Int64Ptr(2)}},
+				)},
+			}),
+			want: errors.Wrapf(errors.Wrapf(errors.Errorf(errFmtTransformInput, v1.TransformTypeMath, 0, "numeric", "string"), errFmtPatchSchema, 0), errFmtTemplateSchema, 0),
+		},
+		"CombineVariable": {
+			reason: "We should return an error if a combine variable does not exist in the composite resource's schema.",
+			comp: comp(v1.ComposedTemplate{
+				Base: composed,
+				Patches: []v1.Patch{{
+					Type:        v1.PatchTypeCombineFromComposite,
+					ToFieldPath: pointer.StringPtr("spec.name"),
+					Combine: &v1.Combine{
+						Strategy:  v1.CombineStrategyString,
+						Variables: []v1.CombineVariable{{FromFieldPath: "spec.region"}, {FromFieldPath: "spec.nope"}},
+						String:    &v1.StringCombine{Format: "%s-%s"},
+					},
+				}},
+			}),
+			want: errors.Wrapf(errors.Wrapf(errors.Wrapf(errors.Errorf("%s: no such field", "spec.nope"), errFmtVariableFieldPath, "spec.nope", 1), errFmtPatchSchema, 0), errFmtTemplateSchema, 0),
+		},
+		"CombineOutput": {
+			reason: "We should return an error if a combine patch writes its string output to a field of another type.",
+			comp: comp(v1.ComposedTemplate{
+				Base: composed,
+				Patches: []v1.Patch{{
+					Type:        v1.PatchTypeCombineFromComposite,
+					ToFieldPath: pointer.StringPtr("spec.capacity"),
+					Combine: &v1.Combine{
+						Strategy:  v1.CombineStrategyString,
+						Variables: []v1.CombineVariable{{FromFieldPath: "spec.region"}},
+						String:    &v1.StringCombine{Format: "%s"},
+					},
+				}},
+			}),
+			want: errors.Wrapf(errors.Wrapf(errors.Errorf(errFmtPatchOutput, "string", "number", "spec.capacity"), errFmtPatchSchema, 0), errFmtTemplateSchema, 0),
+		},
+		"ElementPatch": {
+			reason: "We should validate FromElementFieldPath patches against the schema of the forEach array's elements.",
+			comp: comp(v1.ComposedTemplate{
+				Name:    pointer.StringPtr("bucket"),
+				ForEach: &v1.ForEach{FieldPath: "spec.buckets"},
+				Base:    composed,
+				Patches: []v1.Patch{
+					patch(v1.PatchTypeFromElementFieldPath, "element.name", "spec.name"),
+					patch(v1.PatchTypeFromElementFieldPath, "element.nope", "spec.name"),
+				},
+			}),
+			want: errors.Wrapf(errors.Wrapf(errors.Wrapf(errors.Errorf("%s: no such field", "element.nope"), errFmtFromFieldPath, "element.nope"), errFmtPatchSchema, 1), errFmtTemplateSchema, 0),
+		},
+		"InvalidForEach": {
+			reason: "We should return an error if a forEach fieldPath does not exist in the composite resource's schema.",
+			comp: comp(v1.ComposedTemplate{
+				Name:    pointer.StringPtr("bucket"),
+				ForEach: &v1.ForEach{FieldPath: "spec.nope"},
+				Base:    composed,
+			}),
+			want: errors.Wrapf(errors.Wrapf(errors.Errorf("%s: no such field", "spec.nope"), errFmtForEachFieldPath, "spec.nope"), errFmtTemplateSchema, 0),
+		},
+	}
+
+	v, err := NewSchemaValidator([]v1.CompositeResourceDefinition{xrd}, []extv1.CustomResourceDefinition{crd})
+	if err != nil {
+		t.Fatalf("NewSchemaValidator(...): %s", err)
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := v.Validate(tc.comp)
+			if diff := cmp.Diff(tc.want, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nValidate(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
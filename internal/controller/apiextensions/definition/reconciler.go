@@ -42,6 +42,7 @@ import (
 	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured"
 
 	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
 	"github.com/crossplane/crossplane/internal/controller/apiextensions/composite"
 	"github.com/crossplane/crossplane/internal/xcrd"
 )
@@ -374,13 +375,19 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	}
 
 	recorder := r.record.WithAnnotations("controller", composite.ControllerName(d.GetName()))
-	o := kcontroller.Options{Reconciler: composite.NewReconciler(r.mgr,
-		resource.CompositeKind(d.GetCompositeGroupVersionKind()),
-		composite.WithConnectionPublisher(composite.NewAPIFilteredSecretPublisher(r.client, d.GetConnectionSecretKeys())),
+	ck := resource.CompositeKind(d.GetCompositeGroupVersionKind())
+	pub := composite.ConnectionPublisherChain{
+		composite.NewAPIFilteredSecretPublisher(r.client, d.GetConnectionSecretKeys()),
+		composite.NewAPIStorePublisher(r.client, d.GetConnectionSecretKeys()),
+	}
+	o := kcontroller.Options{Reconciler: composite.NewReconciler(r.mgr, ck,
+		composite.WithConnectionPublisher(pub),
+		composite.WithConnectionUnpublisher(pub),
 		composite.WithCompositionSelector(composite.NewCompositionSelectorChain(
 			composite.NewEnforcedCompositionSelector(*d, recorder),
 			composite.NewAPIDefaultCompositionSelector(r.client, *meta.ReferenceTo(d, v1.CompositeResourceDefinitionGroupVersionKind), recorder),
 			composite.NewAPILabelSelectorResolver(r.client),
+			composite.NewAPIRevisionSelector(r.client),
 		)),
 		composite.WithLogger(log.WithValues("controller", composite.ControllerName(d.GetName()))),
 		composite.WithRecorder(recorder),
@@ -389,7 +396,10 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	u := &kunstructured.Unstructured{}
 	u.SetGroupVersionKind(d.GetCompositeGroupVersionKind())
 
-	if err := r.composite.Start(composite.ControllerName(d.GetName()), o, controller.For(u, &handler.EnqueueRequestForObject{})); err != nil {
+	if err := r.composite.Start(composite.ControllerName(d.GetName()), o,
+		controller.For(u, &handler.EnqueueRequestForObject{}),
+		controller.For(&v1alpha1.EnvironmentConfig{}, composite.EnqueueForEnvironmentConfig(r.client, ck, log)),
+	); err != nil {
 		log.Debug(errStartController, "error", err)
 		r.record.Event(d, event.Warning(reasonEstablishXR, errors.Wrap(err, errStartController)))
 		return reconcile.Result{RequeueAfter: shortWait}, nil
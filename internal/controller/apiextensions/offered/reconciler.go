@@ -366,6 +366,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		resource.CompositeKind(d.GetCompositeGroupVersionKind()),
 		claim.WithLogger(log.WithValues("controller", claim.ControllerName(d.GetName()))),
 		claim.WithRecorder(r.record.WithAnnotations("controller", claim.ControllerName(d.GetName()))),
+		claim.WithConnectionPropagator(claim.NewAPIFilteredConnectionPropagator(r.client, r.mgr.GetScheme(), d.GetConnectionSecretKeys())),
 	), MaxConcurrentReconciles: maxConcurrency}
 
 	if err := r.claim.Err(claim.ControllerName(d.GetName())); err != nil {
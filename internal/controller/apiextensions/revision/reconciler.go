@@ -0,0 +1,240 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package revision implements the Crossplane CompositionRevision controller.
+package revision
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	kcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+	"github.com/crossplane/crossplane-runtime/pkg/meta"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
+)
+
+const (
+	shortWait = 30 * time.Second
+
+	timeout        = 2 * time.Minute
+	maxConcurrency = 5
+
+	// Kubernetes label values may be at most 63 characters long.
+	maxLabelValueLength = 63
+
+	errGet       = "cannot get Composition"
+	errHash      = "cannot hash Composition spec"
+	errListRevs  = "cannot list CompositionRevisions"
+	errCreateRev = "cannot create CompositionRevision"
+	errUpdateRev = "cannot update CompositionRevision"
+)
+
+// Event reasons.
+const (
+	reasonCreateRev event.Reason = "CreateRevision"
+	reasonUpdateRev event.Reason = "UpdateRevision"
+)
+
+// Setup adds a controller that reconciles Compositions by creating a new
+// CompositionRevision each time their spec changes.
+func Setup(mgr ctrl.Manager, log logging.Logger) error {
+	name := "revisions/" + strings.ToLower(v1.CompositionGroupKind)
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name).
+		For(&v1.Composition{}).
+		Owns(&v1alpha1.CompositionRevision{}).
+		WithOptions(kcontroller.Options{MaxConcurrentReconciles: maxConcurrency}).
+		Complete(NewReconciler(mgr,
+			WithLogger(log.WithValues("controller", name)),
+			WithRecorder(event.NewAPIRecorder(mgr.GetEventRecorderFor(name)))))
+}
+
+// ReconcilerOption is used to configure the Reconciler.
+type ReconcilerOption func(*Reconciler)
+
+// WithLogger specifies how the Reconciler should log messages.
+func WithLogger(log logging.Logger) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.log = log
+	}
+}
+
+// WithRecorder specifies how the Reconciler should record Kubernetes events.
+func WithRecorder(er event.Recorder) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.record = er
+	}
+}
+
+// WithClient specifies how the Reconciler should interact with the Kubernetes
+// API.
+func WithClient(c client.Client) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.client = c
+	}
+}
+
+// NewReconciler returns a Reconciler of Compositions.
+func NewReconciler(mgr manager.Manager, opts ...ReconcilerOption) *Reconciler {
+	r := &Reconciler{
+		client: mgr.GetClient(),
+		log:    logging.NewNopLogger(),
+		record: event.NewNopRecorder(),
+	}
+
+	for _, f := range opts {
+		f(r)
+	}
+	return r
+}
+
+// A Reconciler reconciles Compositions.
+type Reconciler struct {
+	client client.Client
+
+	log    logging.Logger
+	record event.Recorder
+}
+
+// Reconcile a Composition by ensuring its latest CompositionRevision matches
+// its current spec.
+func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	log := r.log.WithValues("request", req)
+	log.Debug("Reconciling")
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	comp := &v1.Composition{}
+	if err := r.client.Get(ctx, req.NamespacedName, comp); err != nil {
+		// In case object is not found, most likely the object was deleted and
+		// then disappeared while the event was in the processing queue. We
+		// don't need to take any action in that case.
+		log.Debug(errGet, "error", err)
+		return reconcile.Result{}, errors.Wrap(resource.IgnoreNotFound(err), errGet)
+	}
+
+	log = log.WithValues(
+		"uid", comp.GetUID(),
+		"version", comp.GetResourceVersion(),
+		"name", comp.GetName(),
+	)
+
+	// Our revisions will be garbage collected along with their Composition.
+	if meta.WasDeleted(comp) {
+		return reconcile.Result{}, nil
+	}
+
+	h, err := Hash(comp.Spec)
+	if err != nil {
+		log.Debug(errHash, "error", err)
+		return reconcile.Result{}, errors.Wrap(err, errHash)
+	}
+
+	rl := &v1alpha1.CompositionRevisionList{}
+	if err := r.client.List(ctx, rl, client.MatchingLabels{v1alpha1.LabelCompositionName: comp.GetName()}); err != nil {
+		log.Debug(errListRevs, "error", err)
+		r.record.Event(comp, event.Warning(reasonCreateRev, errors.Wrap(err, errListRevs)))
+		return reconcile.Result{RequeueAfter: shortWait}, nil
+	}
+
+	var latest int64
+	var current *v1alpha1.CompositionRevision
+	for i := range rl.Items {
+		rev := &rl.Items[i]
+		if rev.Spec.Revision > latest {
+			latest = rev.Spec.Revision
+		}
+		if rev.GetLabels()[v1alpha1.LabelCompositionSpecHash] == h {
+			current = rev
+		}
+	}
+
+	if current == nil {
+		rev := NewCompositionRevision(comp, latest+1, h)
+		if err := r.client.Create(ctx, rev); err != nil {
+			log.Debug(errCreateRev, "error", err)
+			r.record.Event(comp, event.Warning(reasonCreateRev, errors.Wrap(err, errCreateRev)))
+			return reconcile.Result{RequeueAfter: shortWait}, nil
+		}
+		log.Debug("Created CompositionRevision", "revision", rev.Spec.Revision)
+		r.record.Event(comp, event.Normal(reasonCreateRev, fmt.Sprintf("Created CompositionRevision %q", rev.GetName())))
+		return reconcile.Result{}, nil
+	}
+
+	if current.Spec.Revision == latest {
+		return reconcile.Result{}, nil
+	}
+
+	// The Composition's spec was reverted to that of an earlier revision. We
+	// make that revision the latest again, rather than creating a duplicate.
+	current.Spec.Revision = latest + 1
+	if err := r.client.Update(ctx, current); err != nil {
+		log.Debug(errUpdateRev, "error", err)
+		r.record.Event(comp, event.Warning(reasonUpdateRev, errors.Wrap(err, errUpdateRev)))
+		return reconcile.Result{RequeueAfter: shortWait}, nil
+	}
+	log.Debug("Updated CompositionRevision", "revision", current.Spec.Revision)
+	r.record.Event(comp, event.Normal(reasonUpdateRev, fmt.Sprintf("Made CompositionRevision %q the latest revision", current.GetName())))
+	return reconcile.Result{}, nil
+}
+
+// Hash returns a hash of the supplied Composition spec that is suitable for use
+// as a label value.
+func Hash(cs v1.CompositionSpec) (string, error) {
+	j, err := json.Marshal(cs)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(j))[:maxLabelValueLength], nil
+}
+
+// NewCompositionRevision returns a revision of the supplied Composition with
+// the supplied revision number and spec hash.
+func NewCompositionRevision(c *v1.Composition, revision int64, hash string) *v1alpha1.CompositionRevision {
+	rev := &v1alpha1.CompositionRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: fmt.Sprintf("%s-%s", c.GetName(), hash[0:7]),
+			Labels: map[string]string{
+				v1alpha1.LabelCompositionName:     c.GetName(),
+				v1alpha1.LabelCompositionSpecHash: hash,
+			},
+		},
+		Spec: v1alpha1.CompositionRevisionSpec{
+			CompositionSpec: *c.Spec.DeepCopy(),
+			Revision:        revision,
+		},
+	}
+	meta.AddOwnerReference(rev, meta.AsController(meta.TypedReferenceTo(c, v1.CompositionGroupVersionKind)))
+	return rev
+}
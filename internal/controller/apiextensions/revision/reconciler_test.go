@@ -0,0 +1,204 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package revision
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/apis/apiextensions/v1alpha1"
+)
+
+func TestReconcile(t *testing.T) {
+	errBoom := errors.New("boom")
+	now := metav1.Now()
+
+	comp := &v1.Composition{
+		ObjectMeta: metav1.ObjectMeta{Name: "cool-comp"},
+		Spec: v1.CompositionSpec{
+			CompositeTypeRef: v1.TypeReference{APIVersion: "example.org/v1", Kind: "XCool"},
+		},
+	}
+	h, _ := Hash(comp.Spec)
+
+	getComp := func(_ context.Context, _ client.ObjectKey, obj client.Object) error {
+		comp.DeepCopyInto(obj.(*v1.Composition))
+		return nil
+	}
+	rev := func(name, hash string, revision int64) v1alpha1.CompositionRevision {
+		return v1alpha1.CompositionRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					v1alpha1.LabelCompositionName:     comp.GetName(),
+					v1alpha1.LabelCompositionSpecHash: hash,
+				},
+			},
+			Spec: v1alpha1.CompositionRevisionSpec{Revision: revision},
+		}
+	}
+	listRevs := func(revs ...v1alpha1.CompositionRevision) test.MockListFn {
+		return func(_ context.Context, obj client.ObjectList, _ ...client.ListOption) error {
+			obj.(*v1alpha1.CompositionRevisionList).Items = revs
+			return nil
+		}
+	}
+
+	type want struct {
+		r   reconcile.Result
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		kube   client.Client
+		want   want
+	}{
+		"CompositionNotFound": {
+			reason: "We should not return an error if the Composition was not found.",
+			kube: &test.MockClient{
+				MockGet: test.NewMockGetFn(kerrors.NewNotFound(schema.GroupResource{}, "")),
+			},
+			want: want{
+				r: reconcile.Result{},
+			},
+		},
+		"GetCompositionError": {
+			reason: "We should return any other error encountered while getting a Composition.",
+			kube: &test.MockClient{
+				MockGet: test.NewMockGetFn(errBoom),
+			},
+			want: want{
+				err: errors.Wrap(errBoom, errGet),
+			},
+		},
+		"CompositionDeleted": {
+			reason: "We should return early if the Composition was deleted.",
+			kube: &test.MockClient{
+				MockGet: test.NewMockGetFn(nil, func(obj client.Object) error {
+					obj.SetDeletionTimestamp(&now)
+					return nil
+				}),
+			},
+			want: want{
+				r: reconcile.Result{},
+			},
+		},
+		"ListRevisionsError": {
+			reason: "We should requeue after a short wait if we encounter an error listing revisions.",
+			kube: &test.MockClient{
+				MockGet:  getComp,
+				MockList: test.NewMockListFn(errBoom),
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"CreateRevisionError": {
+			reason: "We should requeue after a short wait if we encounter an error creating a revision.",
+			kube: &test.MockClient{
+				MockGet:    getComp,
+				MockList:   listRevs(),
+				MockCreate: test.NewMockCreateFn(errBoom),
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"CreateRevision": {
+			reason: "We should create a new revision if no existing revision matches the Composition's spec.",
+			kube: &test.MockClient{
+				MockGet:  getComp,
+				MockList: listRevs(rev("cool-comp-old", "old", 1)),
+				MockCreate: test.NewMockCreateFn(nil, func(obj client.Object) error {
+					want := NewCompositionRevision(comp, 2, h)
+					if diff := cmp.Diff(want, obj); diff != "" {
+						t.Errorf("Create(...): -want, +got:\n%s", diff)
+					}
+					return nil
+				}),
+			},
+			want: want{
+				r: reconcile.Result{},
+			},
+		},
+		"RevisionUpToDate": {
+			reason: "We should not create or update a revision if the latest revision matches the Composition's spec.",
+			kube: &test.MockClient{
+				MockGet:  getComp,
+				MockList: listRevs(rev("cool-comp-old", "old", 1), rev("cool-comp-new", h, 2)),
+			},
+			want: want{
+				r: reconcile.Result{},
+			},
+		},
+		"UpdateRevisionError": {
+			reason: "We should requeue after a short wait if we encounter an error updating a revision.",
+			kube: &test.MockClient{
+				MockGet:    getComp,
+				MockList:   listRevs(rev("cool-comp-old", h, 1), rev("cool-comp-new", "new", 2)),
+				MockUpdate: test.NewMockUpdateFn(errBoom),
+			},
+			want: want{
+				r: reconcile.Result{RequeueAfter: shortWait},
+			},
+		},
+		"UpdateRevision": {
+			reason: "We should make an earlier revision the latest if the Composition's spec was reverted to match it.",
+			kube: &test.MockClient{
+				MockGet:  getComp,
+				MockList: listRevs(rev("cool-comp-old", h, 1), rev("cool-comp-new", "new", 2)),
+				MockUpdate: test.NewMockUpdateFn(nil, func(obj client.Object) error {
+					want := rev("cool-comp-old", h, 3)
+					if diff := cmp.Diff(&want, obj); diff != "" {
+						t.Errorf("Update(...): -want, +got:\n%s", diff)
+					}
+					return nil
+				}),
+			},
+			want: want{
+				r: reconcile.Result{},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := NewReconciler(&fake.Manager{}, WithClient(tc.kube))
+			got, err := r.Reconcile(context.Background(), reconcile.Request{})
+
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nr.Reconcile(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.r, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nr.Reconcile(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
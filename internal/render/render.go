@@ -0,0 +1,279 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package render renders composite resources without an API server.
+package render
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kunstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	kfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	xpv1 "github.com/crossplane/crossplane-runtime/apis/common/v1"
+	"github.com/crossplane/crossplane-runtime/pkg/event"
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	"github.com/crossplane/crossplane-runtime/pkg/resource"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/fake"
+	"github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composed"
+	ucomposite "github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+
+	"github.com/crossplane/crossplane/apis"
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/controller/apiextensions/composite"
+)
+
+// The maximum number of times a composite resource will be reconciled while
+// rendering it. The composite reconciler returns early each time it updates
+// the composite resource's spec or metadata.
+const maxReconciles = 10
+
+const (
+	errNoName         = "composite resource must have a name"
+	errScheme         = "cannot build scheme"
+	errAddObserved    = "cannot add observed resource"
+	errReconcile      = "cannot reconcile composite resource"
+	errGetComposite   = "cannot get rendered composite resource"
+	errGetComposed    = "cannot get rendered composed resource"
+	errFmtNotRendered = "composite resource was not rendered after %d reconciles"
+)
+
+// Output is the result of rendering a composite resource.
+type Output struct {
+	// Composite is the rendered composite resource.
+	Composite *ucomposite.Unstructured
+
+	// Composed are the rendered composed resources, in the order they are
+	// referenced by the composite resource. Resources that would not yet be
+	// applied, for example because they depend on a resource that is not yet
+	// ready, are omitted.
+	Composed []*composed.Unstructured
+
+	// ConnectionDetails the composite resource would publish, before they are
+	// filtered by its CompositeResourceDefinition's connectionSecretKeys.
+	ConnectionDetails managed.ConnectionDetails
+}
+
+// Render the supplied composite resource using the supplied Composition. The
+// same reconciler that composes resources in-cluster is used, but it reads and
+// writes an in-memory client rather than an API server. Any supplied observed
+// resources are added to the in-memory client before rendering. Composed
+// resources referenced by the composite resource are updated from the rendered
+// templates, and their status is used to determine readiness and to patch the
+// composite resource. Observed resources may also include connection Secrets
+// and EnvironmentConfigs.
+func Render(ctx context.Context, xr *ucomposite.Unstructured, comp *v1.Composition, observed ...client.Object) (*Output, error) { //nolint:gocyclo
+	// This function is a little over our complexity goal due to the error
+	// checking required at each step.
+
+	if xr.GetName() == "" {
+		return nil, errors.New(errNoName)
+	}
+
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		return nil, errors.Wrap(err, errScheme)
+	}
+	if err := apis.AddToScheme(s); err != nil {
+		return nil, errors.Wrap(err, errScheme)
+	}
+
+	kube := &renderClient{Client: kfake.NewClientBuilder().WithScheme(s).Build()}
+
+	xr = &ucomposite.Unstructured{Unstructured: *xr.GetUnstructured().DeepCopy()}
+	comp = comp.DeepCopy()
+	if comp.GetName() == "" {
+		comp.SetName("render")
+	}
+	// We always render using the supplied Composition, not a revision.
+	kunstructured.RemoveNestedField(xr.Object, "spec", "compositionRevisionRef")
+	SetDefaults(comp)
+
+	for _, o := range append([]client.Object{xr, comp}, observed...) {
+		o.SetResourceVersion("")
+		if err := kube.Create(ctx, o); err != nil {
+			return nil, errors.Wrap(err, errAddObserved)
+		}
+	}
+
+	rec := &warningRecorder{}
+	out := &Output{Composite: ucomposite.New(ucomposite.WithGroupVersionKind(xr.GroupVersionKind()))}
+	r := composite.NewReconciler(&fake.Manager{Client: kube, Scheme: s}, resource.CompositeKind(xr.GroupVersionKind()),
+		composite.WithRecorder(rec),
+		// The keys a composite resource publishes are filtered by its
+		// CompositeResourceDefinition, which we don't know about. We instead
+		// capture all of the connection details it would publish.
+		composite.WithConnectionPublisher(composite.ConnectionPublisherFn(func(_ context.Context, _ resource.ConnectionSecretOwner, c managed.ConnectionDetails) (bool, error) {
+			out.ConnectionDetails = c
+			return false, nil
+		})),
+		composite.WithCompositionSelector(composite.CompositionSelectorFn(func(_ context.Context, cr resource.Composite) error {
+			cr.SetCompositionReference(&corev1.ObjectReference{Name: comp.GetName()})
+			return nil
+		})),
+	)
+
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: xr.GetNamespace(), Name: xr.GetName()}}
+	rendered := false
+	for i := 0; i < maxReconciles && !rendered; i++ {
+		res, err := r.Reconcile(ctx, req)
+		if err != nil {
+			return nil, errors.Wrap(err, errReconcile)
+		}
+		if rec.err != nil {
+			return nil, errors.Wrap(rec.err, errReconcile)
+		}
+		// The reconciler returns early, without requeueing, each time it
+		// updates the composite resource's spec or metadata.
+		rendered = res.RequeueAfter > 0
+	}
+	if !rendered {
+		return nil, errors.Errorf(errFmtNotRendered, maxReconciles)
+	}
+
+	if err := kube.Get(ctx, req.NamespacedName, out.Composite); err != nil {
+		return nil, errors.Wrap(err, errGetComposite)
+	}
+	out.Composite.SetResourceVersion("")
+
+	for _, ref := range out.Composite.GetResourceReferences() {
+		cd := composed.New(composed.FromReference(ref))
+		err := kube.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, cd)
+		if resource.IgnoreNotFound(err) != nil {
+			return nil, errors.Wrap(err, errGetComposed)
+		}
+		if err != nil {
+			continue
+		}
+		cd.SetResourceVersion("")
+		out.Composed = append(out.Composed, cd)
+	}
+
+	return out, nil
+}
+
+// SetDefaults sets the defaults the API server would set when the supplied
+// Composition was created.
+func SetDefaults(comp *v1.Composition) {
+	for i := range comp.Spec.PatchSets {
+		defaultPatches(comp.Spec.PatchSets[i].Patches)
+	}
+	for i := range comp.Spec.Resources {
+		t := &comp.Spec.Resources[i]
+		defaultPatches(t.Patches)
+		for j := range t.ReadinessChecks {
+			mc := t.ReadinessChecks[j].MatchCondition
+			if mc == nil {
+				continue
+			}
+			if mc.Type == "" {
+				mc.Type = xpv1.TypeReady
+			}
+			if mc.Status == "" {
+				mc.Status = corev1.ConditionTrue
+			}
+		}
+	}
+}
+
+func defaultPatches(ps []v1.Patch) {
+	for i := range ps {
+		if ps[i].Type == "" {
+			ps[i].Type = v1.PatchTypeFromCompositeFieldPath
+		}
+	}
+}
+
+// A renderClient behaves more like an API server than the in-memory client it
+// wraps. It names resources that are dry-run created, the way an API server
+// would if they specified a generate name. Names are derived deterministically
+// so that rendering is repeatable. It also doesn't increment the resource
+// version of resources that are updated without changes, so that the composite
+// reconciler can tell when rendering is complete. All other calls are passed
+// through to the wrapped client.
+type renderClient struct {
+	client.Client
+
+	created int
+}
+
+func (c *renderClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	co := &client.CreateOptions{}
+	co.ApplyOptions(opts)
+	for _, dr := range co.DryRun {
+		if dr != metav1.DryRunAll {
+			continue
+		}
+		if obj.GetName() == "" && obj.GetGenerateName() != "" {
+			c.created++
+			h := sha256.Sum256([]byte(obj.GetGenerateName() + obj.GetObjectKind().GroupVersionKind().Kind + composite.GetCompositionResourceName(obj) + strconv.Itoa(c.created)))
+			obj.SetName(obj.GetGenerateName() + fmt.Sprintf("%x", h)[:5])
+		}
+		return nil
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *renderClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	want, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+	current := &kunstructured.Unstructured{}
+	current.SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+	if err := c.Client.Get(ctx, types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}, current); err != nil {
+		return c.Client.Update(ctx, obj, opts...)
+	}
+	if equality.Semantic.DeepEqual(withoutStatus(current.Object), withoutStatus(want)) {
+		return nil
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+// withoutStatus returns a copy of the supplied object without its status,
+// which is not changed by an update.
+func withoutStatus(o map[string]interface{}) map[string]interface{} {
+	o = runtime.DeepCopyJSON(o)
+	delete(o, "status")
+	return o
+}
+
+// A warningRecorder records the first warning event it is asked to record.
+type warningRecorder struct {
+	err error
+}
+
+func (r *warningRecorder) Event(_ runtime.Object, e event.Event) {
+	if e.Type == event.TypeWarning && r.err == nil {
+		r.err = errors.New(e.Message)
+	}
+}
+
+func (r *warningRecorder) WithAnnotations(_ ...string) event.Recorder {
+	return r
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package render
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	kunstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/crossplane/crossplane-runtime/pkg/reconciler/managed"
+	ucomposite "github.com/crossplane/crossplane-runtime/pkg/resource/unstructured/composite"
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+)
+
+func unmarshal(t *testing.T, y string) map[string]interface{} {
+	t.Helper()
+	m := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(y), &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestRender(t *testing.T) {
+	xr := func() *ucomposite.Unstructured {
+		return &ucomposite.Unstructured{Unstructured: kunstructured.Unstructured{Object: unmarshal(t, `
+apiVersion: example.org/v1
+kind: XCool
+metadata:
+  name: cool-xr
+  uid: cool-uid
+spec:
+  region: us-west-2
+  writeConnectionSecretToRef:
+    namespace: default
+    name: cool-secret
+`)}}
+	}
+
+	comp := &v1.Composition{
+		Spec: v1.CompositionSpec{
+			CompositeTypeRef: v1.TypeReference{APIVersion: "example.org/v1", Kind: "XCool"},
+			Resources: []v1.ComposedTemplate{
+				{
+					Name: pointer.StringPtr("network"),
+					Base: runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Network","spec":{"cidr":"10.0.0.0/16"}}`)},
+					Patches: []v1.Patch{
+						{FromFieldPath: pointer.StringPtr("spec.region")},
+						{
+							Type:          v1.PatchTypeToCompositeFieldPath,
+							FromFieldPath: pointer.StringPtr("status.id"),
+							ToFieldPath:   pointer.StringPtr("status.networkId"),
+						},
+					},
+					ConnectionDetails: []v1.ConnectionDetail{{
+						Name:  pointer.StringPtr("region"),
+						Value: pointer.StringPtr("us-west-2"),
+					}},
+					ReadinessChecks: []v1.ReadinessCheck{{Type: v1.ReadinessCheckTypeNonEmpty, FieldPath: "status.id"}},
+				},
+				{
+					Name:      pointer.StringPtr("subnet"),
+					DependsOn: []string{"network"},
+					Base:      runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Subnet"}`)},
+					Patches: []v1.Patch{{
+						Type:             v1.PatchTypeFromComposedFieldPath,
+						FromResourceName: pointer.StringPtr("network"),
+						FromFieldPath:    pointer.StringPtr("status.id"),
+						ToFieldPath:      pointer.StringPtr("spec.networkId"),
+					}},
+					ReadinessChecks: []v1.ReadinessCheck{{Type: v1.ReadinessCheckTypeNone}},
+				},
+			},
+		},
+	}
+
+	type args struct {
+		xr       *ucomposite.Unstructured
+		comp     *v1.Composition
+		observed []client.Object
+	}
+	type want struct {
+		composed []string
+		xrStatus map[string]interface{}
+		conn     managed.ConnectionDetails
+		err      error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"NoName": {
+			reason: "We should return an error if the composite resource has no name.",
+			args: args{
+				xr:   &ucomposite.Unstructured{},
+				comp: comp,
+			},
+			want: want{
+				err: errors.New(errNoName),
+			},
+		},
+		"InvalidComposition": {
+			reason: "We should return an error if the Composition is invalid.",
+			args: args{
+				xr: xr(),
+				comp: &v1.Composition{Spec: v1.CompositionSpec{
+					CompositeTypeRef: v1.TypeReference{APIVersion: "example.org/v1", Kind: "XCool"},
+					Resources: []v1.ComposedTemplate{
+						{Name: pointer.StringPtr("a")},
+						{},
+					},
+				}},
+			},
+			want: want{
+				err: errors.Wrap(errors.New("cannot mix named and anonymous resource templates"), errReconcile),
+			},
+		},
+		"FirstRender": {
+			reason: "Without observed resources we should render only the resources that don't depend on others.",
+			args: args{
+				xr:   xr(),
+				comp: comp,
+			},
+			want: want{
+				composed: []string{"Network"},
+				conn:     managed.ConnectionDetails{"region": []byte("us-west-2")},
+			},
+		},
+		"ObservedRender": {
+			reason: "Observed resources should be used to patch other resources and the composite resource.",
+			args: args{
+				xr: func() *ucomposite.Unstructured {
+					x := xr()
+					x.Object["spec"].(map[string]interface{})["resourceRefs"] = []interface{}{
+						map[string]interface{}{"apiVersion": "example.org/v1", "kind": "Network", "name": "cool-xr-net"},
+					}
+					return x
+				}(),
+				comp: comp,
+				observed: []client.Object{&kunstructured.Unstructured{Object: unmarshal(t, `
+apiVersion: example.org/v1
+kind: Network
+metadata:
+  name: cool-xr-net
+  annotations:
+    crossplane.io/composition-resource-name: network
+status:
+  id: net-1234
+`)}},
+			},
+			want: want{
+				composed: []string{"Network", "Subnet"},
+				xrStatus: map[string]interface{}{"networkId": "net-1234"},
+				conn:     managed.ConnectionDetails{"region": []byte("us-west-2")},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			out, err := Render(context.Background(), tc.args.xr, tc.args.comp, tc.args.observed...)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Fatalf("\n%s\nRender(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if err != nil {
+				return
+			}
+
+			kinds := make([]string, len(out.Composed))
+			for i, cd := range out.Composed {
+				kinds[i] = cd.GetKind()
+				if c := cd.GetOwnerReferences(); len(c) != 1 || c[0].UID != "cool-uid" {
+					t.Errorf("\n%s\nRender(...): composed resource %s is not controlled by the composite resource", tc.reason, cd.GetName())
+				}
+			}
+			if diff := cmp.Diff(tc.want.composed, kinds); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want composed kinds, +got composed kinds:\n%s", tc.reason, diff)
+			}
+			for k, v := range tc.want.xrStatus {
+				if diff := cmp.Diff(v, out.Composite.Object["status"].(map[string]interface{})[k]); diff != "" {
+					t.Errorf("\n%s\nRender(...): -want status.%s, +got status.%s:\n%s", tc.reason, k, k, diff)
+				}
+			}
+			if diff := cmp.Diff(tc.want.conn, out.ConnectionDetails); diff != "" {
+				t.Errorf("\n%s\nRender(...): -want connection details, +got connection details:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package composition implements a validating admission webhook for
+// Compositions.
+package composition
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pkg/errors"
+	admissionv1 "k8s.io/api/admission/v1"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/crossplane/crossplane-runtime/pkg/logging"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/controller/apiextensions/composite"
+)
+
+// Path at which Compositions are validated.
+const Path = "/validate-apiextensions-crossplane-io-v1-composition"
+
+const (
+	errDecode      = "cannot decode Composition"
+	errValidate    = "invalid Composition"
+	errInline      = "cannot inline Composition patch sets"
+	errParseAPIVer = "cannot parse compositeTypeRef apiVersion"
+	errListXRDs    = "cannot list CompositeResourceDefinitions"
+	errListCRDs    = "cannot list CustomResourceDefinitions"
+	errSchemas     = "cannot load schemas"
+
+	errFmtNoXRD = "compositeTypeRef %s %s does not match any CompositeResourceDefinition"
+)
+
+// Setup registers a webhook that validates Compositions with the supplied
+// manager's webhook server.
+func Setup(mgr ctrl.Manager, log logging.Logger) error {
+	v := NewValidator(mgr.GetClient(), WithLogger(log.WithValues("webhook", Path)))
+	mgr.GetWebhookServer().Register(Path, &webhook.Admission{Handler: v})
+	return nil
+}
+
+// A ValidatorOption configures a Validator.
+type ValidatorOption func(*Validator)
+
+// WithLogger specifies how the Validator should log messages.
+func WithLogger(l logging.Logger) ValidatorOption {
+	return func(v *Validator) {
+		v.log = l
+	}
+}
+
+// WithCompositionValidator specifies how the Validator should validate the
+// structure of a Composition.
+func WithCompositionValidator(cv composite.CompositionValidator) ValidatorOption {
+	return func(v *Validator) {
+		v.composition = cv
+	}
+}
+
+// A Validator rejects invalid Compositions at admission time, rather than
+// when they are first used to compose resources.
+type Validator struct {
+	client      client.Reader
+	composition composite.CompositionValidator
+	log         logging.Logger
+}
+
+// NewValidator returns a Validator of Compositions.
+func NewValidator(c client.Reader, opts ...ValidatorOption) *Validator {
+	v := &Validator{
+		client:      c,
+		composition: composite.DefaultValidationChain(),
+		log:         logging.NewNopLogger(),
+	}
+
+	for _, f := range opts {
+		f(v)
+	}
+	return v
+}
+
+// Handle an admission request for a Composition.
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation == admissionv1.Delete {
+		return admission.Allowed("")
+	}
+
+	comp := &v1.Composition{}
+	if err := json.Unmarshal(req.Object.Raw, comp); err != nil {
+		return admission.Errored(http.StatusBadRequest, errors.Wrap(err, errDecode))
+	}
+
+	err := v.Validate(ctx, comp)
+	if isMissingXRD(err) {
+		// The XRD may not exist yet. The package manager dry-run creates all
+		// of a package's objects before it creates any of them, so an XRD
+		// and a Composition of its kind may be admitted in the same
+		// operation. We can't validate against the XRD's schema until it
+		// exists, so we allow the Composition and warn rather than deny.
+		v.log.Debug("Allowing Composition without validating it against its schema", "name", comp.GetName(), "reason", err)
+		return admission.Allowed("").WithWarnings(err.Error())
+	}
+	if err != nil {
+		v.log.Debug("Rejecting Composition", "name", comp.GetName(), "error", err)
+		return admission.Denied(err.Error())
+	}
+
+	return admission.Allowed("")
+}
+
+// A missingXRDError indicates that a Composition could not be validated
+// against the schema of its composite resource, because no XRD defines it.
+type missingXRDError struct{ error }
+
+// isMissingXRD returns true if the supplied error indicates that no XRD
+// defines a Composition's composite resource.
+func isMissingXRD(err error) bool {
+	return errors.As(err, &missingXRDError{})
+}
+
+// Validate the supplied Composition. A Composition whose compositeTypeRef is
+// not defined by any XRD is not validated against its composite resource's
+// schema; an error satisfying isMissingXRD is returned.
+func (v *Validator) Validate(ctx context.Context, comp *v1.Composition) error {
+	if err := v.composition.Validate(comp); err != nil {
+		return errors.Wrap(err, errValidate)
+	}
+
+	// Inlining mutates the spec, so we validate a copy.
+	if err := comp.Spec.DeepCopy().InlinePatchSets(); err != nil {
+		return errors.Wrap(err, errInline)
+	}
+
+	ref := comp.Spec.CompositeTypeRef
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return errors.Wrap(err, errParseAPIVer)
+	}
+
+	l := &v1.CompositeResourceDefinitionList{}
+	if err := v.client.List(ctx, l); err != nil {
+		return errors.Wrap(err, errListXRDs)
+	}
+
+	if !defines(l.Items, gv.Group, gv.Version, ref.Kind) {
+		return missingXRDError{errors.Errorf(errFmtNoXRD, ref.APIVersion, ref.Kind)}
+	}
+
+	crds := &extv1.CustomResourceDefinitionList{}
+	if err := v.client.List(ctx, crds); err != nil {
+		return errors.Wrap(err, errListCRDs)
+	}
+
+	sv, err := composite.NewSchemaValidator(l.Items, crds.Items)
+	if err != nil {
+		return errors.Wrap(err, errSchemas)
+	}
+
+	return errors.Wrap(sv.Validate(comp), errValidate)
+}
+
+// defines returns true if any of the supplied XRDs defines the supplied kind at
+// the supplied group and version.
+func defines(xrds []v1.CompositeResourceDefinition, group, version, kind string) bool {
+	for _, xrd := range xrds {
+		if xrd.Spec.Group != group || xrd.Spec.Names.Kind != kind {
+			continue
+		}
+		for _, vr := range xrd.Spec.Versions {
+			if vr.Name == version {
+				return true
+			}
+		}
+	}
+	return false
+}
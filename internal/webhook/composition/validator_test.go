@@ -0,0 +1,283 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package composition
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	admissionv1 "k8s.io/api/admission/v1"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+
+	v1 "github.com/crossplane/crossplane/apis/apiextensions/v1"
+	"github.com/crossplane/crossplane/internal/controller/apiextensions/composite"
+)
+
+var errBoom = errors.New("boom")
+
+func withXRDs(xrds ...v1.CompositeResourceDefinition) test.MockListFn {
+	return func(_ context.Context, obj client.ObjectList, _ ...client.ListOption) error {
+		if l, ok := obj.(*v1.CompositeResourceDefinitionList); ok {
+			l.Items = xrds
+		}
+		return nil
+	}
+}
+
+func xrd(group, kind string, versions ...string) v1.CompositeResourceDefinition {
+	x := v1.CompositeResourceDefinition{}
+	x.Spec.Group = group
+	x.Spec.Names.Kind = kind
+	for _, v := range versions {
+		x.Spec.Versions = append(x.Spec.Versions, v1.CompositeResourceDefinitionVersion{Name: v})
+	}
+	return x
+}
+
+func comp(apiVersion, kind string) *v1.Composition {
+	c := &v1.Composition{}
+	c.SetName("cool-comp")
+	c.Spec.CompositeTypeRef = v1.TypeReference{APIVersion: apiVersion, Kind: kind}
+	return c
+}
+
+func TestValidate(t *testing.T) {
+	type args struct {
+		kube client.Reader
+		opts []ValidatorOption
+		comp *v1.Composition
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   error
+	}{
+		"InvalidComposition": {
+			reason: "We should return any error encountered validating the structure of the Composition.",
+			args: args{
+				opts: []ValidatorOption{WithCompositionValidator(composite.CompositionValidatorFn(func(_ *v1.Composition) error {
+					return errBoom
+				}))},
+				comp: comp("example.org/v1", "XCool"),
+			},
+			want: errors.Wrap(errBoom, errValidate),
+		},
+		"InvalidTransform": {
+			reason: "The default validation chain should reject invalid transforms.",
+			args: args{
+				comp: func() *v1.Composition {
+					c := comp("example.org/v1", "XCool")
+					c.Spec.Resources = []v1.ComposedTemplate{{
+						Patches: []v1.Patch{{
+							Transforms: []v1.Transform{{Type: v1.TransformTypeMath, Math: &v1.MathTransform{}}},
+						}},
+					}}
+					return c
+				}(),
+			},
+			want: errors.Wrap(errors.New("transform at index 0 of patch at index 0 of resource at index 0 is invalid: math transform is invalid: no input is given"), errValidate),
+		},
+		"InlinePatchSetsError": {
+			reason: "We should return any error encountered inlining patch sets.",
+			args: args{
+				comp: func() *v1.Composition {
+					c := comp("example.org/v1", "XCool")
+					c.Spec.Resources = []v1.ComposedTemplate{{
+						Patches: []v1.Patch{{
+							Type:         v1.PatchTypePatchSet,
+							PatchSetName: pointer.StringPtr("nonexistent-patchset"),
+						}},
+					}}
+					return c
+				}(),
+			},
+			want: errors.Wrap(errors.Errorf("cannot find PatchSet by name %s", "nonexistent-patchset"), errInline),
+		},
+		"ParseAPIVersionError": {
+			reason: "We should return an error if the compositeTypeRef has an invalid apiVersion.",
+			args: args{
+				comp: comp("example.org/v1/extra", "XCool"),
+			},
+			want: errors.Wrap(errors.New("unexpected GroupVersion string: example.org/v1/extra"), errParseAPIVer),
+		},
+		"ListXRDsError": {
+			reason: "We should return any error encountered listing XRDs.",
+			args: args{
+				kube: &test.MockClient{MockList: test.NewMockListFn(errBoom)},
+				comp: comp("example.org/v1", "XCool"),
+			},
+			want: errors.Wrap(errBoom, errListXRDs),
+		},
+		"ListCRDsError": {
+			reason: "We should return any error encountered listing CRDs.",
+			args: args{
+				kube: &test.MockClient{MockList: func(ctx context.Context, obj client.ObjectList, opts ...client.ListOption) error {
+					if _, ok := obj.(*extv1.CustomResourceDefinitionList); ok {
+						return errBoom
+					}
+					return withXRDs(xrd("example.org", "XCool", "v1"))(ctx, obj, opts...)
+				}},
+				comp: comp("example.org/v1", "XCool"),
+			},
+			want: errors.Wrap(errBoom, errListCRDs),
+		},
+		"SchemaMismatch": {
+			reason: "We should return an error if the Composition does not match the composite resource's schema.",
+			args: args{
+				kube: &test.MockClient{MockList: withXRDs(xrd("example.org", "XCool", "v1"))},
+				comp: func() *v1.Composition {
+					c := comp("example.org/v1", "XCool")
+					c.Spec.Resources = []v1.ComposedTemplate{{
+						Base: runtime.RawExtension{Raw: []byte(`{"apiVersion":"example.org/v1","kind":"Composed"}`)},
+						Patches: []v1.Patch{{
+							Type:          v1.PatchTypeFromCompositeFieldPath,
+							FromFieldPath: pointer.StringPtr("spec.nope"),
+						}},
+					}}
+					return c
+				}(),
+			},
+			want: errors.Wrap(errors.New(`resource at index 0 does not match its schema: patch at index 0 is invalid: invalid fromFieldPath "spec.nope": spec.nope: no such field`), errValidate),
+		},
+		"NoMatchingXRD": {
+			reason: "We should return an error indicating the XRD is missing if no XRD defines the compositeTypeRef's kind and version.",
+			args: args{
+				kube: &test.MockClient{MockList: withXRDs(
+					xrd("example.org", "XCool", "v1alpha1"),
+					xrd("example.net", "XCool", "v1"),
+					xrd("example.org", "XWarm", "v1"),
+				)},
+				comp: comp("example.org/v1", "XCool"),
+			},
+			want: missingXRDError{errors.Errorf(errFmtNoXRD, "example.org/v1", "XCool")},
+		},
+		"Valid": {
+			reason: "We should not return an error if the Composition is valid and an XRD defines its compositeTypeRef.",
+			args: args{
+				kube: &test.MockClient{MockList: withXRDs(xrd("example.org", "XCool", "v1alpha1", "v1"))},
+				comp: comp("example.org/v1", "XCool"),
+			},
+			want: nil,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			v := NewValidator(tc.args.kube, tc.args.opts...)
+			got := v.Validate(context.Background(), tc.args.comp)
+			if diff := cmp.Diff(tc.want, got, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nValidate(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestHandle(t *testing.T) {
+	raw := func(c *v1.Composition) []byte {
+		j, _ := json.Marshal(c)
+		return j
+	}
+	request := func(op admissionv1.Operation, obj []byte) admission.Request {
+		return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: op,
+			Object:    runtime.RawExtension{Raw: obj},
+		}}
+	}
+
+	type args struct {
+		kube client.Reader
+		req  admission.Request
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   admission.Response
+	}{
+		"Delete": {
+			reason: "We should allow all deletes.",
+			args: args{
+				req: request(admissionv1.Delete, nil),
+			},
+			want: admission.Allowed(""),
+		},
+		"DecodeError": {
+			reason: "We should return an error if we cannot decode the Composition.",
+			args: args{
+				req: request(admissionv1.Create, []byte("{")),
+			},
+			want: admission.Errored(http.StatusBadRequest, errors.Wrap(errors.New("unexpected end of JSON input"), errDecode)),
+		},
+		"Denied": {
+			reason: "We should deny invalid Compositions.",
+			args: args{
+				kube: &test.MockClient{MockList: withXRDs()},
+				req:  request(admissionv1.Create, raw(comp("example.org/v1/extra", "XCool"))),
+			},
+			want: admission.Denied(errors.Wrap(errors.New("unexpected GroupVersion string: example.org/v1/extra"), errParseAPIVer).Error()),
+		},
+		"DryRunMissingXRD": {
+			reason: "We should allow, with a warning, a dry-run Composition whose XRD does not exist yet, for example because it is being installed by the same package.",
+			args: args{
+				kube: &test.MockClient{MockList: withXRDs()},
+				req: func() admission.Request {
+					r := request(admissionv1.Create, raw(comp("example.org/v1", "XCool")))
+					r.DryRun = pointer.BoolPtr(true)
+					return r
+				}(),
+			},
+			want: admission.Allowed("").WithWarnings(errors.Errorf(errFmtNoXRD, "example.org/v1", "XCool").Error()),
+		},
+		"MissingXRD": {
+			reason: "We should allow, with a warning, a Composition whose XRD does not exist.",
+			args: args{
+				kube: &test.MockClient{MockList: withXRDs(xrd("example.org", "XWarm", "v1"))},
+				req:  request(admissionv1.Create, raw(comp("example.org/v1", "XCool"))),
+			},
+			want: admission.Allowed("").WithWarnings(errors.Errorf(errFmtNoXRD, "example.org/v1", "XCool").Error()),
+		},
+		"Allowed": {
+			reason: "We should allow valid Compositions.",
+			args: args{
+				kube: &test.MockClient{MockList: withXRDs(xrd("example.org", "XCool", "v1"))},
+				req:  request(admissionv1.Update, raw(comp("example.org/v1", "XCool"))),
+			},
+			want: admission.Allowed(""),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			v := NewValidator(tc.args.kube)
+			got := v.Handle(context.Background(), tc.args.req)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("\n%s\nHandle(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
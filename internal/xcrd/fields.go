@@ -0,0 +1,97 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package xcrd
+
+import (
+	"github.com/pkg/errors"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/fieldpath"
+)
+
+// OpenAPI schema types.
+const (
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeObject  = "object"
+	TypeArray   = "array"
+)
+
+const (
+	errFmtParseFieldPath = "cannot parse field path %q"
+	errFmtNoSuchField    = "%s: no such field"
+	errFmtNotArray       = "%s: not an array"
+	errFmtNotObject      = "%s: not an object"
+)
+
+// FieldSchema returns the schema of the field at the supplied path within an
+// object described by the supplied OpenAPI schema. It returns an error if the
+// schema does not permit such a field. A nil schema is returned if the field
+// is permitted but its schema is unknown; for example because the supplied
+// schema is nil, because the field is within an object that preserves unknown
+// fields, or because the field is within an object's metadata, which is
+// validated by the API server rather than by the object's schema.
+func FieldSchema(s *extv1.JSONSchemaProps, path string) (*extv1.JSONSchemaProps, error) { //nolint:gocyclo
+	// This function is a little over our complexity goal, but is easier to
+	// follow as a single walk of the schema.
+
+	segments, err := fieldpath.Parse(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, errFmtParseFieldPath, path)
+	}
+	if len(segments) > 0 && segments[0].Type == fieldpath.SegmentField && segments[0].Field == "metadata" {
+		return nil, nil
+	}
+
+	cur := s
+	for i, sg := range segments {
+		if cur == nil || (cur.XPreserveUnknownFields != nil && *cur.XPreserveUnknownFields) {
+			return nil, nil
+		}
+
+		switch sg.Type {
+		case fieldpath.SegmentIndex:
+			if cur.Type != TypeArray {
+				return nil, errors.Errorf(errFmtNotArray, segments[:i])
+			}
+			if cur.Items == nil {
+				return nil, nil
+			}
+			cur = cur.Items.Schema
+		case fieldpath.SegmentField:
+			if cur.Type != TypeObject && cur.Type != "" {
+				return nil, errors.Errorf(errFmtNotObject, segments[:i])
+			}
+			if p, ok := cur.Properties[sg.Field]; ok {
+				cur = &p
+				continue
+			}
+			if cur.AdditionalProperties != nil && cur.AdditionalProperties.Schema != nil {
+				cur = cur.AdditionalProperties.Schema
+				continue
+			}
+			if cur.AdditionalProperties != nil && cur.AdditionalProperties.Allows {
+				return nil, nil
+			}
+			return nil, errors.Errorf(errFmtNoSuchField, segments[:i+1])
+		}
+	}
+
+	return cur, nil
+}
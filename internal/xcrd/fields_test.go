@@ -0,0 +1,156 @@
+/*
+Copyright 2021 The Crossplane Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package xcrd
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	"github.com/crossplane/crossplane-runtime/pkg/test"
+)
+
+func TestFieldSchema(t *testing.T) {
+	preserve := true
+	str := extv1.JSONSchemaProps{Type: TypeString}
+	s := &extv1.JSONSchemaProps{
+		Type: TypeObject,
+		Properties: map[string]extv1.JSONSchemaProps{
+			"metadata": {Type: TypeObject},
+			"spec": {
+				Type: TypeObject,
+				Properties: map[string]extv1.JSONSchemaProps{
+					"name": str,
+					"tags": {
+						Type:                 TypeObject,
+						AdditionalProperties: &extv1.JSONSchemaPropsOrBool{Schema: &str},
+					},
+					"anything": {
+						Type:                 TypeObject,
+						AdditionalProperties: &extv1.JSONSchemaPropsOrBool{Allows: true},
+					},
+					"unknown": {
+						Type:                   TypeObject,
+						XPreserveUnknownFields: &preserve,
+					},
+					"ports": {
+						Type: TypeArray,
+						Items: &extv1.JSONSchemaPropsOrArray{Schema: &extv1.JSONSchemaProps{
+							Type: TypeObject,
+							Properties: map[string]extv1.JSONSchemaProps{
+								"port": {Type: TypeInteger},
+							},
+						}},
+					},
+				},
+			},
+		},
+	}
+
+	type args struct {
+		s    *extv1.JSONSchemaProps
+		path string
+	}
+	type want struct {
+		s   *extv1.JSONSchemaProps
+		err error
+	}
+
+	cases := map[string]struct {
+		reason string
+		args   args
+		want   want
+	}{
+		"ParseError": {
+			reason: "We should return an error if the field path cannot be parsed.",
+			args:   args{s: s, path: "spec[0"},
+			want: want{
+				err: errors.Wrapf(errors.New("unterminated '[' at position 4"), errFmtParseFieldPath, "spec[0"),
+			},
+		},
+		"NilSchema": {
+			reason: "Any field of an unknown schema should be permitted.",
+			args:   args{path: "spec.name"},
+			want:   want{},
+		},
+		"Metadata": {
+			reason: "Any metadata field should be permitted.",
+			args:   args{s: s, path: "metadata.labels[example.org/cool]"},
+			want:   want{},
+		},
+		"Property": {
+			reason: "We should return the schema of a known property.",
+			args:   args{s: s, path: "spec.name"},
+			want:   want{s: &str},
+		},
+		"UnknownProperty": {
+			reason: "We should return an error if a property is unknown.",
+			args:   args{s: s, path: "spec.nope"},
+			want: want{
+				err: errors.Errorf(errFmtNoSuchField, "spec.nope"),
+			},
+		},
+		"AdditionalProperties": {
+			reason: "We should return the schema of the additional properties of an object.",
+			args:   args{s: s, path: "spec.tags[cool]"},
+			want:   want{s: &str},
+		},
+		"AllowedAdditionalProperties": {
+			reason: "Any field of an object that allows additional properties should be permitted.",
+			args:   args{s: s, path: "spec.anything.at.all"},
+			want:   want{},
+		},
+		"PreserveUnknownFields": {
+			reason: "Any field of an object that preserves unknown fields should be permitted.",
+			args:   args{s: s, path: "spec.unknown.at.all"},
+			want:   want{},
+		},
+		"ArrayElement": {
+			reason: "We should return the schema of an element's field.",
+			args:   args{s: s, path: "spec.ports[0].port"},
+			want:   want{s: &extv1.JSONSchemaProps{Type: TypeInteger}},
+		},
+		"NotArray": {
+			reason: "We should return an error if we index a field that is not an array.",
+			args:   args{s: s, path: "spec.name[0]"},
+			want: want{
+				err: errors.Errorf(errFmtNotArray, "spec.name"),
+			},
+		},
+		"NotObject": {
+			reason: "We should return an error if we index a field that is not an object.",
+			args:   args{s: s, path: "spec.name.first"},
+			want: want{
+				err: errors.Errorf(errFmtNotObject, "spec.name"),
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := FieldSchema(tc.args.s, tc.args.path)
+			if diff := cmp.Diff(tc.want.err, err, test.EquateErrors()); diff != "" {
+				t.Errorf("\n%s\nFieldSchema(...): -want error, +got error:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.want.s, got); diff != "" {
+				t.Errorf("\n%s\nFieldSchema(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -86,6 +86,21 @@ func CompositeResourceSpecProps() map[string]extv1.JSONSchemaProps {
 				},
 			},
 		},
+		"compositionRevisionRef": {
+			Type:     "object",
+			Required: []string{"name"},
+			Properties: map[string]extv1.JSONSchemaProps{
+				"name": {Type: "string"},
+			},
+		},
+		"compositionUpdatePolicy": {
+			Type: "string",
+			Enum: []extv1.JSON{
+				{Raw: []byte(`"Automatic"`)},
+				{Raw: []byte(`"Manual"`)},
+			},
+			Default: &extv1.JSON{Raw: []byte(`"Automatic"`)},
+		},
 		"claimRef": {
 			Type:     "object",
 			Required: []string{"apiVersion", "kind", "namespace", "name"},
@@ -110,6 +125,48 @@ func CompositeResourceSpecProps() map[string]extv1.JSONSchemaProps {
 				},
 			},
 		},
+		"deletionPolicy": {
+			Type: "string",
+			Enum: []extv1.JSON{
+				{Raw: []byte(`"Delete"`)},
+				{Raw: []byte(`"Orphan"`)},
+			},
+		},
+		"publishConnectionDetailsTo": {
+			Type:     "object",
+			Required: []string{"name"},
+			Properties: map[string]extv1.JSONSchemaProps{
+				"name": {Type: "string"},
+				"configRef": {
+					Type:     "object",
+					Required: []string{"name"},
+					Properties: map[string]extv1.JSONSchemaProps{
+						"name": {Type: "string"},
+					},
+					Default: &extv1.JSON{Raw: []byte(`{"name":"default"}`)},
+				},
+			},
+		},
+		"connectionSecretMetadata": {
+			Type: "object",
+			Properties: map[string]extv1.JSONSchemaProps{
+				"labels": {
+					Type: "object",
+					AdditionalProperties: &extv1.JSONSchemaPropsOrBool{
+						Allows: true,
+						Schema: &extv1.JSONSchemaProps{Type: "string"},
+					},
+				},
+				"annotations": {
+					Type: "object",
+					AdditionalProperties: &extv1.JSONSchemaPropsOrBool{
+						Allows: true,
+						Schema: &extv1.JSONSchemaProps{Type: "string"},
+					},
+				},
+				"type": {Type: "string"},
+			},
+		},
 		"writeConnectionSecretToRef": {
 			Type:     "object",
 			Required: []string{"name", "namespace"},
@@ -155,6 +212,26 @@ func CompositeResourceClaimSpecProps() map[string]extv1.JSONSchemaProps {
 				"name":       {Type: "string"},
 			},
 		},
+		"connectionSecretMetadata": {
+			Type: "object",
+			Properties: map[string]extv1.JSONSchemaProps{
+				"labels": {
+					Type: "object",
+					AdditionalProperties: &extv1.JSONSchemaPropsOrBool{
+						Allows: true,
+						Schema: &extv1.JSONSchemaProps{Type: "string"},
+					},
+				},
+				"annotations": {
+					Type: "object",
+					AdditionalProperties: &extv1.JSONSchemaPropsOrBool{
+						Allows: true,
+						Schema: &extv1.JSONSchemaProps{Type: "string"},
+					},
+				},
+				"type": {Type: "string"},
+			},
+		},
 		"writeConnectionSecretToRef": {
 			Type:     "object",
 			Required: []string{"name"},
@@ -191,6 +268,30 @@ func CompositeResourceStatusProps() map[string]extv1.JSONSchemaProps {
 			Type: "object",
 			Properties: map[string]extv1.JSONSchemaProps{
 				"lastPublishedTime": {Type: "string", Format: "date-time"},
+				"lastRotatedTime":   {Type: "string", Format: "date-time"},
+			},
+		},
+		"resources": {
+			Description: "Resources reports the status of each composed resource.",
+			Type:        "array",
+			Items: &extv1.JSONSchemaPropsOrArray{
+				Schema: &extv1.JSONSchemaProps{
+					Type: "object",
+					Properties: map[string]extv1.JSONSchemaProps{
+						"templateName": {Type: "string"},
+						"kind":         {Type: "string"},
+						"name":         {Type: "string"},
+						"ready":        {Type: "boolean"},
+						"synced":       {Type: "boolean"},
+						"message":      {Type: "string"},
+						"blockedOn": {
+							Type: "array",
+							Items: &extv1.JSONSchemaPropsOrArray{
+								Schema: &extv1.JSONSchemaProps{Type: "string"},
+							},
+						},
+					},
+				},
 			},
 		},
 	}